@@ -36,21 +36,34 @@ func init() {
 	sendCmd.Flags().String("link", "", "Link message content.")
 	sendCmd.Flags().String("image", "", "Image file path.")
 	sendCmd.Flags().String("audio", "", "Audio file path.")
+	sendCmd.Flags().String("video", "", "Video file path.")
+	sendCmd.Flags().String("poster", "", "Poster image file path for video message.")
 	sendCmd.Flags().String("file", "", "File path.")
 	sendCmd.Flags().String("title", "", "Message title.")
 	sendCmd.Flags().String("copy", "", "Copy test for text message.")
 	sendCmd.Flags().String("autocopy", "", "Auto copy text for text message.")
+	sendCmd.Flags().Bool("markdown", false, "Send text message as markdown.")
 	sendCmd.Flags().StringArray("action", []string{}, "Action item for action message.")
 	sendCmd.Flags().Int("priority", 0, "Message priority.")
 	sendCmd.Flags().String("interruption-level", "", "Interruption level for message.")
+	sendCmd.Flags().String("batch", "", "Batch window for message (e.g. 5m).")
+	sendCmd.Flags().String("ttl", "", "Time to live of message (e.g. 10m), 0 to deliver now or drop.")
+	sendCmd.Flags().Bool("critical", false, "Send message as critical alert.")
+	sendCmd.Flags().Float64("volume", 0, "Volume for critical alert (0.0-1.0).")
+	sendCmd.Flags().Bool("ack", false, "Track acknowledgement of message.")
+	sendCmd.Flags().String("ack-callback", "", "Callback url when message is acknowledged.")
+	sendCmd.Flags().String("repeat", "", "Repeat interval until message is acknowledged (e.g. 5m).")
+	sendCmd.Flags().Int("repeat-count", 0, "Max repeat count until message is acknowledged.")
 	sendCmd.Flags().String("timeline.code", "", "Code for timeline message.")
 	sendCmd.Flags().String("timeline.timestamp", "", "Timestamp for timeline message.")
+	sendCmd.Flags().String("timeline.items", "", `Ordered items for timeline message in JSON (e.g. [{"name":"temp","value":21.5,"unit":"°C"}]).`)
 	viper.BindPFlag("client.token", sendCmd.Flags().Lookup("token"))                           // nolint: errcheck
 	viper.BindPFlag("client.sound", sendCmd.Flags().Lookup("sound"))                           // nolint: errcheck
 	viper.BindPFlag("client.autocopy", sendCmd.Flags().Lookup("autocopy"))                     // nolint: errcheck
 	viper.BindPFlag("client.priority", sendCmd.Flags().Lookup("priority"))                     // nolint: errcheck
 	viper.BindPFlag("client.endpoint", sendCmd.Flags().Lookup("endpoint"))                     // nolint: errcheck
 	viper.BindPFlag("client.interruption-level", sendCmd.Flags().Lookup("interruption-level")) // nolint: errcheck
+	viper.BindPFlag("client.batch", sendCmd.Flags().Lookup("batch"))                           // nolint: errcheck
 }
 
 func runSendCmd(cmd *cobra.Command, args []string) error {
@@ -59,6 +72,7 @@ func runSendCmd(cmd *cobra.Command, args []string) error {
 	sound := viper.GetString("client.sound")
 	priority := viper.GetInt("client.priority")
 	interruptionLevel := viper.GetString("client.interruption-level")
+	batch := viper.GetString("client.batch")
 	token := viper.GetString("client.token")
 	if len(token) <= 0 {
 		return errors.New("send token not found")
@@ -79,6 +93,24 @@ func runSendCmd(cmd *cobra.Command, args []string) error {
 	setFieldValue(w, "sound", []byte(sound))
 	setFieldValueInt(w, "priority", priority)
 	setFieldValue(w, "interruption-level", []byte(interruptionLevel))
+	setFieldValue(w, "batch", []byte(batch))
+	if critical, _ := cmd.Flags().GetBool("critical"); critical {
+		setFieldValue(w, "critical", []byte("1"))
+	}
+	if volume, _ := cmd.Flags().GetFloat64("volume"); volume > 0 {
+		setFieldValue(w, "volume", []byte(strconv.FormatFloat(volume, 'f', -1, 64)))
+	}
+	if ack, _ := cmd.Flags().GetBool("ack"); ack {
+		setFieldValue(w, "ack", []byte("1"))
+	}
+	ackCallback, _ := cmd.Flags().GetString("ack-callback")
+	setFieldValue(w, "ack-callback", []byte(ackCallback))
+	repeat, _ := cmd.Flags().GetString("repeat")
+	setFieldValue(w, "repeat", []byte(repeat))
+	repeatCount, _ := cmd.Flags().GetInt("repeat-count")
+	setFieldValueInt(w, "repeat-count", repeatCount)
+	ttl, _ := cmd.Flags().GetString("ttl")
+	setFieldValue(w, "ttl", []byte(ttl))
 	w.Close()
 	return sendMessage(&data, w.FormDataContentType())
 }
@@ -96,6 +128,31 @@ func parseTimelineMessage(cmd *cobra.Command, code string, w *multipart.Writer)
 		}
 		setFieldValue(w, "timeline-timestamp", []byte(ts.Format(time.RFC3339Nano)))
 	}
+	items, _ := flags.GetString("timeline.items")
+	if len(items) > 0 {
+		var lst []struct {
+			Name  string      `json:"name"`
+			Value interface{} `json:"value"`
+			Unit  string      `json:"unit"`
+		}
+		if err := json.Unmarshal([]byte(items), &lst); err != nil {
+			return fmt.Errorf("Invalid timeline items: %v", err)
+		}
+		for i, item := range lst {
+			var value string
+			switch v := item.Value.(type) {
+			case float64:
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				value = strconv.FormatBool(v)
+			case string:
+				value = v
+			default:
+				return fmt.Errorf("Invalid timeline item: %d", i)
+			}
+			setFieldValue(w, "timeline-item", []byte(item.Name+"="+value+item.Unit))
+		}
+	}
 	for _, arg := range cmd.Flags().Args() {
 		parts := strings.SplitN(arg, "=", 2)
 		if len(parts) > 1 {
@@ -105,7 +162,7 @@ func parseTimelineMessage(cmd *cobra.Command, code string, w *multipart.Writer)
 				value = "0"
 			}
 			if len(key) > 0 {
-				setFieldValue(w, fmt.Sprintf("timeline-items[%s]", key), []byte(value))
+				setFieldValue(w, "timeline-item", []byte(key+"="+value))
 				continue
 			}
 		}
@@ -131,6 +188,10 @@ func parseOtherMessage(cmd *cobra.Command, w *multipart.Writer) error {
 	image, _, _ := readInputFile(imagePath)
 	audioPath, _ := flags.GetString("audio")
 	audio, faudio, _ := readInputFile(audioPath)
+	videoPath, _ := flags.GetString("video")
+	video, fvideo, _ := readInputFile(videoPath)
+	posterPath, _ := flags.GetString("poster")
+	poster, _, _ := readInputFile(posterPath)
 	filePath, err := flags.GetString("file")
 	if err != nil {
 		return err
@@ -139,13 +200,18 @@ func parseOtherMessage(cmd *cobra.Command, w *multipart.Writer) error {
 	if err != nil {
 		return err
 	}
-	if len(text) <= 0 && len(image) <= 0 && len(link) <= 0 && len(file) <= 0 && len(audio) <= 0 {
+	if len(text) <= 0 && len(image) <= 0 && len(link) <= 0 && len(file) <= 0 && len(audio) <= 0 && len(video) <= 0 {
 		return errors.New("no message content")
 	}
 	setFieldValue(w, "text", []byte(text))
+	if markdown, _ := flags.GetBool("markdown"); markdown {
+		setFieldValue(w, "format", []byte("markdown"))
+	}
 	setFieldValue(w, "link", []byte(link))
 	setFieldFile(w, "image", "image", image)
 	setFieldFile(w, "audio", fixFilename(faudio, "audio"), audio)
+	setFieldFile(w, "video", fixFilename(fvideo, "video"), video)
+	setFieldFile(w, "poster", "poster", poster)
 	setFieldFile(w, "file", filename, file)
 	setFieldValue(w, "title", []byte(title))
 	copytext, _ := flags.GetString("copy")
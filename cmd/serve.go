@@ -46,15 +46,21 @@ func init() {
 				defer c.Close()
 				endpoint := getEndpoint()
 				opts := &logic.Options{
-					Name:       getName(),
-					Version:    Version,
-					Endpoint:   endpoint,
-					DataPath:   getExpandPath("server.datapath"),
-					FilePath:   getExpandPath("server.filepath"),
-					PluginPath: getExpandPath("server.pluginpath"),
-					DBUrl:      viper.GetString("server.dburl"),
-					Secret:     viper.GetString("server.secret"),
-					WebHooks:   getWebhooks(),
+					Name:          getName(),
+					Version:       Version,
+					Endpoint:      endpoint,
+					DataPath:      getExpandPath("server.datapath"),
+					FilePath:      getExpandPath("server.filepath"),
+					ImageMaxSize:  viper.GetInt("server.image.maxsize"),
+					PluginPath:    getExpandPath("server.pluginpath"),
+					DBUrl:         viper.GetString("server.dburl"),
+					Secret:        viper.GetString("server.secret"),
+					WebHooks:      getWebhooks(),
+					Templates:     getTemplates(),
+					Filters:       getFilters(),
+					Batches:       getOptionList(viper.Get("server.batch")),
+					Policy:        viper.GetStringMap("server.policy"),
+					CallbackHosts: viper.GetStringSlice("server.callback.allow-hosts"),
 				}
 				opts.Registerable, opts.RegUsers = getUserWhitlist(cmd)
 				if err := c.Init(opts); err != nil {
@@ -87,6 +93,7 @@ func init() {
 	serveCmd.Flags().String("name", "", "Http service name")
 	serveCmd.Flags().String("datapath", "~/.chanify", "Data file path")
 	serveCmd.Flags().String("filepath", "", "Store file path")
+	serveCmd.Flags().Int("imagemaxsize", 0, "Max width or height for stored images, 0 to disable resizing")
 	serveCmd.Flags().String("pluginpath", "~/.chanify/plugin", "Plugin file path")
 	serveCmd.Flags().String("dburl", "", "Databse dsn uri")
 	serveCmd.Flags().String("secret", "", "Secret key for serverless mode")
@@ -100,6 +107,7 @@ func init() {
 	viper.BindPFlag("server.name", serveCmd.Flags().Lookup("name"))                      // nolint: errcheck
 	viper.BindPFlag("server.datapath", serveCmd.Flags().Lookup("datapath"))              // nolint: errcheck
 	viper.BindPFlag("server.filepath", serveCmd.Flags().Lookup("filepath"))              // nolint: errcheck
+	viper.BindPFlag("server.image.maxsize", serveCmd.Flags().Lookup("imagemaxsize"))     // nolint: errcheck
 	viper.BindPFlag("server.pluginpath", serveCmd.Flags().Lookup("pluginpath"))          // nolint: errcheck
 	viper.BindPFlag("server.dburl", serveCmd.Flags().Lookup("dburl"))                    // nolint: errcheck
 	viper.BindPFlag("server.secret", serveCmd.Flags().Lookup("secret"))                  // nolint: errcheck
@@ -164,23 +172,45 @@ func getUserWhitlist(cmd *cobra.Command) (bool, []string) {
 func getWebhooks() []map[string]interface{} {
 	plugin := viper.GetStringMap("server.plugin")
 	if whs, ok := plugin["webhook"]; ok {
-		if whss, ok := whs.([]interface{}); ok {
-			ret := []map[string]interface{}{}
-			for _, wh := range whss {
-				ww := map[string]interface{}{}
-				if webhook, ok := wh.(map[interface{}]interface{}); ok {
-					for k, v := range webhook {
-						if key, ok := k.(string); ok {
-							ww[key] = v
-						}
+		return getOptionList(whs)
+	}
+	return nil
+}
+
+func getFilters() []map[string]interface{} {
+	plugin := viper.GetStringMap("server.plugin")
+	if fts, ok := plugin["filter"]; ok {
+		return getOptionList(fts)
+	}
+	return nil
+}
+
+func getTemplates() []map[string]interface{} {
+	return getOptionList(viper.Get("server.templates"))
+}
+
+func getOptionList(value interface{}) []map[string]interface{} {
+	if items, ok := value.([]interface{}); ok {
+		ret := []map[string]interface{}{}
+		for _, item := range items {
+			opts := map[string]interface{}{}
+			switch val := item.(type) {
+			case map[interface{}]interface{}:
+				for k, v := range val {
+					if key, ok := k.(string); ok {
+						opts[key] = v
 					}
 				}
-				if len(ww) > 0 {
-					ret = append(ret, ww)
+			case map[string]interface{}:
+				for k, v := range val {
+					opts[k] = v
 				}
 			}
-			return ret
+			if len(opts) > 0 {
+				ret = append(ret, opts)
+			}
 		}
+		return ret
 	}
 	return nil
 }
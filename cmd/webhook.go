@@ -0,0 +1,217 @@
+//go:build !test
+// +build !test
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/chanify/chanify/core"
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/pb"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	webhookCmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Webhook plugin tools",
+		Long:  "Tools for developing webhook plugins.",
+	}
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Dry-run webhook plugin",
+		Long:  "Run webhook plugin with local request, messages are printed instead of sent.",
+		RunE:  runWebhookTestCmd,
+	}
+	webhookCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(webhookCmd)
+	testCmd.Flags().String("name", "", "Webhook name.")
+	testCmd.Flags().String("file", "", "Webhook lua script, relative to plugin path.")
+	testCmd.Flags().String("handler", "", "Native webhook handler name.")
+	testCmd.Flags().String("pluginpath", "", "Plugin path.")
+	testCmd.Flags().String("body", "", "Request body file path, '-' for stdin.")
+	testCmd.Flags().String("token", "", "Send token.")
+	testCmd.Flags().StringArray("header", []string{}, "Request header (key=value).")
+	testCmd.Flags().StringArray("query", []string{}, "Request query (key=value).")
+	testCmd.Flags().StringArray("env", []string{}, "Webhook env (key=value).")
+}
+
+func runWebhookTestCmd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	name, _ := cmd.Flags().GetString("name")
+	name = strings.ToLower(name)
+	if len(name) <= 0 {
+		return errors.New("webhook name not found")
+	}
+	wh, err := getTestWebhook(cmd, name)
+	if err != nil {
+		return err
+	}
+	req, err := getTestWebhookRequest(cmd, name)
+	if err != nil {
+		return err
+	}
+	pluginPath, _ := cmd.Flags().GetString("pluginpath")
+	if len(pluginPath) > 0 {
+		if p, err := homedir.Expand(pluginPath); err == nil {
+			pluginPath = p
+		}
+	} else {
+		pluginPath = getExpandPath("server.pluginpath")
+	}
+	secret := make([]byte, 16)
+	rand.Read(secret) // nolint: errcheck
+	c := core.New()
+	defer c.Close()
+	if err := c.Init(&logic.Options{
+		Name:       getName(),
+		Version:    Version,
+		PluginPath: pluginPath,
+		DBUrl:      "nosql://?secret=" + hex.EncodeToString(secret),
+		WebHooks:   []map[string]interface{}{wh},
+		Templates:  getTemplates(),
+	}); err != nil {
+		return err
+	}
+	ret, err := c.DryRunWebhook(name, req)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Status:", ret.Code)
+	if len(ret.ContentType) > 0 {
+		fmt.Println("Content-Type:", ret.ContentType)
+	}
+	fmt.Println("Body:", ret.Body)
+	fmt.Println("Messages:", len(ret.Messages))
+	for idx, msg := range ret.Messages {
+		fmt.Printf("--- message %d ---\n", idx+1)
+		if msg.Sound != nil {
+			fmt.Println("Sound:", msg.Sound.Name)
+			if msg.IsCritical() {
+				fmt.Println("Critical-Volume:", msg.Sound.Volume)
+			}
+		}
+		fmt.Println("Priority:", msg.Priority)
+		fmt.Println("Interruption-Level:", msg.InterruptionLevel)
+		var content pb.MsgContent
+		if err := proto.Unmarshal(msg.Content, &content); err != nil {
+			return err
+		}
+		data, err := protojson.MarshalOptions{Multiline: true}.Marshal(&content)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Content:", string(data))
+	}
+	return nil
+}
+
+func getTestWebhook(cmd *cobra.Command, name string) (map[string]interface{}, error) {
+	wh := map[string]interface{}{}
+	for _, item := range getWebhooks() {
+		if n, ok := item["name"].(string); ok && strings.ToLower(n) == name {
+			for k, v := range item {
+				wh[k] = v
+			}
+			break
+		}
+	}
+	wh["name"] = name
+	if file, _ := cmd.Flags().GetString("file"); len(file) > 0 {
+		wh["file"] = file
+		delete(wh, "handler")
+	}
+	if handler, _ := cmd.Flags().GetString("handler"); len(handler) > 0 {
+		wh["handler"] = handler
+		delete(wh, "file")
+	}
+	if _, ok := wh["file"]; !ok {
+		if _, ok := wh["handler"]; !ok {
+			return nil, fmt.Errorf("webhook %s not found", name)
+		}
+	}
+	envs, _ := cmd.Flags().GetStringArray("env")
+	if len(envs) > 0 {
+		env := map[string]interface{}{}
+		for _, item := range getOptionList([]interface{}{wh["env"]}) {
+			for k, v := range item {
+				env[k] = v
+			}
+		}
+		for _, e := range envs {
+			k, v, err := parseKeyValue(e)
+			if err != nil {
+				return nil, err
+			}
+			env[k] = v
+		}
+		wh["env"] = env
+	}
+	return wh, nil
+}
+
+func getTestWebhookRequest(cmd *cobra.Command, name string) (*http.Request, error) {
+	var body []byte
+	if path, _ := cmd.Flags().GetString("body"); len(path) > 0 {
+		var err error
+		if path == "-" {
+			body, err = io.ReadAll(os.Stdin)
+		} else {
+			body, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	query := url.Values{}
+	queries, _ := cmd.Flags().GetStringArray("query")
+	for _, q := range queries {
+		k, v, err := parseKeyValue(q)
+		if err != nil {
+			return nil, err
+		}
+		query.Add(k, v)
+	}
+	u := "/v1/webhook/" + name
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	headers, _ := cmd.Flags().GetStringArray("header")
+	for _, h := range headers {
+		k, v, err := parseKeyValue(h)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add(k, v)
+	}
+	if token, _ := cmd.Flags().GetString("token"); len(token) > 0 {
+		req.Header.Set("token", token)
+	}
+	return req, nil
+}
+
+func parseKeyValue(item string) (string, string, error) {
+	kv := strings.SplitN(item, "=", 2)
+	if len(kv) != 2 || len(kv[0]) <= 0 {
+		return "", "", fmt.Errorf("invalid key value: %s", item)
+	}
+	return kv[0], kv[1], nil
+}
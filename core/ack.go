@@ -0,0 +1,147 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRepeatCount = 3
+	ackPollInterval    = time.Second
+	ackPollTimeout     = 30 * time.Second
+	ackPollMaxTimeout  = 60 * time.Second
+)
+
+func (c *Core) handleAck(ctx *gin.Context) {
+	var params struct {
+		Nonce      uint64 `json:"nonce"`
+		DeviceID   string `json:"device"`
+		UserID     string `json:"user"`
+		RequestUID string `json:"request-uid"`
+	}
+	if err := c.bindBodyJSON(ctx, &params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid params"})
+		return
+	}
+	if !c.verifyServerfulUser(ctx, params.UserID) {
+		return
+	}
+	dev, err := c.logic.GetUserDeviceKey(params.UserID, params.DeviceID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid device id"})
+		return
+	}
+	if !verifyDevice(ctx, crypto.Base64Encode.EncodeToString(dev)) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"res": http.StatusUnauthorized, "msg": "invalid device sign"})
+		return
+	}
+	rec, first, err := c.logic.AckMessage(params.RequestUID, params.UserID, params.DeviceID)
+	if err != nil {
+		if err == logic.ErrNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"res": http.StatusNotFound, "msg": "no request found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "ack message failed"})
+		return
+	}
+	if first && len(rec.Callback) > 0 {
+		c.wg.Add(1)
+		go c.notifyAckCallback(params.RequestUID, rec)
+	}
+	ctx.JSON(http.StatusOK, ackResult(params.RequestUID, rec))
+}
+
+// handleSenderAck long-poll acknowledgement of request until acked or timeout, only for the user of sender token
+func (c *Core) handleSenderAck(ctx *gin.Context) {
+	token, err := c.parseToken(getToken(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"res": http.StatusUnauthorized, "msg": "invalid token"})
+		return
+	}
+	reqID := ctx.Param("request-uid")
+	timeout := ackPollTimeout
+	if t := ctx.Query("timeout"); len(t) > 0 {
+		if n, err := strconv.Atoi(t); err == nil && n >= 0 {
+			timeout = time.Duration(n) * time.Second
+		}
+	}
+	if timeout > ackPollMaxTimeout {
+		timeout = ackPollMaxTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(ackPollInterval)
+	defer ticker.Stop()
+	for {
+		rec, err := c.logic.GetAck(reqID)
+		if err == nil && rec.UID != token.GetUserID() {
+			err = logic.ErrNotFound
+		}
+		if err != nil {
+			if err == logic.ErrNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"res": http.StatusNotFound, "msg": "no request found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "get ack failed"})
+			return
+		}
+		if rec.Acked > 0 || !time.Now().Before(deadline) {
+			ctx.JSON(http.StatusOK, ackResult(reqID, rec))
+			return
+		}
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// repeatMessage push message again if request is not acked yet
+func (c *Core) repeatMessage(reqID string) {
+	rec, msg, err := c.logic.NextRepeat(reqID)
+	if err != nil {
+		log.Println("Repeat message failed:", fixLog(reqID), err)
+		return
+	}
+	if msg == nil {
+		return
+	}
+	lc := &luaSendContext{}
+	if !c.pushAPNS(lc, reqID, rec.UID, msg) {
+		log.Println("Repeat message failed:", fixLog(reqID), lc.String())
+	}
+}
+
+func (c *Core) notifyAckCallback(reqID string, rec *logic.AckRecord) {
+	defer c.wg.Done()
+	if err := c.logic.CheckCallbackURL(rec.Callback); err != nil {
+		log.Println("Notify ack callback failed:", err)
+		return
+	}
+	data, _ := json.Marshal(ackResult(reqID, rec))
+	resp, err := callbackClient.Post(rec.Callback, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Println("Notify ack callback failed:", err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024)) // nolint: errcheck
+}
+
+func ackResult(reqID string, rec *logic.AckRecord) gin.H {
+	res := gin.H{"request-uid": reqID, "acked": rec.Acked > 0}
+	if rec.Acked > 0 {
+		res["device"] = rec.Device
+		res["ack-time"] = rec.Acked
+	}
+	return res
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+)
+
+func TestAckMessage(t *testing.T) {
+	acked := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		acked <- string(data)
+	}))
+	defer srv.Close()
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true, CallbackHosts: []string{"127.0.0.1"}}) // nolint: errcheck
+	sk, uid := newDNDTestUser(t, c, false)
+	dk := crypto.GenerateSecretKey([]byte("device"))
+	h := sha1.Sum(dk.MarshalPublicKey())
+	devID := strings.ToUpper(hex.EncodeToString(h[:]))
+	c.logic.BindDevice(uid, devID, dk.EncodePublicKey(), 1) // nolint: errcheck
+	c.logic.UpdatePushToken(uid, devID, "aGVsbG8", false)   // nolint: errcheck
+	pusher := &MockAPNSPusher{}
+	logic.MockPusher = pusher
+	defer func() { logic.MockPusher = nil }()
+
+	tk := newDNDTestToken(uid)
+	lc := &luaSendContext{}
+	c.sendMsg(lc, tk, model.NewMessage(tk).TextContent("hello", "", "", "").SetAck(&model.AckOptions{Callback: srv.URL, Interval: time.Minute, Repeat: 2}))
+	var res struct {
+		UID string `json:"request-uid"`
+	}
+	json.Unmarshal([]byte(lc.String()), &res) // nolint: errcheck
+	if lc.code != http.StatusOK || len(res.UID) <= 0 {
+		t.Fatal("Send ack message failed:", lc.code, lc.String())
+	}
+	data, _ := json.Marshal(pusher.Notification.Payload)
+	if !strings.Contains(string(data), `"req":"`+res.UID+`"`) {
+		t.Error("Check request uid in payload failed:", string(data))
+	}
+
+	pusher.Notification = nil
+	c.deliverHeldMessages(time.Now().Add(time.Minute))
+	if pusher.Notification == nil || pusher.Notification.ApnsID != res.UID {
+		t.Error("Check repeat message failed")
+	}
+
+	handler := c.APIHandler()
+	sender := newSignedTestToken(t, c, uid)
+	getWith := func(path string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if len(token) > 0 {
+			req.Header.Set("token", token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		return getWith(path, sender)
+	}
+	if w := getWith("/v1/sender/ack/"+res.UID+"?timeout=0", ""); w.Code != http.StatusUnauthorized {
+		t.Error("Check ack without token failed:", w.Code)
+	}
+	osk := crypto.GenerateSecretKey([]byte("other"))
+	ouid := osk.ToID(0x00)
+	c.logic.UpsertUser(ouid, osk.EncodePublicKey(), false) // nolint: errcheck
+	if w := getWith("/v1/sender/ack/"+res.UID+"?timeout=0", newSignedTestToken(t, c, ouid)); w.Code != http.StatusNotFound {
+		t.Error("Check ack of other user failed:", w.Code)
+	}
+	if w := get("/v1/sender/ack/" + res.UID + "?timeout=0"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"acked":false`) {
+		t.Error("Check not acked failed:", w.Code, w.Body.String())
+	}
+	if w := get("/v1/sender/ack/abc?timeout=0"); w.Code != http.StatusNotFound {
+		t.Error("Check unknown request failed:", w.Code)
+	}
+
+	post := func(body string, dev bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/rest/v1/ack", strings.NewReader(body))
+		sig, _ := sk.Sign([]byte(body))
+		req.Header.Set("CHUserSign", crypto.Base64Encode.EncodeToString(sig))
+		if dev {
+			sig, _ = dk.Sign([]byte(body))
+			req.Header.Set("CHDevSign", crypto.Base64Encode.EncodeToString(sig))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"nonce":1,"user":"` + uid + `","device":"` + devID + `","request-uid":"` + res.UID + `"}`
+	if w := post(body, false); w.Code != http.StatusUnauthorized {
+		t.Error("Check ack without device sign failed:", w.Code)
+	}
+	if w := post(`{"nonce":2,"user":"`+uid+`","device":"DEV2","request-uid":"`+res.UID+`"}`, true); w.Code != http.StatusBadRequest {
+		t.Error("Check ack invalid device failed:", w.Code)
+	}
+	odk := crypto.GenerateSecretKey([]byte("other device"))
+	oh := sha1.Sum(odk.MarshalPublicKey())
+	odevID := strings.ToUpper(hex.EncodeToString(oh[:]))
+	c.logic.BindDevice(ouid, odevID, odk.EncodePublicKey(), 1) // nolint: errcheck
+	obody := `{"nonce":4,"user":"` + uid + `","device":"` + odevID + `","request-uid":"` + res.UID + `"}`
+	req := httptest.NewRequest("POST", "/rest/v1/ack", strings.NewReader(obody))
+	sig, _ := sk.Sign([]byte(obody))
+	req.Header.Set("CHUserSign", crypto.Base64Encode.EncodeToString(sig))
+	sig, _ = odk.Sign([]byte(obody))
+	req.Header.Set("CHDevSign", crypto.Base64Encode.EncodeToString(sig))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Error("Check ack with device of other user failed:", w.Code)
+	}
+	if w := post(`{"nonce":3,"user":"`+uid+`","device":"`+devID+`","request-uid":"abc"}`, true); w.Code != http.StatusNotFound {
+		t.Error("Check ack unknown request failed:", w.Code)
+	}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- get("/v1/sender/ack/" + res.UID + "?timeout=5") }()
+	if w := post(body, true); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"acked":true`) {
+		t.Fatal("Ack message failed:", w.Code, w.Body.String())
+	}
+	if w := <-done; w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"device":"`+devID+`"`) {
+		t.Error("Check long-poll ack failed:", w.Code, w.Body.String())
+	}
+	select {
+	case s := <-acked:
+		if !strings.Contains(s, `"request-uid":"`+res.UID+`"`) || !strings.Contains(s, `"acked":true`) {
+			t.Error("Check ack callback failed:", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Check ack callback timeout")
+	}
+	pusher.Notification = nil
+	c.deliverHeldMessages(time.Now().Add(2 * time.Minute))
+	if pusher.Notification != nil {
+		t.Error("Check repeat acked message failed")
+	}
+
+	lc = &luaSendContext{}
+	c.sendMsg(lc, tk, model.NewMessage(tk).TextContent("hello", "", "", "").SetAck(&model.AckOptions{Callback: "ftp://127.0.0.1"}))
+	if lc.code != http.StatusBadRequest {
+		t.Error("Check invalid ack options failed:", lc.code)
+	}
+	lc = &luaSendContext{}
+	c.sendMsg(lc, tk, model.NewMessage(tk).TextContent("hello", "", "", "").SetAck(&model.AckOptions{Callback: "http://localhost/admin"}))
+	if lc.code != http.StatusBadRequest || !strings.Contains(lc.String(), "host not allowed") {
+		t.Error("Check ack callback host not allowed failed:", lc.code, lc.String())
+	}
+}
+
+func TestMsgParamAckOptions(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory"}) // nolint: errcheck
+	if (&MsgParam{}).ackOptions() != nil {
+		t.Error("Check no ack options failed")
+	}
+	if opts := (&MsgParam{Ack: "1"}).ackOptions(); opts == nil || opts.Repeat != 0 {
+		t.Error("Check ack options failed:", opts)
+	}
+	if opts := (&MsgParam{Repeat: "5m"}).ackOptions(); opts == nil || opts.Repeat != defaultRepeatCount || opts.Interval != 5*time.Minute {
+		t.Error("Check repeat options failed:", opts)
+	}
+	if opts := (&MsgParam{Repeat: "5m", RepeatCount: parseRepeatCount("x")}).ackOptions(); c.logic.ValidateAck(opts) != logic.ErrInvalidAck {
+		t.Error("Check invalid repeat count failed:", opts)
+	}
+}
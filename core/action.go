@@ -0,0 +1,164 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+)
+
+// callbackClient never follows redirects, so callbacks only reach allowed hosts
+var callbackClient = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// ActionParam structured action item
+type ActionParam struct {
+	Type   string `json:"type,omitempty"`
+	Name   string `json:"name"`
+	Link   string `json:"link,omitempty"`
+	Method string `json:"method,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+// UnmarshalJSON accept both "name|link" and action object
+func (a *ActionParam) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = *parseActionParam(s)
+		return nil
+	}
+	type actionParam ActionParam
+	return json.Unmarshal(data, (*actionParam)(a))
+}
+
+func parseActionParam(action string) *ActionParam {
+	ss := strings.SplitN(action, "|", 2)
+	act := &ActionParam{Name: ss[0]}
+	if len(ss) > 1 {
+		act.Link = ss[1]
+	}
+	return act
+}
+
+func (c *Core) makeActions(uid string, params []*ActionParam) ([]string, error) {
+	actions := []string{}
+	for _, p := range params {
+		act, err := c.makeAction(uid, p)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, act)
+	}
+	return actions, nil
+}
+
+func (c *Core) makeAction(uid string, p *ActionParam) (string, error) {
+	if p == nil || len(p.Name) <= 0 {
+		return "", logic.ErrInvalidAction
+	}
+	switch strings.ToLower(p.Type) {
+	case "", "url":
+		if len(p.Link) <= 0 {
+			return "", logic.ErrInvalidAction
+		}
+		return p.Name + "|" + p.Link, nil
+	case model.SysActionCopy:
+		text := tryStringValue(p.Body, p.Link)
+		if len(text) <= 0 {
+			return "", logic.ErrInvalidAction
+		}
+		return model.SysAction(p.Name, model.SysActionCopy, url.Values{"text": {text}}), nil
+	case model.SysActionAck:
+		return model.SysAction(p.Name, model.SysActionAck, nil), nil
+	case model.SysActionCallback:
+		cb, err := c.logic.NewCallback(uid, p.Link, p.Method, p.Body)
+		if err != nil {
+			return "", err
+		}
+		return model.SysAction(p.Name, model.SysActionCallback, url.Values{"url": {c.logic.MakeCallbackURL(cb)}}), nil
+	}
+	return "", logic.ErrInvalidAction
+}
+
+func (c *Core) handleCallback(ctx *gin.Context) {
+	cb, err := c.logic.ParseCallback(ctx.Param("data"))
+	if err != nil {
+		if err == logic.ErrHostNotAllowed {
+			ctx.JSON(http.StatusForbidden, gin.H{"res": http.StatusForbidden, "msg": "callback host not allowed"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid callback"})
+		return
+	}
+	var params struct {
+		Nonce    uint64 `json:"nonce"`
+		DeviceID string `json:"device"`
+	}
+	if err := c.bindBodyJSON(ctx, &params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid params"})
+		return
+	}
+	dev, err := c.logic.GetUserDeviceKey(cb.UID, params.DeviceID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid device id"})
+		return
+	}
+	if !verifyDevice(ctx, crypto.Base64Encode.EncodeToString(dev)) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"res": http.StatusUnauthorized, "msg": "invalid device sign"})
+		return
+	}
+	if err := c.logic.DoneCallback(cb); err != nil {
+		if err == logic.ErrCallbackDone {
+			ctx.JSON(http.StatusConflict, gin.H{"res": http.StatusConflict, "msg": "callback already done"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "callback failed"})
+		return
+	}
+	var body io.Reader
+	if len(cb.Body) > 0 {
+		body = strings.NewReader(cb.Body)
+	}
+	req, err := http.NewRequest(cb.Method, cb.URL, body)
+	if err != nil {
+		c.logic.ReleaseCallback(cb) // nolint: errcheck
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid callback"})
+		return
+	}
+	if body != nil {
+		if json.Valid([]byte(cb.Body)) {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	}
+	resp, err := callbackClient.Do(req)
+	if err != nil {
+		c.logic.ReleaseCallback(cb) // nolint: errcheck
+		ctx.JSON(http.StatusBadGateway, gin.H{"res": http.StatusBadGateway, "msg": "callback failed"})
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024)) // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		c.logic.ReleaseCallback(cb) // nolint: errcheck
+		ctx.JSON(http.StatusBadGateway, gin.H{"res": http.StatusBadGateway, "msg": "callback failed", "status": resp.StatusCode})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": resp.StatusCode})
+}
+
+func replyActionError(ctx sendContext, err error) {
+	ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": err.Error()})
+}
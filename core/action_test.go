@@ -0,0 +1,136 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+)
+
+func TestMakeActions(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Endpoint: "http://node", CallbackHosts: []string{"127.0.0.1"}}) // nolint: errcheck
+	acts, err := c.makeActions("u1", []*ActionParam{
+		parseActionParam("Open|http://127.0.0.1"),
+		{Type: "copy", Name: "Copy", Body: "abc"},
+		{Type: "ack", Name: "Ack"},
+		{Type: "callback", Name: "Approve", Link: "https://127.0.0.1/approve", Body: `{"id":1}`},
+	})
+	if err != nil || len(acts) != 4 {
+		t.Fatal("Make actions failed:", err)
+	}
+	if acts[0] != "Open|http://127.0.0.1" || acts[1] != "Copy|chanify://action/copy?text=abc" || acts[2] != "Ack|chanify://action/ack" {
+		t.Error("Check actions failed:", acts)
+	}
+	if !strings.HasPrefix(acts[3], "Approve|chanify://action/callback?url="+url.QueryEscape("http://node/v1/callback/")) {
+		t.Error("Check callback action failed:", acts[3])
+	}
+	for _, p := range []*ActionParam{nil, {Name: "Open"}, {Link: "http://127.0.0.1"}, {Type: "copy", Name: "Copy"}, {Type: "unknown", Name: "X"}, {Type: "callback", Name: "X", Link: "file:///etc/passwd"}} {
+		if _, err := c.makeActions("u1", []*ActionParam{p}); err != logic.ErrInvalidAction {
+			t.Error("Check invalid action failed:", p, err)
+		}
+	}
+	if _, err := c.makeActions("u1", []*ActionParam{{Type: "callback", Name: "X", Link: "http://localhost:8080/admin"}}); err != logic.ErrHostNotAllowed {
+		t.Error("Check callback host not allowed failed:", err)
+	}
+}
+
+func TestHandleCallback(t *testing.T) {
+	var body, ctype string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://localhost/admin", http.StatusFound)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		ctype = r.Header.Get("Content-Type")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true, CallbackHosts: []string{"127.0.0.1"}}) // nolint: errcheck
+	_, uid := newDNDTestUser(t, c, false)
+	dk := crypto.GenerateSecretKey([]byte("device"))
+	h := sha1.Sum(dk.MarshalPublicKey())
+	devID := strings.ToUpper(hex.EncodeToString(h[:]))
+	c.logic.BindDevice(uid, devID, dk.EncodePublicKey(), 1) // nolint: errcheck
+	handler := c.APIHandler()
+	sendWith := func(path string, dev string, key *crypto.SecretKey) *httptest.ResponseRecorder {
+		data := `{"nonce":1,"device":"` + dev + `"}`
+		req := httptest.NewRequest("POST", path, strings.NewReader(data))
+		sig, _ := key.Sign([]byte(data))
+		req.Header.Set("CHDevSign", crypto.Base64Encode.EncodeToString(sig))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	send := func(path string) *httptest.ResponseRecorder {
+		return sendWith(path, devID, dk)
+	}
+	if w := send("/v1/callback/abc"); w.Code != http.StatusBadRequest {
+		t.Error("Check invalid callback failed:", w.Code)
+	}
+	cb, _ := c.logic.NewCallback(uid, srv.URL+"/approve", "", `{"id":1}`)
+	link := c.logic.MakeCallbackURL(cb)
+	if w := sendWith(link, devID, crypto.GenerateSecretKey([]byte("other"))); w.Code != http.StatusUnauthorized || body != "" {
+		t.Error("Check callback device sign failed:", w.Code)
+	}
+	if w := sendWith(link, "B3BC1B875EDA13986801B1004B4ABF5760C197F4", dk); w.Code != http.StatusBadRequest || body != "" {
+		t.Error("Check callback unknown device failed:", w.Code)
+	}
+	other, _ := c.logic.NewCallback("ABOO6TSIXKSEVIJKXLDQSUXQRXUAOXGGYY", srv.URL+"/approve", "", "ok")
+	if w := send(c.logic.MakeCallbackURL(other)); w.Code != http.StatusBadRequest || body != "" {
+		t.Error("Check callback device of other user failed:", w.Code)
+	}
+	if w := send(link); w.Code != http.StatusOK || body != `{"id":1}` || ctype != "application/json" {
+		t.Error("Check callback failed:", w.Code, body, ctype)
+	}
+	if w := send(link); w.Code != http.StatusConflict {
+		t.Error("Check callback run once failed:", w.Code)
+	}
+	status = http.StatusInternalServerError
+	cb, _ = c.logic.NewCallback(uid, srv.URL+"/approve", "", "ok")
+	if w := send(c.logic.MakeCallbackURL(cb)); w.Code != http.StatusBadGateway {
+		t.Error("Check callback response status failed:", w.Code)
+	}
+	status = http.StatusOK
+	if w := send(c.logic.MakeCallbackURL(cb)); w.Code != http.StatusOK {
+		t.Error("Check callback retry after failed status failed:", w.Code)
+	}
+	cb, _ = c.logic.NewCallback(uid, "http://127.0.0.1:1/approve", "", "ok")
+	if w := send(c.logic.MakeCallbackURL(cb)); w.Code != http.StatusBadGateway {
+		t.Error("Check callback request failed:", w.Code)
+	}
+	if w := send(c.logic.MakeCallbackURL(cb)); w.Code != http.StatusBadGateway {
+		t.Error("Check callback retry after failed request failed:", w.Code)
+	}
+	body = ""
+	cb, _ = c.logic.NewCallback(uid, srv.URL+"/redirect", "", "ok")
+	if w := send(c.logic.MakeCallbackURL(cb)); w.Code != http.StatusBadGateway || !strings.Contains(w.Body.String(), `"status":302`) || body != "" {
+		t.Error("Check callback redirect failed:", w.Code, w.Body.String())
+	}
+}
+
+func TestSenderPostInvalidAction(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory"}) // nolint: errcheck
+	req := httptest.NewRequest("POST", "/v1/sender/abc", strings.NewReader(`{"text":"hello","actions":[{"type":"unknown","name":"X"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c.APIHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid action") {
+		t.Error("Check invalid action failed:", w.Code, w.Body.String())
+	}
+}
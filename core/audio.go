@@ -0,0 +1,372 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+// AudioInfo define audio metadata
+type AudioInfo struct {
+	ContentType string
+	Duration    uint64 // milliseconds
+	Title       string
+	Artist      string
+}
+
+// DisplayTitle return title with artist
+func (a *AudioInfo) DisplayTitle() string {
+	if len(a.Artist) > 0 && len(a.Title) > 0 {
+		return a.Artist + " - " + a.Title
+	}
+	if len(a.Title) > 0 {
+		return a.Title
+	}
+	return a.Artist
+}
+
+var mp3Bitrates = [2][3][16]int{
+	{ // MPEG-1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+	{ // MPEG-2 & 2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+}
+
+var mp3SampleRates = [4][3]int{
+	{11025, 12000, 8000},  // MPEG-2.5
+	{0, 0, 0},             // reserved
+	{22050, 24000, 16000}, // MPEG-2
+	{44100, 48000, 32000}, // MPEG-1
+}
+
+var aacSampleRates = []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+func parseAudioContentType(data []byte) string {
+	switch {
+	case len(data) > 12 && string(data[0:4]) == riffHeader && string(data[8:12]) == "WAVE":
+		return "audio/wav"
+	case len(data) > 4 && string(data[0:4]) == "OggS":
+		return "audio/ogg"
+	case len(data) > 8 && string(data[4:8]) == "ftyp":
+		return "audio/mp4"
+	case len(data) > 2 && data[0] == 0xFF && data[1]&0xF6 == 0xF0:
+		return "audio/aac"
+	}
+	return "audio/mpeg"
+}
+
+func parseAudioInfo(data []byte) *AudioInfo {
+	info := &AudioInfo{ContentType: parseAudioContentType(data)}
+	switch info.ContentType {
+	case "audio/wav":
+		parseWAVInfo(data, info)
+	case "audio/ogg":
+		parseOGGInfo(data, info)
+	case "audio/mp4":
+		parseM4AInfo(data, info)
+	case "audio/aac":
+		info.Duration = parseADTSDuration(data)
+	default:
+		parseMP3Info(data, info)
+	}
+	return info
+}
+
+func parseMP3Info(data []byte, info *AudioInfo) {
+	offset := 0
+	if len(data) > 10 && string(data[0:3]) == "ID3" {
+		size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+		offset = 10 + size
+		if offset > len(data) {
+			return
+		}
+		parseID3v2Tags(data[10:offset], data[3], info)
+	}
+	end := len(data)
+	if end-offset > 128 && string(data[end-128:end-125]) == "TAG" {
+		tag := data[end-128:]
+		if len(info.Title) <= 0 {
+			info.Title = trimTagString(tag[3:33])
+		}
+		if len(info.Artist) <= 0 {
+			info.Artist = trimTagString(tag[33:63])
+		}
+		end -= 128
+	}
+	for ; offset+4 <= end; offset++ {
+		if data[offset] != 0xFF || data[offset+1]&0xE0 != 0xE0 {
+			continue
+		}
+		h := binary.BigEndian.Uint32(data[offset:])
+		version := (h >> 19) & 0x03
+		layer := (h >> 17) & 0x03
+		bitrateIdx := (h >> 12) & 0x0F
+		rateIdx := (h >> 10) & 0x03
+		if version == 1 || layer == 0 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+			continue
+		}
+		v := 1
+		if version == 3 {
+			v = 0
+		}
+		bitrate := mp3Bitrates[v][3-layer][bitrateIdx] * 1000
+		rate := mp3SampleRates[version][rateIdx]
+		samples := 1152
+		if layer == 3 {
+			samples = 384
+		} else if layer == 1 && version != 3 {
+			samples = 576
+		}
+		// Xing/Info header for VBR
+		side := 32
+		if version != 3 {
+			side = 17
+		}
+		if (h>>6)&0x03 == 3 {
+			if version == 3 {
+				side = 17
+			} else {
+				side = 9
+			}
+		}
+		if x := offset + 4 + side; x+12 <= end {
+			tag := string(data[x : x+4])
+			if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(data[x+4:])&0x01 != 0 {
+				frames := uint64(binary.BigEndian.Uint32(data[x+8:]))
+				info.Duration = frames * uint64(samples) * 1000 / uint64(rate)
+				return
+			}
+		}
+		if x := offset + 4 + 32; x+18 <= end && string(data[x:x+4]) == "VBRI" {
+			frames := uint64(binary.BigEndian.Uint32(data[x+14:]))
+			info.Duration = frames * uint64(samples) * 1000 / uint64(rate)
+			return
+		}
+		info.Duration = uint64(end-offset) * 8 * 1000 / uint64(bitrate)
+		return
+	}
+}
+
+func parseID3v2Tags(data []byte, version byte, info *AudioInfo) {
+	headerSize := 10
+	idSize := 4
+	if version == 2 {
+		headerSize = 6
+		idSize = 3
+	}
+	for len(data) >= headerSize && data[0] != 0 {
+		id := string(data[:idSize])
+		var size int
+		switch version {
+		case 2:
+			size = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 4:
+			size = int(data[4]&0x7F)<<21 | int(data[5]&0x7F)<<14 | int(data[6]&0x7F)<<7 | int(data[7]&0x7F)
+		default:
+			size = int(binary.BigEndian.Uint32(data[4:8]))
+		}
+		if size < 0 || headerSize+size > len(data) {
+			return
+		}
+		value := data[headerSize : headerSize+size]
+		switch id {
+		case "TIT2", "TT2":
+			info.Title = decodeID3Text(value)
+		case "TPE1", "TP1":
+			info.Artist = decodeID3Text(value)
+		}
+		data = data[headerSize+size:]
+	}
+}
+
+func decodeID3Text(data []byte) string {
+	if len(data) <= 1 {
+		return ""
+	}
+	switch enc := data[0]; enc {
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		data = data[1:]
+		bigEndian := enc == 2
+		if len(data) >= 2 && (data[0] == 0xFF && data[1] == 0xFE || data[0] == 0xFE && data[1] == 0xFF) {
+			bigEndian = data[0] == 0xFE
+			data = data[2:]
+		}
+		u := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			if bigEndian {
+				u = append(u, uint16(data[i])<<8|uint16(data[i+1]))
+			} else {
+				u = append(u, uint16(data[i+1])<<8|uint16(data[i]))
+			}
+		}
+		return trimTagString([]byte(string(utf16.Decode(u))))
+	case 0: // ISO-8859-1
+		rs := make([]rune, 0, len(data)-1)
+		for _, b := range data[1:] {
+			rs = append(rs, rune(b))
+		}
+		return trimTagString([]byte(string(rs)))
+	}
+	return trimTagString(data[1:])
+}
+
+func parseM4AInfo(data []byte, info *AudioInfo) {
+	if v := parseVideoInfo(data); v != nil {
+		info.Duration = v.Duration
+	}
+	moov := findMP4Box(data, "moov")
+	meta := findMP4Box(findMP4Box(moov, "udta"), "meta")
+	if len(meta) > 8 && string(meta[4:8]) != "hdlr" {
+		meta = meta[4:] // full box header
+	}
+	walkMP4Boxes(findMP4Box(meta, "ilst"), func(name string, body []byte) bool {
+		if d := findMP4Box(body, "data"); len(d) > 8 {
+			switch name {
+			case "\xa9nam":
+				info.Title = trimTagString(d[8:])
+			case "\xa9ART":
+				info.Artist = trimTagString(d[8:])
+			}
+		}
+		return true
+	})
+}
+
+func parseADTSDuration(data []byte) uint64 {
+	frames := uint64(0)
+	rate := 0
+	for len(data) >= 7 && data[0] == 0xFF && data[1]&0xF6 == 0xF0 {
+		idx := int(data[2]>>2) & 0x0F
+		if idx >= len(aacSampleRates) {
+			break
+		}
+		rate = aacSampleRates[idx]
+		size := int(data[3]&0x03)<<11 | int(data[4])<<3 | int(data[5]>>5)
+		if size < 7 || size > len(data) {
+			break
+		}
+		frames += uint64(data[6]&0x03) + 1
+		data = data[size:]
+	}
+	if rate <= 0 {
+		return 0
+	}
+	return frames * 1024 * 1000 / uint64(rate)
+}
+
+func parseOGGInfo(data []byte, info *AudioInfo) {
+	var rate, skip uint64
+	if i := bytes.Index(data, []byte("\x01vorbis")); i >= 0 && i+16 <= len(data) {
+		rate = uint64(binary.LittleEndian.Uint32(data[i+12:]))
+	} else if i := bytes.Index(data, []byte("OpusHead")); i >= 0 && i+16 <= len(data) {
+		rate = 48000
+		skip = uint64(binary.LittleEndian.Uint16(data[i+10:]))
+	}
+	if i := bytes.Index(data, []byte("\x03vorbis")); i >= 0 {
+		parseVorbisComment(data[i+7:], info)
+	} else if i := bytes.Index(data, []byte("OpusTags")); i >= 0 {
+		parseVorbisComment(data[i+8:], info)
+	}
+	if i := bytes.LastIndex(data, []byte("OggS")); rate > 0 && i >= 0 && i+14 <= len(data) {
+		granule := binary.LittleEndian.Uint64(data[i+6:])
+		if granule > skip {
+			info.Duration = (granule - skip) * 1000 / rate
+		}
+	}
+}
+
+func parseVorbisComment(data []byte, info *AudioInfo) {
+	if len(data) < 4 {
+		return
+	}
+	n := int(binary.LittleEndian.Uint32(data))
+	if n < 0 || 4+n+4 > len(data) {
+		return
+	}
+	data = data[4+n:]
+	count := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	for i := 0; i < count && len(data) >= 4; i++ {
+		l := int(binary.LittleEndian.Uint32(data))
+		if l < 0 || 4+l > len(data) {
+			return
+		}
+		kv := strings.SplitN(string(data[4:4+l]), "=", 2)
+		if len(kv) > 1 {
+			switch strings.ToUpper(kv[0]) {
+			case "TITLE":
+				info.Title = strings.TrimSpace(kv[1])
+			case "ARTIST":
+				info.Artist = strings.TrimSpace(kv[1])
+			}
+		}
+		data = data[4+l:]
+	}
+}
+
+func parseWAVInfo(data []byte, info *AudioInfo) {
+	var byteRate, dataSize uint64
+	data = data[12:]
+	for len(data) >= 8 {
+		name := string(data[0:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if size < 0 || 8+size > len(data) {
+			size = len(data) - 8
+		}
+		body := data[8 : 8+size]
+		switch name {
+		case "fmt ":
+			if len(body) >= 12 {
+				byteRate = uint64(binary.LittleEndian.Uint32(body[8:12]))
+			}
+		case "data":
+			dataSize = uint64(size)
+		case "LIST":
+			if len(body) >= 4 && string(body[0:4]) == "INFO" {
+				parseWAVListInfo(body[4:], info)
+			}
+		}
+		size += size & 0x01
+		if 8+size > len(data) {
+			break
+		}
+		data = data[8+size:]
+	}
+	if byteRate > 0 {
+		info.Duration = dataSize * 1000 / byteRate
+	}
+}
+
+func parseWAVListInfo(data []byte, info *AudioInfo) {
+	for len(data) >= 8 {
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if size < 0 || 8+size > len(data) {
+			return
+		}
+		switch string(data[0:4]) {
+		case "INAM":
+			info.Title = trimTagString(data[8 : 8+size])
+		case "IART":
+			info.Artist = trimTagString(data[8 : 8+size])
+		}
+		size += size & 0x01
+		if 8+size > len(data) {
+			return
+		}
+		data = data[8+size:]
+	}
+}
+
+func trimTagString(data []byte) string {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return strings.TrimSpace(strings.ToValidUTF8(string(data), ""))
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func id3Frame(id string, text string) []byte {
+	out := make([]byte, 10, 10+1+len(text))
+	copy(out, id)
+	binary.BigEndian.PutUint32(out[4:], uint32(1+len(text)))
+	out = append(out, 3)
+	return append(out, text...)
+}
+
+func testMP3Data() []byte {
+	tags := append(id3Frame("TIT2", "Song"), id3Frame("TPE1", "Band")...)
+	size := len(tags)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	data := append(header, tags...)
+	// MPEG-1 Layer III, 128kbps, 44100Hz: 1 second of frames
+	frame := make([]byte, 16000)
+	frame[0], frame[1], frame[2], frame[3] = 0xFF, 0xFB, 0x90, 0x00
+	return append(data, frame...)
+}
+
+func TestParseMP3Info(t *testing.T) {
+	info := parseAudioInfo(testMP3Data())
+	if info.ContentType != "audio/mpeg" || info.Duration != 1000 {
+		t.Error("Parse mp3 duration failed:", info.Duration)
+	}
+	if info.Title != "Song" || info.Artist != "Band" || info.DisplayTitle() != "Band - Song" {
+		t.Error("Parse mp3 tags failed:", info.DisplayTitle())
+	}
+
+	xing := make([]byte, 200)
+	xing[0], xing[1], xing[2], xing[3] = 0xFF, 0xFB, 0x90, 0x00
+	copy(xing[36:], "Xing\x00\x00\x00\x01")
+	binary.BigEndian.PutUint32(xing[44:], 100)
+	tag := make([]byte, 128)
+	copy(tag, "TAGTitle")
+	info = parseAudioInfo(append(xing, tag...))
+	if info.Duration != 2612 || info.DisplayTitle() != "Title" {
+		t.Error("Parse mp3 vbr failed:", info.Duration, info.Title)
+	}
+}
+
+func TestDecodeID3Text(t *testing.T) {
+	if decodeID3Text([]byte{1, 0xFF, 0xFE, 'a', 0, 'b', 0}) != "ab" {
+		t.Error("Decode utf16 text failed")
+	}
+	if decodeID3Text([]byte{2, 0, 'a', 0, 'b'}) != "ab" {
+		t.Error("Decode utf16be text failed")
+	}
+	if decodeID3Text([]byte{0, 0xE9}) != "é" {
+		t.Error("Decode latin1 text failed")
+	}
+	if decodeID3Text([]byte{0}) != "" {
+		t.Error("Check empty text failed")
+	}
+}
+
+func TestParseWAVInfo(t *testing.T) {
+	fmtChunk := make([]byte, 24)
+	copy(fmtChunk, "fmt ")
+	binary.LittleEndian.PutUint32(fmtChunk[4:], 16)
+	binary.LittleEndian.PutUint32(fmtChunk[16:], 8000)
+	list := []byte("LIST\x10\x00\x00\x00INFOINAM\x04\x00\x00\x00Wav\x00")
+	dataChunk := make([]byte, 8+4000)
+	copy(dataChunk, "data")
+	binary.LittleEndian.PutUint32(dataChunk[4:], 4000)
+	data := append([]byte("RIFF\x00\x00\x00\x00WAVE"), fmtChunk...)
+	data = append(data, list...)
+	info := parseAudioInfo(append(data, dataChunk...))
+	if info.ContentType != "audio/wav" || info.Duration != 500 || info.Title != "Wav" {
+		t.Error("Parse wav info failed:", info)
+	}
+}
+
+func TestParseOGGInfo(t *testing.T) {
+	ident := make([]byte, 30)
+	copy(ident, "\x01vorbis")
+	binary.LittleEndian.PutUint32(ident[12:], 44100)
+	comment := []byte("\x03vorbis\x01\x00\x00\x00x\x02\x00\x00\x00\x0a\x00\x00\x00TITLE=Ogg!\x0a\x00\x00\x00ARTIST=Me!")
+	last := make([]byte, 27)
+	copy(last, "OggS")
+	binary.LittleEndian.PutUint64(last[6:], 88200)
+	data := append([]byte("OggS"), make([]byte, 24)...)
+	data = append(data, ident...)
+	data = append(data, comment...)
+	info := parseAudioInfo(append(data, last...))
+	if info.ContentType != "audio/ogg" || info.Duration != 2000 || info.Title != "Ogg!" || info.Artist != "Me!" {
+		t.Error("Parse ogg info failed:", info)
+	}
+}
+
+func TestParseM4AInfo(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 44100)
+	binary.BigEndian.PutUint32(mvhd[16:], 44100*3)
+	ilst := mp4Box("ilst",
+		mp4Box("\xa9nam", mp4Box("data", []byte("\x00\x00\x00\x01\x00\x00\x00\x00M4A"))),
+		mp4Box("\xa9ART", mp4Box("data", []byte("\x00\x00\x00\x01\x00\x00\x00\x00Artist"))),
+	)
+	meta := mp4Box("meta", []byte{0, 0, 0, 0}, mp4Box("hdlr", make([]byte, 24)), ilst)
+	data := append(mp4Box("ftyp", []byte("M4A \x00\x00\x02\x00")), mp4Box("moov", mp4Box("mvhd", mvhd), mp4Box("udta", meta))...)
+	info := parseAudioInfo(data)
+	if info.ContentType != "audio/mp4" || info.Duration != 3000 || info.Title != "M4A" || info.Artist != "Artist" {
+		t.Error("Parse m4a info failed:", info)
+	}
+}
+
+func TestParseADTSDuration(t *testing.T) {
+	// 44100Hz, 7 bytes frame
+	frame := []byte{0xFF, 0xF1, 0x50, 0x80, 0x00, 0xFF, 0xFC}
+	data := []byte{}
+	for i := 0; i < 431; i++ {
+		data = append(data, frame...)
+	}
+	info := parseAudioInfo(data)
+	if info.ContentType != "audio/aac" || info.Duration != 10007 {
+		t.Error("Parse aac duration failed:", info.Duration)
+	}
+	if parseADTSDuration([]byte{0xFF, 0xF1, 0xFC, 0x80, 0x00, 0xFF, 0xFC}) != 0 {
+		t.Error("Check invalid aac failed")
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+const batchSummaryLines = 5
+
+// batchMessage hold message into batch window of token or channel, return true if message is batched
+func (c *Core) batchMessage(ctx sendContext, uid string, msg *model.Message) bool {
+	if msg.IsTimeline() || msg.IsCritical() || msg.Ack() != nil {
+		return false
+	}
+	perToken := true
+	window := msg.BatchWindow()
+	if window <= 0 {
+		perToken = false
+		window = c.logic.GetChannelBatch(msg.ChannelName())
+	}
+	if window <= 0 {
+		return false
+	}
+	if ttl, ok := msg.TTL(); ok && ttl < window {
+		return false
+	}
+	deliver, err := c.logic.BatchMessage(uid, perToken, window, msg)
+	if err != nil {
+		if err != model.ErrNotImplemented {
+			log.Println("Batch message failed:", err)
+		}
+		return false
+	}
+	ctx.JSON(http.StatusOK, gin.H{"request-uid": "", "msg": "message batched", "deliver": deliver.Unix()})
+	return true
+}
+
+// makeBatchMessage merge messages into one notification, full list is attached as text file if file store enabled
+func (c *Core) makeBatchMessage(msgs []*model.Message) *model.Message {
+	if len(msgs) == 1 {
+		return msgs[0]
+	}
+	first := msgs[0]
+	batch := &model.Message{}
+	batch.From = first.From
+	batch.Channel = first.Channel
+	batch.TokenHash = first.TokenHash
+	batch.InterruptionLevel = first.InterruptionLevel
+	title := fmt.Sprintf("%d messages", len(msgs))
+	summary := []string{}
+	items := []string{}
+	for idx, msg := range msgs {
+		var content pb.MsgContent
+		proto.Unmarshal(msg.Content, &content) // nolint: errcheck
+		if idx == 0 && len(content.Title) > 0 {
+			title = fmt.Sprintf("%s (%d messages)", content.Title, len(msgs))
+		}
+		if batch.Sound == nil && msg.Sound != nil {
+			batch.Sound = msg.Sound
+		}
+		if msg.Priority > batch.Priority {
+			batch.Priority = msg.Priority
+		}
+		text := strings.TrimSpace(content.Text)
+		if len(text) <= 0 {
+			text = "[" + content.Type.String() + "]"
+		}
+		line := strings.SplitN(text, "\n", 2)[0]
+		if len(content.Title) > 0 {
+			line = content.Title + ": " + line
+			text = content.Title + "\n" + text
+		}
+		if len(summary) < batchSummaryLines {
+			summary = append(summary, "• "+line)
+		} else if len(summary) == batchSummaryLines {
+			summary = append(summary, fmt.Sprintf("... and %d more", len(msgs)-idx))
+		}
+		items = append(items, text)
+	}
+	desc := strings.Join(summary, "\n")
+	if c.logic.CanFileStore() {
+		data := []byte(strings.Join(items, "\n\n"))
+		if path, err := c.logic.SaveFile("files", data); err == nil {
+			return batch.TextFileContent(path, "batch.txt", title, desc, len(data), nil)
+		}
+	}
+	return batch.TextContent(desc, title, "", "")
+}
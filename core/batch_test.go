@@ -0,0 +1,117 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func newSignedTestToken(t *testing.T, c *Core, uid string) string {
+	key, err := c.logic.GetUserKey(uid)
+	if err != nil {
+		t.Fatal("Get user key failed:", err)
+	}
+	data, _ := proto.Marshal(&pb.Token{UserId: uid, Expires: uint64(time.Now().Add(time.Hour).Unix())})
+	mac := hmac.New(sha256.New, key[0:32])
+	mac.Write(data) // nolint: errcheck
+	return crypto.Base64Encode.EncodeToString(data) + ".." + crypto.Base64Encode.EncodeToString(mac.Sum(nil))
+}
+
+func TestBatchMessage(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true, Batches: []map[string]interface{}{{"channel": "logs", "window": "1m"}}}) // nolint: errcheck
+	_, uid := newDNDTestUser(t, c, false)
+	tk := newDNDTestToken(uid)
+	send := func(msg *model.Message) *luaSendContext {
+		lc := &luaSendContext{}
+		c.sendDirect(lc, tk, msg)
+		return lc
+	}
+	if lc := send(model.NewMessage(tk).TextContent("hello", "", "", "")); lc.code != http.StatusNotFound {
+		t.Error("Check send without batch failed:", lc.code, lc.String())
+	}
+	if lc := send(model.NewMessage(tk).TimelineContent("code", "", nil, nil).SetBatch(time.Minute)); lc.code != http.StatusNotFound {
+		t.Error("Check send timeline batch failed:", lc.code, lc.String())
+	}
+	for i := 0; i < 2; i++ {
+		if lc := send(model.NewMessage(tk).TextContent("hello", "", "", "").SetBatch(time.Minute)); lc.code != http.StatusOK || !strings.Contains(lc.String(), "message batched") {
+			t.Error("Check send token batch failed:", lc.code, lc.String())
+		}
+	}
+	if lc := send(model.NewMessage(tk).TextContent("hello", "", "", "").SetBatch(time.Minute).SetTTL(30 * time.Second)); lc.code != http.StatusNotFound {
+		t.Error("Check send batch out of ttl failed:", lc.code, lc.String())
+	}
+	if lc := send(model.NewMessage(tk).TextContent("hello", "", "", "").SetChannelName("logs")); lc.code != http.StatusOK || !strings.Contains(lc.String(), "message batched") {
+		t.Error("Check send channel batch failed:", lc.code, lc.String())
+	}
+	deliver := time.Now().Add(time.Minute)
+	c.deliverHeldMessages(deliver)
+	if held, _ := c.logic.PopHeldMessages(deliver, 10); len(held) != 0 {
+		t.Error("Check deliver batch messages failed:", len(held))
+	}
+
+	handler := c.APIHandler()
+	token := newSignedTestToken(t, c, uid)
+	req := httptest.NewRequest("GET", "/v1/sender/"+token+"/hello?batch=5m", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "message batched") {
+		t.Error("Check get sender batch failed:", w.Code, w.Body.String())
+	}
+	req = httptest.NewRequest("POST", "/v1/sender/"+token, strings.NewReader(`{"text":"hello","batch":300}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "message batched") {
+		t.Error("Check post sender batch failed:", w.Code, w.Body.String())
+	}
+}
+
+func TestMakeBatchMessage(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory"}) // nolint: errcheck
+	tk := &model.Token{}
+	msg := model.NewMessage(tk).TextContent("hello", "", "", "")
+	if c.makeBatchMessage([]*model.Message{msg}) != msg {
+		t.Error("Check single batch message failed")
+	}
+	msgs := []*model.Message{
+		model.NewMessage(tk).TextContent("line1\nline2", "Title", "", "").SetPriority(5),
+		model.NewMessage(tk).LinkContent("https://example.com").SoundName("1"),
+	}
+	for i := 0; i < 10; i++ {
+		msgs = append(msgs, model.NewMessage(tk).TextContent("log", "", "", ""))
+	}
+	batch := c.makeBatchMessage(msgs)
+	var content pb.MsgContent
+	proto.Unmarshal(batch.Content, &content) // nolint: errcheck
+	if content.Type != pb.MsgType_Text || content.Title != "Title (12 messages)" || !strings.HasPrefix(content.Text, "• Title: line1\n• [Link]\n• log") || !strings.HasSuffix(content.Text, "... and 7 more") {
+		t.Error("Check batch message failed:", content.Title, content.Text)
+	}
+	if batch.Priority != 5 || batch.Sound.GetName() != "1" {
+		t.Error("Check batch message options failed:", batch.Priority, batch.Sound)
+	}
+
+	fpath := "./tmp-batch"
+	defer os.RemoveAll(fpath)
+	c2 := New()
+	defer c2.Close()
+	c2.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", FilePath: fpath}) // nolint: errcheck
+	proto.Unmarshal(c2.makeBatchMessage(msgs).Content, &content)             // nolint: errcheck
+	if content.Type != pb.MsgType_File || content.Filename != "batch.txt" || content.Size <= 0 {
+		t.Error("Check batch file message failed:", content.Type, content.Filename, content.Size)
+	}
+}
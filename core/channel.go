@@ -0,0 +1,109 @@
+package core
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *Core) handleChannels(ctx *gin.Context) {
+	var params struct {
+		Nonce  uint64 `json:"nonce"`
+		UserID string `json:"user"`
+	}
+	if err := c.bindBodyJSON(ctx, &params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid params"})
+		return
+	}
+	if !c.verifyServerfulUser(ctx, params.UserID) {
+		return
+	}
+	chs, err := c.logic.GetChannels(params.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "get channels failed"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"uid": params.UserID, "channels": chs})
+}
+
+func (c *Core) handleUpdateChannel(ctx *gin.Context) {
+	var params struct {
+		Nonce   uint64         `json:"nonce"`
+		UserID  string         `json:"user"`
+		Channel *model.Channel `json:"channel"`
+	}
+	if err := c.bindBodyJSON(ctx, &params); err != nil || params.Channel == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid params"})
+		return
+	}
+	if !c.verifyServerfulUser(ctx, params.UserID) {
+		return
+	}
+	if err := c.logic.UpsertChannel(params.UserID, params.Channel); err != nil {
+		switch err {
+		case logic.ErrInvalidChannel:
+			ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid channel"})
+		case logic.ErrTooManyChannels:
+			ctx.JSON(http.StatusNotAcceptable, gin.H{"res": http.StatusNotAcceptable, "msg": "too many channels"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "update channel failed"})
+		}
+		return
+	}
+	log.Println("Update channel:", fixLog(params.UserID), fixLog(params.Channel.Name))
+	ctx.JSON(http.StatusOK, gin.H{"uid": params.UserID, "channel": params.Channel})
+}
+
+func (c *Core) handleDeleteChannel(ctx *gin.Context) {
+	var params struct {
+		Nonce  uint64 `json:"nonce"`
+		UserID string `json:"user"`
+		Name   string `json:"name"`
+	}
+	if err := c.bindBodyJSON(ctx, &params); err != nil || len(params.Name) <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid params"})
+		return
+	}
+	if !c.verifyServerfulUser(ctx, params.UserID) {
+		return
+	}
+	if err := c.logic.DeleteChannel(params.UserID, params.Name); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "delete channel failed"})
+		return
+	}
+	log.Println("Delete channel:", fixLog(params.UserID), fixLog(params.Name))
+	ctx.JSON(http.StatusOK, gin.H{"uid": params.UserID, "name": params.Name})
+}
+
+// applyChannel fill message options with defaults of user channel
+func (c *Core) applyChannel(uid string, msg *model.Message) {
+	ch, err := c.logic.GetChannel(uid, msg.ChannelName())
+	if err != nil {
+		log.Println("Get channel failed:", err)
+		return
+	}
+	if ch != nil {
+		msg.ApplyChannel(ch)
+	}
+}
+
+// verifyServerfulUser check request is signed by serverful user, reply error if failed
+func (c *Core) verifyServerfulUser(ctx *gin.Context, uid string) bool {
+	u, err := c.logic.GetUser(uid)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid user id"})
+		return false
+	}
+	if u.IsServerless() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid user mode"})
+		return false
+	}
+	if !verifyUser(ctx, u.GetPublicKeyString()) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"res": http.StatusUnauthorized, "msg": "invalid user sign"})
+		return false
+	}
+	return true
+}
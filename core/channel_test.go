@@ -0,0 +1,79 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+)
+
+func TestChannelHandler(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true}) // nolint: errcheck
+	sk, uid := newDNDTestUser(t, c, false)
+	handler := c.APIHandler()
+	post := func(path string, body string, sign bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		if sign {
+			sig, _ := sk.Sign([]byte(body))
+			req.Header.Set("CHUserSign", crypto.Base64Encode.EncodeToString(sig))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	w := post("/rest/v1/update-channel", `{"nonce":1,"user":"`+uid+`","channel":{"name":"alerts","sound":"bell","priority":10,"interruption-level":"time-sensitive"}}`, true)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"alerts"`) {
+		t.Fatal("Update channel failed:", w.Code, w.Body.String())
+	}
+	post("/rest/v1/update-channel", `{"nonce":2,"user":"`+uid+`","channel":{"name":"logs","priority":5}}`, true)
+	w = post("/rest/v1/channels", `{"nonce":3,"user":"`+uid+`"}`, true)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"channels":[{"name":"alerts"`) || !strings.Contains(w.Body.String(), `"name":"logs"`) {
+		t.Error("Get channels failed:", w.Code, w.Body.String())
+	}
+	w = post("/rest/v1/delete-channel", `{"nonce":4,"user":"`+uid+`","name":"logs"}`, true)
+	if w.Code != http.StatusOK {
+		t.Error("Delete channel failed:", w.Code, w.Body.String())
+	}
+	if w := post("/rest/v1/channels", `{"nonce":5,"user":"`+uid+`"}`, true); strings.Contains(w.Body.String(), `"name":"logs"`) {
+		t.Error("Check delete channel failed:", w.Body.String())
+	}
+	tests := []struct {
+		path string
+		body string
+		sign bool
+		code int
+	}{
+		{"/rest/v1/channels", `{`, true, http.StatusBadRequest},
+		{"/rest/v1/channels", `{"user":"abc"}`, true, http.StatusBadRequest},
+		{"/rest/v1/channels", `{"nonce":6,"user":"` + uid + `"}`, false, http.StatusUnauthorized},
+		{"/rest/v1/update-channel", `{"nonce":7,"user":"` + uid + `"}`, true, http.StatusBadRequest},
+		{"/rest/v1/update-channel", `{"nonce":8,"user":"` + uid + `","channel":{"name":""}}`, true, http.StatusBadRequest},
+		{"/rest/v1/update-channel", `{"nonce":9,"user":"` + uid + `","channel":{"name":"x"}}`, false, http.StatusUnauthorized},
+		{"/rest/v1/delete-channel", `{"nonce":10,"user":"` + uid + `"}`, true, http.StatusBadRequest},
+		{"/rest/v1/delete-channel", `{"nonce":11,"user":"` + uid + `","name":"alerts"}`, false, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if w := post(tt.path, tt.body, tt.sign); w.Code != tt.code {
+			t.Error("Check channel params failed:", tt.path, tt.body, w.Code)
+		}
+	}
+
+	tk := newDNDTestToken(uid)
+	msg := model.NewMessage(tk).TextContent("hello", "", "", "").SetChannelName("alerts")
+	c.sendMsg(&luaSendContext{}, tk, msg)
+	if msg.Sound.GetName() != "bell" || msg.Priority != 10 || msg.InterruptionLevel != pb.InterruptionLevel_IlTimeSensitive {
+		t.Error("Check apply channel defaults failed:", msg.Sound, msg.Priority, msg.InterruptionLevel)
+	}
+	msg = model.NewMessage(tk).TextContent("hello", "", "", "").SetChannelName("alerts").SetPriority(5)
+	c.sendMsg(&luaSendContext{}, tk, msg)
+	if msg.Priority != 5 {
+		t.Error("Check override channel defaults failed:", msg.Priority)
+	}
+}
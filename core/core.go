@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/chanify/chanify/logic"
@@ -16,11 +17,14 @@ var (
 	ErrNoContent       = errors.New("NoContent")
 	ErrTooLargeContent = errors.New("TooLargeContent")
 	ErrInvalidContent  = errors.New("InvalidContent")
+	ErrMarkdownActions = errors.New("MarkdownActions")
 )
 
 // Core instance
 type Core struct {
 	logic *logic.Logic
+	stop  chan struct{}
+	wg    sync.WaitGroup
 }
 
 // New core instance
@@ -33,11 +37,22 @@ func New() *Core {
 func (c *Core) Init(opts *logic.Options) error {
 	var err error
 	c.logic, err = logic.NewLogic(opts)
-	return err
+	if err != nil {
+		return err
+	}
+	c.stop = make(chan struct{})
+	c.wg.Add(1)
+	go c.deliverHeldLoop(c.stop)
+	return nil
 }
 
 // Close & cleaup for core
 func (c *Core) Close() {
+	if c.stop != nil {
+		close(c.stop)
+		c.wg.Wait()
+		c.stop = nil
+	}
 	if c.logic != nil {
 		c.logic.Close()
 		c.logic = nil
@@ -59,6 +74,7 @@ func (c *Core) APIHandler() http.Handler {
 	})
 
 	s := r.Group("/v1")
+	s.GET("/sender/ack/:request-uid", c.handleSenderAck)
 	s.GET("/sender/:token/:msg", c.handleSender)
 	s.GET("/sender/:token/", c.handleSender)
 	s.GET("/sender/:token", c.handleSender)
@@ -66,6 +82,7 @@ func (c *Core) APIHandler() http.Handler {
 	s.POST("/sender", c.handlePostSender)
 	s.POST("/webhook/:name/:token", c.handlePostWebhook)
 	s.POST("/webhook/:name", c.handlePostWebhook)
+	s.POST("/callback/:data", c.handleCallback)
 
 	api := r.Group("/rest/v1")
 	api.GET("/info", c.handleInfo)
@@ -73,10 +90,17 @@ func (c *Core) APIHandler() http.Handler {
 	api.POST("/bind-user", c.handleBindUser)
 	api.POST("/unbind-user", c.handleUnbindUser)
 	api.POST("/push-token", c.handleUpdatePushToken)
+	api.POST("/ack", c.handleAck)
+	api.POST("/dnd", c.handleUserDND)
+	api.POST("/channels", c.handleChannels)
+	api.POST("/update-channel", c.handleUpdateChannel)
+	api.POST("/delete-channel", c.handleDeleteChannel)
+	api.POST("/timeline", c.handleTimeline)
 
 	file := r.Group("/files")
 	file.GET("/images/:fname", c.handleImageDownload)
 	file.GET("/audios/:fname", c.handleAudioDownload)
+	file.GET("/videos/:fname", c.handleVideoDownload)
 	file.GET("/files/:fname", c.handleFileDownload)
 
 	return r
@@ -0,0 +1,200 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	heldDeliverInterval = 30 * time.Second
+	heldDeliverBatch    = 100
+	digestMaxText       = 2000
+)
+
+func (c *Core) handleUserDND(ctx *gin.Context) {
+	var params struct {
+		Nonce  uint64          `json:"nonce"`
+		UserID string          `json:"user"`
+		DND    json.RawMessage `json:"dnd,omitempty"`
+	}
+	if err := c.bindBodyJSON(ctx, &params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid params"})
+		return
+	}
+	if !c.verifyServerfulUser(ctx, params.UserID) {
+		return
+	}
+	if len(params.DND) > 0 {
+		var policy *logic.DNDPolicy
+		if string(params.DND) != "null" {
+			var err error
+			if policy, err = logic.ParseDNDPolicy(params.DND); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": err.Error()})
+				return
+			}
+		}
+		if err := c.logic.SetUserDND(params.UserID, policy); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "update dnd failed"})
+			return
+		}
+	}
+	policy, err := c.logic.GetUserDND(params.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "get dnd failed"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"uid": params.UserID, "dnd": policy})
+}
+
+// holdMessage apply do-not-disturb policy of user, return true if message is held
+func (c *Core) holdMessage(ctx sendContext, uid string, msg *model.Message) bool {
+	policy, err := c.logic.GetUserDND(uid)
+	if err != nil || policy == nil {
+		return false
+	}
+	action, until := policy.Check(msg, time.Now())
+	switch action {
+	case logic.DNDPassive:
+		msg.Sound = nil
+		msg.SetInterruptionLevel("passive")
+	case logic.DNDHold, logic.DNDDigest:
+		if msg.Ack() != nil {
+			// tracked messages need request uid in reply, deliver them passively instead
+			msg.Sound = nil
+			msg.SetInterruptionLevel("passive")
+			return false
+		}
+		if ttl, ok := msg.TTL(); ok && until.After(time.Now().Add(ttl)) {
+			ctx.JSON(http.StatusOK, gin.H{"request-uid": "", "msg": "message expired"})
+			return true
+		}
+		if err := c.logic.HoldMessage(uid, action, until, msg); err != nil {
+			log.Println("Hold message failed:", err)
+			msg.Sound = nil
+			msg.SetInterruptionLevel("passive")
+			return false
+		}
+		ctx.JSON(http.StatusOK, gin.H{"request-uid": "", "msg": "message held", "deliver": until.Unix()})
+		return true
+	}
+	return false
+}
+
+func (c *Core) deliverHeldLoop(stop chan struct{}) {
+	defer c.wg.Done()
+	ticker := time.NewTicker(heldDeliverInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			c.deliverHeldMessages(now)
+		}
+	}
+}
+
+// deliverHeldMessages push held messages which quiet hours ended before now,
+// all due messages are popped before grouping so a window is merged into one notification
+func (c *Core) deliverHeldMessages(now time.Time) {
+	keys := []string{}
+	groups := map[string][]*model.Message{}
+	modes := map[string]*model.HeldMessage{}
+	for {
+		held, err := c.logic.PopHeldMessages(now, heldDeliverBatch)
+		if err != nil {
+			log.Println("Pop held messages failed:", err)
+			break
+		}
+		for _, h := range held {
+			if h.Mode == logic.HeldModeRepeat {
+				c.repeatMessage(string(h.Data))
+				continue
+			}
+			msg, err := h.Message()
+			if err != nil {
+				continue
+			}
+			key := heldGroupKey(h, msg)
+			if len(key) <= 0 {
+				if !c.batchMessage(&luaSendContext{}, h.UID, msg) {
+					c.pushHeldMessage(h.UID, msg)
+				}
+				continue
+			}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+				modes[key] = h
+			}
+			groups[key] = append(groups[key], msg)
+		}
+		if len(held) < heldDeliverBatch {
+			break
+		}
+	}
+	for _, key := range keys {
+		h := modes[key]
+		if h.Mode == logic.HeldModeDigest {
+			c.pushHeldMessage(h.UID, makeDigestMessage(groups[key]))
+		} else {
+			c.pushHeldMessage(h.UID, c.makeBatchMessage(groups[key]))
+		}
+	}
+}
+
+// heldGroupKey return key to merge held messages, empty if message is delivered alone
+func heldGroupKey(h *model.HeldMessage, msg *model.Message) string {
+	switch h.Mode {
+	case logic.HeldModeDigest:
+		return fmt.Sprintf("%d:%s", h.Mode, h.UID)
+	case logic.HeldModeBatchChannel:
+		return fmt.Sprintf("%d:%s:%x", h.Mode, h.UID, msg.Channel)
+	case logic.HeldModeBatchToken:
+		return fmt.Sprintf("%d:%s:%x:%x", h.Mode, h.UID, msg.Channel, msg.TokenHash)
+	}
+	return ""
+}
+
+func (c *Core) pushHeldMessage(uid string, msg *model.Message) {
+	lc := &luaSendContext{}
+	c.pushMessage(lc, uid, msg)
+	if lc.code != http.StatusOK {
+		log.Println("Deliver held message failed:", fixLog(uid), lc.String())
+	}
+}
+
+// makeDigestMessage combine messages into one text message
+func makeDigestMessage(msgs []*model.Message) *model.Message {
+	lines := []string{}
+	size := 0
+	for idx, msg := range msgs {
+		var content pb.MsgContent
+		proto.Unmarshal(msg.Content, &content) // nolint: errcheck
+		line := strings.SplitN(strings.TrimSpace(content.Text), "\n", 2)[0]
+		if len(line) <= 0 {
+			line = "[" + content.Type.String() + "]"
+		}
+		if len(content.Title) > 0 {
+			line = content.Title + ": " + line
+		}
+		if size+len(line) > digestMaxText {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(msgs)-idx))
+			break
+		}
+		size += len(line) + 1
+		lines = append(lines, "• "+line)
+	}
+	digest := &model.Message{}
+	digest.From = msgs[0].From
+	return digest.TextContent(strings.Join(lines, "\n"), fmt.Sprintf("%d messages during quiet hours", len(msgs)), "", "")
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func newDNDTestUser(t *testing.T, c *Core, serverless bool) (*crypto.SecretKey, string) {
+	sk := crypto.GenerateSecretKey([]byte("dnd"))
+	uid := sk.ToID(0x00)
+	if _, err := c.logic.UpsertUser(uid, sk.EncodePublicKey(), serverless); err != nil {
+		t.Fatal("Create user failed:", err)
+	}
+	return sk, uid
+}
+
+func newDNDTestToken(uid string) *model.Token {
+	data, _ := proto.Marshal(&pb.Token{UserId: uid})
+	tk, _ := model.ParseToken(crypto.Base64Encode.EncodeToString(data) + "..")
+	return tk
+}
+
+func TestUserDNDHandler(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true}) // nolint: errcheck
+	sk, uid := newDNDTestUser(t, c, false)
+	handler := c.APIHandler()
+	post := func(body string, sign bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/rest/v1/dnd", strings.NewReader(body))
+		if sign {
+			sig, _ := sk.Sign([]byte(body))
+			req.Header.Set("CHUserSign", crypto.Base64Encode.EncodeToString(sig))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	w := post(`{"nonce":1,"user":"`+uid+`","dnd":{"action":"hold","schedules":[{"days":"mon-fri","start":"22:00","end":"07:00"}]}}`, true)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"action":"hold"`) {
+		t.Fatal("Set user dnd failed:", w.Code, w.Body.String())
+	}
+	w = post(`{"nonce":2,"user":"`+uid+`"}`, true)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"days":"mon-fri"`) {
+		t.Error("Get user dnd failed:", w.Code, w.Body.String())
+	}
+	w = post(`{"nonce":3,"user":"`+uid+`","dnd":{"action":"mute","schedules":[]}}`, true)
+	if w.Code != http.StatusBadRequest {
+		t.Error("Check invalid user dnd failed:", w.Code, w.Body.String())
+	}
+	w = post(`{"nonce":4,"user":"`+uid+`","dnd":null}`, true)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"dnd":null`) {
+		t.Error("Clear user dnd failed:", w.Code, w.Body.String())
+	}
+	tests := map[string]int{
+		`{`:                                http.StatusBadRequest,
+		`{"user":"abc"}`:                   http.StatusBadRequest,
+		`{"nonce":5,"user":"` + uid + `"}`: http.StatusUnauthorized,
+	}
+	for body, code := range tests {
+		if w := post(body, false); w.Code != code {
+			t.Error("Check user dnd params failed:", body, w.Code)
+		}
+	}
+	newDNDTestUser(t, c, true)
+	if w := post(`{"nonce":6,"user":"`+uid+`"}`, true); w.Code != http.StatusBadRequest {
+		t.Error("Check serverless user dnd failed:", w.Code)
+	}
+}
+
+func TestHoldMessage(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true}) // nolint: errcheck
+	_, uid := newDNDTestUser(t, c, false)
+	tk := newDNDTestToken(uid)
+	send := func(msg *model.Message) *luaSendContext {
+		lc := &luaSendContext{}
+		c.sendDirect(lc, tk, msg)
+		return lc
+	}
+	msg := model.NewMessage(tk).TextContent("hello", "", "", "").SoundName("1")
+	if lc := send(msg); lc.code != http.StatusNotFound || msg.Sound == nil {
+		t.Error("Check send without dnd failed:", lc.code, lc.String())
+	}
+
+	policy := &logic.DNDPolicy{Action: logic.DNDPassive, MinPriority: 10, Schedules: []*logic.DNDSchedule{{Start: "00:00", End: "00:00"}}}
+	c.logic.SetUserDND(uid, policy) // nolint: errcheck
+	if lc := send(msg); lc.code != http.StatusNotFound || msg.Sound != nil || msg.InterruptionLevel != pb.InterruptionLevel_IlPassive {
+		t.Error("Check send passive dnd failed:", lc.code, msg.Sound, msg.InterruptionLevel)
+	}
+
+	policy.Action = logic.DNDHold
+	c.logic.SetUserDND(uid, policy) // nolint: errcheck
+	if lc := send(model.NewMessage(tk).TextContent("hello", "", "", "")); lc.code != http.StatusOK || !strings.Contains(lc.String(), "message held") {
+		t.Error("Check send hold dnd failed:", lc.code, lc.String())
+	}
+	if lc := send(model.NewMessage(tk).TextContent("disk full", "", "", "").SetTTL(time.Minute)); lc.code != http.StatusOK || !strings.Contains(lc.String(), "message expired") {
+		t.Error("Check send hold dnd out of ttl failed:", lc.code, lc.String())
+	}
+	tracked := model.NewMessage(tk).TextContent("deploy", "", "", "").SoundName("1").SetAck(&model.AckOptions{})
+	if lc := send(tracked); lc.code != http.StatusNotFound || tracked.Sound != nil || tracked.InterruptionLevel != pb.InterruptionLevel_IlPassive {
+		t.Error("Check send hold dnd with ack failed:", lc.code, lc.String())
+	}
+	if lc := send(model.NewMessage(tk).TextContent("urgent", "", "", "").SetPriority(10)); lc.code != http.StatusNotFound {
+		t.Error("Check send dnd min priority failed:", lc.code, lc.String())
+	}
+
+	policy.Action = logic.DNDDigest
+	c.logic.SetUserDND(uid, policy) // nolint: errcheck
+	for i := 0; i < 2; i++ {
+		if lc := send(model.NewMessage(tk).TextContent("hello", "", "", "")); lc.code != http.StatusOK {
+			t.Error("Check send digest dnd failed:", lc.code, lc.String())
+		}
+	}
+	c.deliverHeldMessages(time.Now())
+	if held, _ := c.logic.PopHeldMessages(time.Now().Add(48*time.Hour), 10); len(held) != 3 {
+		t.Fatal("Check held messages failed:", len(held))
+	}
+	for i := 0; i < heldDeliverBatch+1; i++ {
+		c.logic.HoldMessage(uid, logic.DNDDigest, time.Now(), msg) // nolint: errcheck
+	}
+	c.logic.HoldMessage(uid, logic.DNDHold, time.Now(), msg) // nolint: errcheck
+	c.deliverHeldMessages(time.Now())
+	if held, _ := c.logic.PopHeldMessages(time.Now(), 10); len(held) != 0 {
+		t.Error("Check deliver held messages failed:", len(held))
+	}
+}
+
+func TestDeliverHeldMessages(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true}) // nolint: errcheck
+	_, uid := newDNDTestUser(t, c, false)
+	c.logic.BindDevice(uid, "B3BC1B875EDA13986801B1004B4ABF5760C197F4", "BDuFNLkmxyK0-NN3H3oKzzOtISq1w17-JAibD7X4pljYl6IEaEglWkKD5Iw537h-DYxAooXkHtu6un078sm7IiQ", 1) // nolint: errcheck
+	c.logic.UpdatePushToken(uid, "B3BC1B875EDA13986801B1004B4ABF5760C197F4", "aGVsbG8", false)                                                                        // nolint: errcheck
+	pusher := &MockAPNSPusher{}
+	logic.MockPusher = pusher
+	defer func() { logic.MockPusher = nil }()
+	tk := newDNDTestToken(uid)
+
+	msg := model.NewMessage(tk).TextContent("hello", "", "", "").SetTTL(time.Hour)
+	c.logic.HoldMessage(uid, logic.DNDHold, time.Now(), msg) // nolint: errcheck
+	c.deliverHeldMessages(time.Now())
+	if pusher.Notification == nil {
+		t.Fatal("Deliver held message failed")
+	}
+	if exp := time.Until(pusher.Notification.Expiration); exp < 59*time.Minute || exp > time.Hour {
+		t.Error("Check held message expiration failed:", exp)
+	}
+
+	pusher.Count = 0
+	for i := 0; i < heldDeliverBatch*2+1; i++ {
+		c.logic.HoldMessage(uid, logic.DNDDigest, time.Now(), model.NewMessage(tk).TextContent("hello", "", "", "")) // nolint: errcheck
+	}
+	c.deliverHeldMessages(time.Now())
+	if pusher.Count != 1 {
+		t.Error("Check digest held messages across pages failed:", pusher.Count)
+	}
+
+	pusher.Notification = nil
+	c.logic.HoldMessage(uid, logic.DNDHold, time.Now(), model.NewMessage(tk).TextContent("hello", "", "", "").SetBatch(time.Minute)) // nolint: errcheck
+	c.deliverHeldMessages(time.Now())
+	if held, _ := c.logic.PopHeldMessages(time.Now().Add(time.Minute), 10); pusher.Notification != nil || len(held) != 1 || held[0].Mode != logic.HeldModeBatchToken {
+		t.Error("Check held message batch failed:", pusher.Notification, len(held))
+	}
+}
+
+func TestMakeDigestMessage(t *testing.T) {
+	tk := &model.Token{}
+	msgs := []*model.Message{
+		model.NewMessage(tk).TextContent("line1\nline2", "Title", "", ""),
+		model.NewMessage(tk).LinkContent("https://example.com"),
+	}
+	var content pb.MsgContent
+	proto.Unmarshal(makeDigestMessage(msgs).Content, &content) // nolint: errcheck
+	if content.Title != "2 messages during quiet hours" || content.Text != "• Title: line1\n• [Link]" {
+		t.Error("Check digest message failed:", content.Title, content.Text)
+	}
+	for i := 0; i < 100; i++ {
+		msgs = append(msgs, model.NewMessage(tk).TextContent(strings.Repeat("a", 100), "", "", ""))
+	}
+	proto.Unmarshal(makeDigestMessage(msgs).Content, &content) // nolint: errcheck
+	if len(content.Text) > digestMaxText+100 || !strings.HasSuffix(content.Text, "more") {
+		t.Error("Check digest message limit failed:", len(content.Text))
+	}
+}
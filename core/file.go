@@ -17,6 +17,11 @@ func (c *Core) handleAudioDownload(ctx *gin.Context) {
 	c.downloadAudioFile(ctx, token)
 }
 
+func (c *Core) handleVideoDownload(ctx *gin.Context) {
+	token, _ := c.parseToken(getToken(ctx))
+	c.downloadVideoFile(ctx, token)
+}
+
 func (c *Core) handleFileDownload(ctx *gin.Context) {
 	token, _ := c.parseToken(getToken(ctx))
 	c.downloadFile(ctx, token)
@@ -63,7 +68,29 @@ func (c *Core) downloadAudioFile(ctx *gin.Context, token *model.Token) {
 		ctx.AbortWithStatus(http.StatusNotFound)
 		return
 	}
-	ctx.Data(http.StatusOK, "audio/mpeg", data)
+	ctx.Data(http.StatusOK, parseAudioContentType(data), data)
+}
+
+func (c *Core) downloadVideoFile(ctx *gin.Context, token *model.Token) {
+	if token == nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	if !token.VerifyDataHash([]byte(ctx.Request.URL.Path)) {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	fname := ctx.Param("fname")
+	if len(fname) <= 0 {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	data, err := c.logic.LoadFile("videos", fname)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	ctx.Data(http.StatusOK, parseVideoContentType(data), data)
 }
 
 func (c *Core) downloadFile(ctx *gin.Context, token *model.Token) {
@@ -142,6 +142,71 @@ func TestAudioFileFailed(t *testing.T) {
 	}
 }
 
+func TestVideoFile(t *testing.T) {
+	fpath := filepath.Join(os.TempDir(), "files")
+	defer os.RemoveAll(fpath)
+	os.MkdirAll(fpath+"/videos/", os.ModePerm)                      // nolint: errcheck
+	os.WriteFile(fpath+"/videos/1234567890", []byte("hello"), 0644) // nolint: errcheck
+	logic.APIEndpoint = "http://127.0.0.1"
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", FilePath: fpath}) // nolint: errcheck
+
+	tk, _ := model.ParseToken("EgMxMjMiBGNoYW4qBU1GUkdHMhTa8bNpUg6Q3vMvx_3eTkFjaR1pFg..c2lnbg") // nolint: errcheck
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request, _ = http.NewRequest("GET", "/files/videos/1234567890", nil)
+	ctx.Request.URL.Path = "/files/videos/1234567890"
+	ctx.Params = []gin.Param{{Key: "fname", Value: "1234567890"}}
+	c.downloadVideoFile(ctx, tk)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatal("Download video hash failed", w.Result().StatusCode)
+	}
+}
+
+func TestVideoFileFailed(t *testing.T) {
+	logic.APIEndpoint = "http://127.0.0.1"
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory"}) // nolint: errcheck
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request, _ = http.NewRequest("GET", "/files/videos/1234567890", nil)
+	c.handleVideoDownload(ctx)
+	if w.Result().StatusCode != http.StatusUnauthorized {
+		t.Fatal("Check download token failed")
+	}
+
+	tk, _ := model.ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRzIUx5tXg-Vym58og7aZw05IkoDvse8..c2lnbg") // nolint: errcheck
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	ctx.Request, _ = http.NewRequest("GET", "/files/videos/1234567890", nil)
+	c.downloadVideoFile(ctx, tk)
+	if w.Result().StatusCode != http.StatusUnauthorized {
+		t.Fatal("Check download url hash failed")
+	}
+
+	tk, _ = model.ParseToken("EgMxMjMiBGNoYW4qBU1GUkdHMhTa8bNpUg6Q3vMvx_3eTkFjaR1pFg..c2lnbg") // nolint: errcheck
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	ctx.Request, _ = http.NewRequest("GET", "/files/videos/1234567890", nil)
+	ctx.Request.URL.Path = "/files/videos/1234567890"
+	c.downloadVideoFile(ctx, tk)
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatal("Check download video url hash failed")
+	}
+
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	ctx.Request, _ = http.NewRequest("GET", "/files/videos/1234567890", nil)
+	ctx.Request.URL.Path = "/files/videos/1234567890"
+	ctx.Params = []gin.Param{{Key: "fname", Value: "1234567890"}}
+	c.downloadVideoFile(ctx, tk)
+	if w.Result().StatusCode != http.StatusNotFound {
+		t.Fatal("Check download video name failed")
+	}
+}
+
 func TestFile(t *testing.T) {
 	fpath := filepath.Join(os.TempDir(), "files")
 	defer os.RemoveAll(fpath)
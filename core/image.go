@@ -0,0 +1,453 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	jpegMarkerSOI  = 0xD8
+	jpegMarkerSOS  = 0xDA
+	jpegMarkerAPP0 = 0xE0
+	jpegMarkerAPP1 = 0xE1
+	jpegMarkerAPP2 = 0xE2
+	jpegMarkerAPPE = 0xEE
+	jpegMarkerCOM  = 0xFE
+
+	exifHeader         = "Exif\x00\x00"
+	exifTagOrientation = 0x0112
+)
+
+// imageMaxPixels is the max pixels of image to decode for resizing, larger images are kept as is
+var imageMaxPixels = 50 * 1000 * 1000
+
+// prepareImage resize the image to max size and strip the private metadata
+func prepareImage(data []byte, maxSize int) []byte {
+	if maxSize > 0 {
+		if out := resizeImage(data, maxSize); out != nil {
+			return out
+		}
+	}
+	return stripImageMetadata(data)
+}
+
+func resizeImage(data []byte, maxSize int) []byte {
+	ctype := parseImageContentType(data)
+	switch ctype {
+	case "image/gif", "image/heic", "image/avif":
+		return nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (cfg.Width <= maxSize && cfg.Height <= maxSize) {
+		return nil
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > imageMaxPixels/cfg.Height {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	img = orientImage(scaleImage(img, maxSize), parseImageOrientation(data))
+	var out bytes.Buffer
+	if ctype == "image/png" {
+		err = png.Encode(&out, img)
+	} else {
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil
+	}
+	return out.Bytes()
+}
+
+func scaleImage(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+	if w > h {
+		w, h = maxSize, h*maxSize/w
+	} else {
+		w, h = w*maxSize/h, maxSize
+	}
+	if w <= 0 {
+		w = 1
+	}
+	if h <= 0 {
+		h = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+func parseImageOrientation(data []byte) int {
+	if parseImageContentType(data) != "image/jpeg" {
+		return 1
+	}
+	orientation := 1
+	walkJPEGSegments(data, func(marker byte, seg []byte) bool {
+		if body := seg[4:]; marker == jpegMarkerAPP1 && bytes.HasPrefix(body, []byte(exifHeader)) {
+			orientation = parseExifOrientation(body[len(exifHeader):])
+			return false
+		}
+		return true
+	})
+	return orientation
+}
+
+func parseExifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == exifTagOrientation {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			break
+		}
+	}
+	return 1
+}
+
+func walkJPEGSegments(data []byte, fn func(marker byte, seg []byte) bool) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegMarkerSOI {
+		return -1
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return -1
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == jpegMarkerSOS {
+			return pos
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if size < 2 || pos+2+size > len(data) {
+			return -1
+		}
+		if !fn(marker, data[pos:pos+2+size]) {
+			return pos
+		}
+		pos += 2 + size
+	}
+	return -1
+}
+
+func stripImageMetadata(data []byte) []byte {
+	var out []byte
+	switch parseImageContentType(data) {
+	case "image/jpeg":
+		out = stripJPEGMetadata(data)
+	case "image/png":
+		out = stripPNGMetadata(data)
+	case "image/webp":
+		out = stripWEBPMetadata(data)
+	case "image/heic", "image/avif":
+		out = stripHEIFMetadata(data)
+	}
+	if out == nil {
+		return data
+	}
+	return out
+}
+
+func stripJPEGMetadata(data []byte) []byte {
+	orientation := 1
+	segs := [][]byte{}
+	sos := walkJPEGSegments(data, func(marker byte, seg []byte) bool {
+		body := seg[4:]
+		switch {
+		case marker == jpegMarkerAPP1:
+			if bytes.HasPrefix(body, []byte(exifHeader)) {
+				orientation = parseExifOrientation(body[len(exifHeader):])
+			}
+			return true
+		case marker == jpegMarkerAPP2:
+			if !bytes.HasPrefix(body, []byte("ICC_PROFILE\x00")) {
+				return true
+			}
+		case marker == jpegMarkerCOM, marker > jpegMarkerAPP2 && marker < jpegMarkerAPPE, marker == 0xEF:
+			return true
+		}
+		segs = append(segs, seg)
+		return true
+	})
+	if sos < 0 {
+		return nil
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, jpegMarkerSOI)
+	if len(segs) > 0 && segs[0][1] == jpegMarkerAPP0 {
+		out = append(out, segs[0]...)
+		segs = segs[1:]
+	}
+	if orientation > 1 {
+		// minimal exif only with orientation
+		out = append(out, 0xFF, jpegMarkerAPP1, 0x00, 0x22)
+		out = append(out, exifHeader...)
+		out = append(out, 'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, 0x00, 0x01)
+		out = append(out, 0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, byte(orientation), 0x00, 0x00)
+		out = append(out, 0x00, 0x00, 0x00, 0x00)
+	}
+	for _, seg := range segs {
+		out = append(out, seg...)
+	}
+	return append(out, data[sos:]...)
+}
+
+func stripPNGMetadata(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:len(pngHeader)]...)
+	pos := len(pngHeader)
+	for pos+12 <= len(data) {
+		size := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + size
+		if size < 0 || end > len(data) {
+			return nil
+		}
+		switch string(data[pos+4 : pos+8]) {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return out
+}
+
+func stripWEBPMetadata(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+	pos := 12
+	for pos+8 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size&0x01
+		if size < 0 || end > len(data) {
+			return nil
+		}
+		switch string(data[pos : pos+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[pos:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x0C // clear exif & xmp flags
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out
+}
+
+// stripHEIFMetadata wipe exif & xmp items in place, so that the item locations keep unchanged
+func stripHEIFMetadata(data []byte) []byte {
+	meta := findMP4Box(data, "meta")
+	if len(meta) < 4 {
+		return nil
+	}
+	meta = meta[4:]
+	items := map[uint32]bool{}
+	if iinf := findMP4Box(meta, "iinf"); len(iinf) > 6 {
+		n := 6
+		if iinf[0] > 0 {
+			n = 8
+		}
+		walkMP4Boxes(iinf[n:], func(name string, infe []byte) bool {
+			if name != "infe" || len(infe) < 4 || infe[0] < 2 {
+				return true
+			}
+			var id uint32
+			p := 4
+			if infe[0] == 2 && len(infe) >= 12 {
+				id = uint32(binary.BigEndian.Uint16(infe[4:]))
+				p = 8
+			} else if len(infe) >= 14 {
+				id = binary.BigEndian.Uint32(infe[4:])
+				p = 10
+			} else {
+				return true
+			}
+			itemType := string(infe[p : p+4])
+			if itemType == "Exif" || (itemType == "mime" && bytes.Contains(infe[p+4:], []byte("application/rdf+xml"))) {
+				items[id] = true
+			}
+			return true
+		})
+	}
+	if len(items) <= 0 {
+		return data
+	}
+	out := append([]byte{}, data...)
+	iloc := findMP4Box(meta, "iloc")
+	if len(iloc) < 8 {
+		return nil
+	}
+	version := iloc[0]
+	offsetSize := int(iloc[4] >> 4)
+	lengthSize := int(iloc[4] & 0x0F)
+	baseSize := int(iloc[5] >> 4)
+	indexSize := 0
+	if version > 0 {
+		indexSize = int(iloc[5] & 0x0F)
+	}
+	r := &heifReader{data: iloc[6:]}
+	count := r.read(2)
+	if version >= 2 {
+		count = r.read(4)
+	}
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		var id uint64
+		if version < 2 {
+			id = r.read(2)
+		} else {
+			id = r.read(4)
+		}
+		method := uint64(0)
+		if version > 0 {
+			method = r.read(2) & 0x0F
+		}
+		r.read(2) // data reference index
+		base := r.read(baseSize)
+		extents := r.read(2)
+		for j := uint64(0); j < extents && r.err == nil; j++ {
+			r.read(indexSize)
+			offset := r.read(offsetSize)
+			length := r.read(lengthSize)
+			if items[uint32(id)] && method == 0 {
+				start, end := base+offset, base+offset+length
+				if end > uint64(len(out)) || start > end {
+					return nil
+				}
+				for k := start; k < end; k++ {
+					out[k] = 0
+				}
+			}
+		}
+	}
+	if r.err != nil {
+		return nil
+	}
+	return out
+}
+
+type heifReader struct {
+	data []byte
+	err  error
+}
+
+func (r *heifReader) read(n int) uint64 {
+	if r.err != nil || n <= 0 {
+		return 0
+	}
+	if n > len(r.data) {
+		r.err = ErrInvalidContent
+		return 0
+	}
+	var v uint64
+	for _, b := range r.data[:n] {
+		v = v<<8 | uint64(b)
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func parseHEIFSize(data []byte) (int, int) {
+	meta := findMP4Box(data, "meta")
+	if len(meta) < 4 {
+		return 0, 0
+	}
+	ipco := findMP4Box(findMP4Box(meta[4:], "iprp"), "ipco")
+	w, h, rotate := 0, 0, false
+	walkMP4Boxes(ipco, func(name string, body []byte) bool {
+		switch name {
+		case "ispe":
+			if len(body) >= 12 {
+				iw := int(binary.BigEndian.Uint32(body[4:]))
+				ih := int(binary.BigEndian.Uint32(body[8:]))
+				if iw*ih > w*h {
+					w, h = iw, ih
+				}
+			}
+		case "irot":
+			if len(body) > 0 {
+				rotate = body[0]&0x01 != 0
+			}
+		}
+		return true
+	})
+	if rotate {
+		w, h = h, w
+	}
+	return w, h
+}
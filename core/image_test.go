@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/bmp"
+)
+
+func testJPEGData(w int, h int, orientation int) []byte {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil) // nolint: errcheck
+	data := buf.Bytes()
+	exif := []byte("Exif\x00\x00II\x2a\x00\x08\x00\x00\x00\x02\x00")
+	exif = append(exif, 0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, byte(orientation), 0x00, 0x00, 0x00)
+	exif = append(exif, 0x25, 0x88, 0x04, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00) // GPS
+	exif = append(exif, 0x00, 0x00, 0x00, 0x00)
+	app1 := []byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}
+	com := []byte{0xFF, 0xFE, 0x00, 0x06, 'G', 'P', 'S', '!'}
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	out = append(out, exif...)
+	out = append(out, com...)
+	return append(out, data[2:]...)
+}
+
+func TestImageOrientation(t *testing.T) {
+	data := testJPEGData(4, 2, 6)
+	if parseImageOrientation(data) != 6 {
+		t.Fatal("Parse image orientation failed")
+	}
+	thumbnail := createThumbnail(data)
+	if thumbnail == nil {
+		t.Fatal("Create oriented thumbnail failed")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for i := 1; i <= 8; i++ {
+		b := orientImage(img, i).Bounds()
+		if (i < 5 && (b.Dx() != 4 || b.Dy() != 2)) || (i >= 5 && (b.Dx() != 2 || b.Dy() != 4)) {
+			t.Error("Orient image failed:", i, b)
+		}
+	}
+	if parseImageOrientation(testJPEGData(4, 2, 1)) != 1 {
+		t.Error("Parse normal orientation failed")
+	}
+	if parseExifOrientation([]byte("XX\x2a\x00\x08\x00\x00\x00")) != 1 {
+		t.Error("Check invalid exif failed")
+	}
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	data := testJPEGData(4, 2, 6)
+	out := stripImageMetadata(data)
+	if len(out) >= len(data) || bytes.Contains(out, []byte("GPS!")) {
+		t.Fatal("Strip jpeg metadata failed")
+	}
+	if parseImageOrientation(out) != 6 {
+		t.Error("Keep jpeg orientation failed")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Error("Decode stripped jpeg failed:", err)
+	}
+	if !bytes.Equal(stripImageMetadata([]byte("1234567890abcdef")), []byte("1234567890abcdef")) {
+		t.Error("Check invalid jpeg failed")
+	}
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))) // nolint: errcheck
+	data := buf.Bytes()
+	text := []byte("\x00\x00\x00\x04tEXtGPS!\x00\x00\x00\x00")
+	in := append(append(append([]byte{}, data[:33]...), text...), data[33:]...)
+	out := stripImageMetadata(in)
+	if !bytes.Equal(out, data) {
+		t.Fatal("Strip png metadata failed")
+	}
+}
+
+func TestStripWEBPMetadata(t *testing.T) {
+	data, _ := base64.StdEncoding.DecodeString("UklGRiQAAABXRUJQVlA4IBgAAAAwAQCdASoBAAEAAgA0JaQAA3AA/vuUAAA=")
+	in := append(append([]byte{}, data...), []byte("EXIF\x03\x00\x00\x00GPS\x00")...)
+	binary.LittleEndian.PutUint32(in[4:], uint32(len(in)-8))
+	out := stripImageMetadata(in)
+	if !bytes.Equal(out, data) {
+		t.Fatal("Strip webp metadata failed")
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	data := testJPEGData(40, 20, 6)
+	out := prepareImage(data, 10)
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal("Decode resized image failed:", err)
+	}
+	if cfg.Width != 5 || cfg.Height != 10 {
+		t.Error("Resize image failed:", cfg.Width, cfg.Height)
+	}
+	if !bytes.Equal(prepareImage(out, 20), stripImageMetadata(out)) {
+		t.Error("Check small image resize failed")
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 30, 30))) // nolint: errcheck
+	if cfg, err := png.DecodeConfig(bytes.NewReader(prepareImage(buf.Bytes(), 10))); err != nil || cfg.Width != 10 {
+		t.Error("Resize png image failed")
+	}
+	maxPixels := imageMaxPixels
+	imageMaxPixels = 100
+	defer func() { imageMaxPixels = maxPixels }()
+	if out := resizeImage(buf.Bytes(), 10); out != nil {
+		t.Error("Check resize too many pixels image failed")
+	}
+}
+
+func TestBMPImage(t *testing.T) {
+	var buf bytes.Buffer
+	bmp.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))) // nolint: errcheck
+	if parseImageContentType(buf.Bytes()) != "image/bmp" {
+		t.Fatal("Check bmp content type failed")
+	}
+	if createThumbnail(buf.Bytes()) == nil {
+		t.Error("Create bmp thumbnail failed")
+	}
+}
+
+func fullBox(name string, version byte, body ...[]byte) []byte {
+	return mp4Box(name, append([][]byte{{version, 0, 0, 0}}, body...)...)
+}
+
+func testHEIFData(brand string) []byte {
+	infe := fullBox("infe", 2, []byte{0x00, 0x01, 0x00, 0x00}, []byte("Exif\x00"))
+	iinf := fullBox("iinf", 0, []byte{0x00, 0x01}, infe)
+	ispe := fullBox("ispe", 0, []byte{0, 0, 0x0F, 0, 0, 0, 0x0A, 0})
+	iprp := mp4Box("iprp", mp4Box("ipco", ispe, mp4Box("irot", []byte{1})))
+	ftyp := mp4Box("ftyp", []byte(brand+"\x00\x00\x00\x00"))
+	// iloc v0: offset_size=4, length_size=4, base_offset_size=0
+	iloc := fullBox("iloc", 0, []byte{0x44, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, make([]byte, 8))
+	meta := fullBox("meta", 0, iinf, iloc, iprp)
+	data := append(ftyp, meta...)
+	offset := len(data) + 8
+	pos := bytes.Index(data, []byte("iloc")) + 4 + 4 + 10
+	binary.BigEndian.PutUint32(data[pos:], uint32(offset))
+	binary.BigEndian.PutUint32(data[pos+4:], 8)
+	return append(data, mp4Box("mdat", []byte("GPS!GPS!"))...)
+}
+
+func TestHEIFImage(t *testing.T) {
+	data := testHEIFData("heic")
+	if parseImageContentType(data) != "image/heic" || parseImageContentType(testHEIFData("avif")) != "image/avif" {
+		t.Fatal("Check heif content type failed")
+	}
+	if w, h := parseHEIFSize(data); w != 2560 || h != 3840 {
+		t.Error("Parse heif size failed:", w, h)
+	}
+	if createThumbnail(data) == nil {
+		t.Error("Create heif thumbnail failed")
+	}
+	out := prepareImage(data, 100)
+	if len(out) != len(data) || bytes.Contains(out, []byte("GPS!")) {
+		t.Error("Strip heif metadata failed")
+	}
+}
@@ -0,0 +1,281 @@
+package core
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	formatText     = "text"
+	formatMarkdown = "markdown"
+)
+
+var (
+	mdFenceRegex     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	mdHeadingRegex   = regexp.MustCompile(`^ {0,3}#{1,6}(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	mdSetextRegex    = regexp.MustCompile(`^ {0,3}(?:=+|-+)\s*$`)
+	mdBreakRegex     = regexp.MustCompile(`^ {0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	mdQuoteRegex     = regexp.MustCompile(`^ {0,3}(?:>\s?)+`)
+	mdBulletRegex    = regexp.MustCompile(`^(\s*)[-*+]\s+(?:\[([ xX])\]\s+)?`)
+	mdDelimRegex     = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdImageRegex     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLinkRegex      = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdRefLinkRegex   = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	mdAutoLinkRegex  = regexp.MustCompile(`<((?:https?|mailto|ftp):[^>\s]+)>`)
+	mdBrRegex        = regexp.MustCompile(`(?i)<br\s*/?>`)
+	mdTagRegex       = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	mdStrongRegex    = regexp.MustCompile(`(\*\*|__)([^\s*_](?:.*?[^\s*_])?)(\*\*|__)`)
+	mdStrikeRegex    = regexp.MustCompile(`~~([^\s~](?:.*?[^\s~])?)~~`)
+	mdEmStarRegex    = regexp.MustCompile(`\*([^\s*](?:[^*]*[^\s*])?)\*`)
+	mdEmLineRegex    = regexp.MustCompile(`(^|[^\w])_([^\s_](?:[^_]*[^\s_])?)_([^\w]|$)`)
+	mdEscapeRegex    = regexp.MustCompile(`\\([!-/:-@\[-` + "`" + `{-~])`)
+	mdBlankLineRegex = regexp.MustCompile(`\n{3,}`)
+)
+
+func parseTextFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "text", "plain":
+		return formatText, nil
+	case "markdown", "md":
+		return formatMarkdown, nil
+	}
+	return "", ErrInvalidContent
+}
+
+// normalizeMarkdown fix line endings, close dangling code fences and align tables of CommonMark text.
+// Markdown syntax is not checked, only text with invalid UTF-8 or NUL is rejected.
+func normalizeMarkdown(text string) (string, error) {
+	if !utf8.ValidString(text) || strings.ContainsRune(text, 0) {
+		return "", ErrInvalidContent
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if len(fence) > 0 {
+			if m := mdFenceRegex.FindStringSubmatch(line); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) && len(strings.TrimSpace(m[2])) <= 0 {
+				fence = ""
+				line = strings.TrimRight(line, " \t")
+			}
+			out = append(out, line)
+			continue
+		}
+		if m := mdFenceRegex.FindStringSubmatch(line); m != nil && !(m[1][0] == '`' && strings.ContainsRune(m[2], '`')) {
+			fence = m[1]
+			out = append(out, strings.TrimRight(line, " \t"))
+			continue
+		}
+		if i+1 < len(lines) && isMarkdownTable(line, lines[i+1]) {
+			n := i + 2
+			for n < len(lines) && strings.ContainsRune(lines[n], '|') && len(strings.TrimSpace(lines[n])) > 0 {
+				n++
+			}
+			out = append(out, normalizeMarkdownTable(lines[i], lines[i+1], lines[i+2:n])...)
+			i = n - 1
+			continue
+		}
+		trimmed := strings.TrimRight(line, " \t")
+		if len(trimmed) <= 0 && len(out) > 0 && len(out[len(out)-1]) <= 0 {
+			continue
+		}
+		if len(trimmed) > 0 && strings.HasSuffix(line, "  ") && !mdHeadingRegex.MatchString(trimmed) && i+1 < len(lines) && len(strings.TrimSpace(lines[i+1])) > 0 {
+			trimmed += "\\"
+		}
+		out = append(out, trimmed)
+	}
+	if len(fence) > 0 {
+		out = append(out, fence)
+	}
+	text = strings.Trim(strings.Join(out, "\n"), "\n")
+	if len(strings.TrimSpace(text)) <= 0 {
+		return "", ErrNoContent
+	}
+	return text, nil
+}
+
+func isMarkdownTable(header string, delim string) bool {
+	return strings.ContainsRune(header, '|') && mdDelimRegex.MatchString(delim) && len(splitMarkdownTableRow(header)) == len(splitMarkdownTableRow(delim))
+}
+
+func normalizeMarkdownTable(header string, delim string, rows []string) []string {
+	heads := splitMarkdownTableRow(header)
+	aligns := splitMarkdownTableRow(delim)
+	cols := len(heads)
+	out := make([]string, 0, len(rows)+2)
+	out = append(out, joinMarkdownTableRow(heads, cols))
+	ds := make([]string, cols)
+	for i := range ds {
+		ds[i] = "---"
+		if i < len(aligns) {
+			a := aligns[i]
+			left, right := strings.HasPrefix(a, ":"), strings.HasSuffix(a, ":")
+			switch {
+			case left && right:
+				ds[i] = ":---:"
+			case left:
+				ds[i] = ":---"
+			case right:
+				ds[i] = "---:"
+			}
+		}
+	}
+	out = append(out, joinMarkdownTableRow(ds, cols))
+	for _, row := range rows {
+		out = append(out, joinMarkdownTableRow(splitMarkdownTableRow(row), cols))
+	}
+	return out
+}
+
+func splitMarkdownTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, "\\|") {
+		row = row[:len(row)-1]
+	}
+	cells := []string{}
+	var sb strings.Builder
+	code := false
+	for i := 0; i < len(row); i++ {
+		ch := row[i]
+		switch {
+		case ch == '\\' && i+1 < len(row) && row[i+1] == '|':
+			sb.WriteString("\\|")
+			i++
+		case ch == '`':
+			code = !code
+			sb.WriteByte(ch)
+		case ch == '|' && !code:
+			cells = append(cells, strings.TrimSpace(sb.String()))
+			sb.Reset()
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return append(cells, strings.TrimSpace(sb.String()))
+}
+
+func joinMarkdownTableRow(cells []string, cols int) string {
+	if len(cells) > cols {
+		cells = cells[:cols]
+	}
+	for len(cells) < cols {
+		cells = append(cells, "")
+	}
+	return "| " + strings.Join(cells, " | ") + " |"
+}
+
+// markdownPlainText render markdown to plain text for notification body.
+func markdownPlainText(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if len(fence) > 0 {
+			if m := mdFenceRegex.FindStringSubmatch(line); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) {
+				fence = ""
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+		if m := mdFenceRegex.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			continue
+		}
+		line = mdQuoteRegex.ReplaceAllString(line, "")
+		if len(strings.TrimSpace(line)) > 0 && i > 0 && mdSetextRegex.MatchString(line) && len(strings.TrimSpace(lines[i-1])) > 0 && !strings.ContainsRune(lines[i-1], '|') {
+			continue
+		}
+		if mdBreakRegex.MatchString(line) {
+			out = append(out, "")
+			continue
+		}
+		if i+1 < len(lines) && isMarkdownTable(line, lines[i+1]) {
+			out = append(out, plainMarkdownTableRow(line))
+			i++
+			for i+1 < len(lines) && strings.ContainsRune(lines[i+1], '|') && len(strings.TrimSpace(lines[i+1])) > 0 {
+				i++
+				out = append(out, plainMarkdownTableRow(lines[i]))
+			}
+			continue
+		}
+		if m := mdHeadingRegex.FindStringSubmatch(line); m != nil {
+			out = append(out, plainMarkdownInline(m[1]))
+			continue
+		}
+		line = mdBulletRegex.ReplaceAllStringFunc(line, func(s string) string {
+			m := mdBulletRegex.FindStringSubmatch(s)
+			switch m[2] {
+			case " ":
+				return m[1] + "☐ "
+			case "x", "X":
+				return m[1] + "☑ "
+			}
+			return m[1] + "• "
+		})
+		out = append(out, strings.TrimRight(plainMarkdownInline(line), " \t"))
+	}
+	return strings.Trim(collapseMarkdownBlankLines(strings.Join(out, "\n")), "\n")
+}
+
+func plainMarkdownTableRow(row string) string {
+	cells := splitMarkdownTableRow(row)
+	for i, cell := range cells {
+		cells[i] = plainMarkdownInline(strings.ReplaceAll(cell, "\\|", "|"))
+	}
+	return strings.Join(cells, " | ")
+}
+
+func plainMarkdownInline(text string) string {
+	var sb strings.Builder
+	for len(text) > 0 {
+		start := strings.IndexByte(text, '`')
+		if start < 0 {
+			sb.WriteString(plainMarkdownSpan(text))
+			break
+		}
+		n := start
+		for n < len(text) && text[n] == '`' {
+			n++
+		}
+		ticks := text[start:n]
+		end := strings.Index(text[n:], ticks)
+		if end < 0 {
+			sb.WriteString(plainMarkdownSpan(text[:n]))
+			text = text[n:]
+			continue
+		}
+		sb.WriteString(plainMarkdownSpan(text[:start]))
+		sb.WriteString(strings.TrimSpace(text[n : n+end]))
+		text = text[n+end+len(ticks):]
+	}
+	return sb.String()
+}
+
+func plainMarkdownSpan(text string) string {
+	if strings.HasSuffix(text, "\\") && !strings.HasSuffix(text, "\\\\") {
+		text = text[:len(text)-1]
+	}
+	text = mdImageRegex.ReplaceAllString(text, "$1")
+	text = mdLinkRegex.ReplaceAllString(text, "$1")
+	text = mdRefLinkRegex.ReplaceAllString(text, "$1")
+	text = mdAutoLinkRegex.ReplaceAllString(text, "$1")
+	text = mdBrRegex.ReplaceAllString(text, "\n")
+	text = mdTagRegex.ReplaceAllString(text, "")
+	text = mdStrongRegex.ReplaceAllString(text, "$2")
+	text = mdStrikeRegex.ReplaceAllString(text, "$1")
+	text = mdEmStarRegex.ReplaceAllString(text, "$1")
+	text = mdEmLineRegex.ReplaceAllString(text, "$1$2$3")
+	text = mdEscapeRegex.ReplaceAllString(text, "$1")
+	return html.UnescapeString(text)
+}
+
+func collapseMarkdownBlankLines(text string) string {
+	return mdBlankLineRegex.ReplaceAllString(text, "\n\n")
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseTextFormat(t *testing.T) {
+	if f, err := parseTextFormat(""); err != nil || f != formatText {
+		t.Error("Parse default format failed")
+	}
+	if f, err := parseTextFormat("Markdown"); err != nil || f != formatMarkdown {
+		t.Error("Parse markdown format failed")
+	}
+	if _, err := parseTextFormat("html"); err != ErrInvalidContent {
+		t.Error("Check invalid format failed")
+	}
+}
+
+func TestNormalizeMarkdown(t *testing.T) {
+	md, err := normalizeMarkdown("\r\n# Deploy  \r\nline1  \r\nline2\r\n\r\n\r\n\r\nname|status\n-|:-:\napp|ok|extra\ndb\\|x|\n\n```sh\nmake\n\n\n  \ndone")
+	if err != nil {
+		t.Fatal("Normalize markdown failed:", err)
+	}
+	expected := "# Deploy\nline1\\\nline2\n\n| name | status |\n| --- | :---: |\n| app | ok |\n| db\\|x |  |\n\n```sh\nmake\n\n\n  \ndone\n```"
+	if md != expected {
+		t.Errorf("Check normalized markdown failed: %q", md)
+	}
+	if _, err := normalizeMarkdown("abc\xff"); err != ErrInvalidContent {
+		t.Error("Check invalid utf8 markdown failed")
+	}
+	if _, err := normalizeMarkdown(" \n\t\n"); err != ErrNoContent {
+		t.Error("Check empty markdown failed")
+	}
+}
+
+func TestMarkdownPlainText(t *testing.T) {
+	text := markdownPlainText(strings.Join([]string{
+		"Title",
+		"=====",
+		"## Build *passed* ##",
+		"> **Note**: see [logs](https://ci/1) and ![img](a.png) <https://ci>",
+		"- [x] test_case_1 `a*b*c`",
+		"* __deploy__ ~~old~~ 1 &lt; 2 \\*",
+		"",
+		"---",
+		"",
+		"| name | status |",
+		"| --- | --- |",
+		"| `a|b` | **ok** |",
+		"```",
+		"# not heading",
+		"```",
+	}, "\n"))
+	expected := strings.Join([]string{
+		"Title",
+		"Build passed",
+		"Note: see logs and img https://ci",
+		"☑ test_case_1 a*b*c",
+		"• deploy old 1 < 2 *",
+		"",
+		"name | status",
+		"a|b | ok",
+		"# not heading",
+	}, "\n")
+	if text != expected {
+		t.Errorf("Check markdown plain text failed: %q", text)
+	}
+}
+
+func TestMakeMarkdownContent(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory"})                                      // nolint: errcheck
+	tk, _ := model.ParseToken("EgMxMjMiBGNoYW4qBU1GUkdHMhQZZ_-_F4Oa-oQO0sLHXKqNSU8Qmw..c2lnbg") // nolint: errcheck
+	msg, err := c.makeFormatTextContent(model.NewMessage(tk), "markdown", "**hello**", "title", "", "1", nil)
+	if err != nil {
+		t.Fatal("Make markdown content failed:", err)
+	}
+	var ctx pb.MsgContent
+	if err := proto.Unmarshal(msg.Content, &ctx); err != nil {
+		t.Fatal("Unmarshal markdown content failed")
+	}
+	if ctx.Markdown != "**hello**" || ctx.Text != "hello" || ctx.Flags != model.ContentFlagMarkdown|model.ContentFlagAutoCopy {
+		t.Error("Check markdown content failed")
+	}
+	if _, err := c.makeFormatTextContent(model.NewMessage(tk), "markdown", "", "", "", "", nil); err != ErrNoContent {
+		t.Error("Check empty markdown content failed")
+	}
+	if _, err := c.makeFormatTextContent(model.NewMessage(tk), "markdown", "123", "", strings.Repeat("1", 1001), "", nil); err != ErrTooLargeContent {
+		t.Error("Check too large markdown copy text failed")
+	}
+	if _, err := c.makeFormatTextContent(model.NewMessage(tk), "xml", "123", "", "", "", nil); err != ErrInvalidContent {
+		t.Error("Check invalid text format failed")
+	}
+	if _, err := c.makeFormatTextContent(model.NewMessage(tk), "md", "*123*", "", "", "", []string{"a|http://127.0.0.1"}); err != ErrMarkdownActions {
+		t.Error("Check markdown action content failed")
+	}
+	if _, err := c.makeFormatTextContent(model.NewMessage(tk), "markdown", strings.Repeat("1", 2001), "", "", "", nil); err != ErrTooLargeContent {
+		t.Error("Check too large markdown failed")
+	}
+}
@@ -1,21 +1,43 @@
 package core
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/chanify/chanify/logic"
 	"github.com/chanify/chanify/model"
 	"github.com/gin-gonic/gin"
 )
 
+var timeValueUnitRegexp = regexp.MustCompile(`^([-+]?[0-9]+(?:\.[0-9]+)?)\s*([^0-9\s.][^0-9]*)$`)
+
+const timeItemMaxUnit = 32
+
+// error define for timeline items
+var (
+	errInvalidTimeItem   = errors.New("invalid item")
+	errInvalidTimeValue  = errors.New("invalid value")
+	errInvalidTimeUnit   = errors.New("invalid unit")
+	errEmptyTimeItemName = errors.New("empty name")
+	errDuplicateTimeItem = errors.New("duplicate name")
+)
+
 // TimeContent define timeline content
 type TimeContent struct {
 	Code      string
 	Timestamp *time.Time
 	Items     []*model.MsgTimeItem
+	Errors    []*TimeItemError
 }
 
 // MsgParam parse message parameters
@@ -28,8 +50,19 @@ type MsgParam struct {
 	AutoCopy          string
 	CopyText          string
 	Filename          string
+	Format            string
+	Template          string
+	Vars              map[string]interface{}
 	Priority          int
 	InterruptionLevel string
+	Critical          string
+	Volume            float64
+	Batch             string
+	Ack               string
+	AckCallback       string
+	Repeat            string
+	RepeatCount       int
+	TTL               string
 	Actions           []string
 	TimeContent       TimeContent
 }
@@ -43,23 +76,35 @@ func (m *MsgParam) ParsePlainText(ctx *gin.Context) {
 }
 
 // ParseJSON process application/json
-func (m *MsgParam) ParseJSON(c *Core, ctx *gin.Context) {
+func (m *MsgParam) ParseJSON(c *Core, ctx *gin.Context) error {
 	defer ctx.Request.Body.Close()
 	var params struct {
-		Token             string     `json:"token,omitempty"`
-		Title             string     `json:"title,omitempty"`
-		Text              string     `json:"text,omitempty"`
-		Copy              string     `json:"copy,omitempty"`
-		AutoCopy          JSONString `json:"autocopy,omitempty"`
-		Link              string     `json:"link,omitempty"`
-		Sound             JSONString `json:"sound,omitempty"`
-		Priority          int        `json:"priority,omitempty"`
-		InterruptionLevel string     `json:"interruption-level,omitempty"`
-		Actions           []string   `json:"actions,omitempty"`
+		Token             string                 `json:"token,omitempty"`
+		Title             string                 `json:"title,omitempty"`
+		Text              string                 `json:"text,omitempty"`
+		Copy              string                 `json:"copy,omitempty"`
+		AutoCopy          JSONString             `json:"autocopy,omitempty"`
+		Link              string                 `json:"link,omitempty"`
+		Format            string                 `json:"format,omitempty"`
+		Template          string                 `json:"template,omitempty"`
+		Vars              map[string]interface{} `json:"vars,omitempty"`
+		Sound             JSONString             `json:"sound,omitempty"`
+		Priority          int                    `json:"priority,omitempty"`
+		InterruptionLevel string                 `json:"interruption-level,omitempty"`
+		Critical          JSONString             `json:"critical,omitempty"`
+		Volume            float64                `json:"volume,omitempty"`
+		Batch             JSONString             `json:"batch,omitempty"`
+		Ack               JSONString             `json:"ack,omitempty"`
+		AckCallback       string                 `json:"ack-callback,omitempty"`
+		Repeat            JSONString             `json:"repeat,omitempty"`
+		RepeatCount       int                    `json:"repeat-count,omitempty"`
+		TTL               interface{}            `json:"ttl,omitempty"`
+		Expires           interface{}            `json:"expires,omitempty"`
+		Actions           []*ActionParam         `json:"actions,omitempty"`
 		Timeline          struct {
-			Code     string                 `json:"code"`
-			Timstamp interface{}            `json:"timestamp,omitempty"`
-			Items    map[string]interface{} `json:"items"`
+			Code     string         `json:"code"`
+			Timstamp interface{}    `json:"timestamp,omitempty"`
+			Items    TimeItemsParam `json:"items"`
 		} `json:"timeline,omitempty"`
 	}
 	if err := ctx.BindJSON(&params); err == nil {
@@ -68,6 +113,11 @@ func (m *MsgParam) ParseJSON(c *Core, ctx *gin.Context) {
 		}
 		m.Link = tryStringValue(m.Link, params.Link)
 		m.Title = tryStringValue(m.Title, params.Title)
+		m.Format = tryStringValue(m.Format, params.Format)
+		m.Template = tryStringValue(m.Template, params.Template)
+		if len(m.Vars) <= 0 {
+			m.Vars = params.Vars
+		}
 		if len(m.Sound) <= 0 && len(params.Sound) > 0 {
 			m.Sound = string(params.Sound)
 		}
@@ -75,8 +125,16 @@ func (m *MsgParam) ParseJSON(c *Core, ctx *gin.Context) {
 		if len(m.AutoCopy) <= 0 && len(params.AutoCopy) > 0 {
 			m.AutoCopy = string(params.AutoCopy)
 		}
-		if len(m.Actions) <= 0 {
-			m.Actions = params.Actions
+		if len(m.Actions) <= 0 && len(params.Actions) > 0 {
+			uid := ""
+			if m.Token != nil {
+				uid = m.Token.GetUserID()
+			}
+			actions, err := c.makeActions(uid, params.Actions)
+			if err != nil {
+				return err
+			}
+			m.Actions = actions
 		}
 		if m.Priority <= 0 {
 			m.Priority = params.Priority
@@ -84,13 +142,35 @@ func (m *MsgParam) ParseJSON(c *Core, ctx *gin.Context) {
 		if len(m.InterruptionLevel) <= 0 {
 			m.InterruptionLevel = params.InterruptionLevel
 		}
+		if len(m.Critical) <= 0 && len(params.Critical) > 0 {
+			m.Critical = string(params.Critical)
+		}
+		if m.Volume <= 0 {
+			m.Volume = params.Volume
+		}
+		if len(m.Batch) <= 0 && len(params.Batch) > 0 {
+			m.Batch = string(params.Batch)
+		}
+		if len(m.Ack) <= 0 && len(params.Ack) > 0 {
+			m.Ack = string(params.Ack)
+		}
+		m.AckCallback = tryStringValue(m.AckCallback, params.AckCallback)
+		if len(m.Repeat) <= 0 && len(params.Repeat) > 0 {
+			m.Repeat = string(params.Repeat)
+		}
+		if m.RepeatCount <= 0 {
+			m.RepeatCount = params.RepeatCount
+		}
+		m.TTL = tryStringValue(m.TTL, jsonTTLValue(params.TTL, params.Expires))
 		if len(m.TimeContent.Code) <= 0 {
 			m.TimeContent.Code = params.Timeline.Code
 			m.TimeContent.Timestamp = parseTimestamp(params.Timeline.Timstamp)
-			m.TimeContent.Items = parseTimeContentItems(params.Timeline.Items)
+			m.TimeContent.Items = params.Timeline.Items.Items
+			m.TimeContent.Errors = params.Timeline.Items.Errors
 		}
 		m.Text = params.Text
 	}
+	return nil
 }
 
 // ParseForm process form
@@ -105,6 +185,15 @@ func (m *MsgParam) ParseForm(c *Core, ctx *gin.Context) {
 	if len(m.CopyText) <= 0 {
 		m.CopyText = ctx.PostForm("copy")
 	}
+	if len(m.Format) <= 0 {
+		m.Format = ctx.PostForm("format")
+	}
+	if len(m.Template) <= 0 {
+		m.Template = ctx.PostForm("template")
+	}
+	if len(m.Vars) <= 0 {
+		m.Vars = stringMapToVars(ctx.PostFormMap("vars"))
+	}
 	if len(m.AutoCopy) <= 0 {
 		m.AutoCopy = ctx.PostForm("autocopy")
 	}
@@ -120,10 +209,33 @@ func (m *MsgParam) ParseForm(c *Core, ctx *gin.Context) {
 	if len(m.InterruptionLevel) <= 0 {
 		m.InterruptionLevel = ctx.PostForm("interruption-level")
 	}
+	if len(m.Critical) <= 0 {
+		m.Critical = ctx.PostForm("critical")
+	}
+	if m.Volume <= 0 {
+		m.Volume = parseVolume(ctx.PostForm("volume"))
+	}
+	if len(m.Batch) <= 0 {
+		m.Batch = ctx.PostForm("batch")
+	}
+	if len(m.Ack) <= 0 {
+		m.Ack = ctx.PostForm("ack")
+	}
+	if len(m.AckCallback) <= 0 {
+		m.AckCallback = ctx.PostForm("ack-callback")
+	}
+	if len(m.Repeat) <= 0 {
+		m.Repeat = ctx.PostForm("repeat")
+	}
+	if m.RepeatCount <= 0 {
+		m.RepeatCount = parseRepeatCount(ctx.PostForm("repeat-count"))
+	}
+	m.TTL = tryStringValue(m.TTL, ctx.PostForm("ttl"))
+	m.TTL = tryStringValue(m.TTL, ctx.PostForm("expires"))
 	if len(m.TimeContent.Code) <= 0 {
 		m.TimeContent.Code = ctx.PostForm("timeline-code")
 		m.TimeContent.Timestamp = parseTimestamp(ctx.PostForm("timeline-timestamp"))
-		m.TimeContent.Items = parseTimeContentStringItems(ctx.PostFormMap("timeline-items"))
+		m.TimeContent.Items, m.TimeContent.Errors = parseTimeContentFormItems(ctx.PostFormArray("timeline-item"), ctx.PostFormMap("timeline-items"))
 	}
 }
 
@@ -144,16 +256,36 @@ func (m *MsgParam) ParseFormData(c *Core, ctx *gin.Context) (*model.Message, err
 		m.Title = tryFormValue(form, "title", m.Title)
 		m.Link = tryFormValue(form, "link", m.Link)
 		m.CopyText = tryFormValue(form, "copy", m.CopyText)
+		m.Format = tryFormValue(form, "format", m.Format)
+		m.Template = tryFormValue(form, "template", m.Template)
+		if len(m.Vars) <= 0 {
+			m.Vars = stringMapToVars(readFormMap(form, "vars"))
+		}
 		m.AutoCopy = tryFormValue(form, "autocopy", m.AutoCopy)
 		m.Filename = tryFormValue(form, "filename", m.Filename)
 		m.Sound = tryFormValue(form, "sound", m.Sound)
 		m.Actions = tryFormValues(form, "action", m.Actions)
 		m.parsePriorityFromForm(form)
 		m.InterruptionLevel = tryFormValue(form, "interruption-level", m.InterruptionLevel)
+		m.Critical = tryFormValue(form, "critical", m.Critical)
+		if m.Volume <= 0 {
+			m.Volume = parseVolume(tryFormValue(form, "volume", ""))
+		}
+		m.Batch = tryFormValue(form, "batch", m.Batch)
+		m.Ack = tryFormValue(form, "ack", m.Ack)
+		m.AckCallback = tryFormValue(form, "ack-callback", m.AckCallback)
+		m.Repeat = tryFormValue(form, "repeat", m.Repeat)
+		if m.RepeatCount <= 0 {
+			m.RepeatCount = parseRepeatCount(tryFormValue(form, "repeat-count", ""))
+		}
+		m.TTL = tryFormValue(form, "ttl", m.TTL)
+		m.TTL = tryFormValue(form, "expires", m.TTL)
 		m.TimeContent.Code = tryFormValue(form, "timeline-code", m.TimeContent.Code)
 		if len(m.TimeContent.Code) > 0 {
 			m.TimeContent.Timestamp = tryFormTimestamp(form, "timeline-timestamp", m.TimeContent.Timestamp)
-			m.TimeContent.Items = tryFormMap(form, "timeline-items", m.TimeContent.Items)
+			if len(m.TimeContent.Items) <= 0 {
+				m.TimeContent.Items, m.TimeContent.Errors = parseTimeContentFormItems(form.Value["timeline-item"], readFormMap(form, "timeline-items"))
+			}
 		}
 		if m.Token != nil && c.logic.CanFileStore() {
 			if data, _, err := readFileFromForm(form, "image"); err == nil {
@@ -171,6 +303,13 @@ func (m *MsgParam) ParseFormData(c *Core, ctx *gin.Context) (*model.Message, err
 					return nil, err
 				}
 			}
+			if data, _, err := readFileFromForm(form, "video"); err == nil {
+				poster, _, _ := readFileFromForm(form, "poster")
+				msg, err = c.saveUploadVideo(ctx, m.Token, data, poster)
+				if err != nil {
+					return nil, err
+				}
+			}
 			if data, fname, err := readFileFromForm(form, "file"); err == nil {
 				msg, err = c.saveUploadFile(ctx, m.Token, data, fileBaseName(fname), m.Text, m.Actions)
 				if err != nil {
@@ -210,6 +349,65 @@ func (m *MsgParam) ParseAudio(c *Core, ctx *gin.Context) (*model.Message, error)
 	return msg, nil
 }
 
+// ParseVideo process video
+func (m *MsgParam) ParseVideo(c *Core, ctx *gin.Context) (*model.Message, error) {
+	var msg *model.Message = nil
+	if m.Token != nil && c.logic.CanFileStore() {
+		var err error
+		data, _ := ctx.GetRawData()
+		msg, err = c.saveUploadVideo(ctx, m.Token, data, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// ApplyOptions set notification options of message
+func (m *MsgParam) ApplyOptions(msg *model.Message) *model.Message {
+	msg.SoundName(m.Sound).SetPriority(m.Priority).SetInterruptionLevel(m.InterruptionLevel)
+	if ttl, ok := logic.ParseTTL(m.TTL); ok {
+		msg.SetTTL(ttl)
+	}
+	return msg.SetCritical(parseCritical(m.Critical), m.Volume).SetBatch(logic.ParseBatchWindow(m.Batch)).SetAck(m.ackOptions())
+}
+
+// ackOptions return acknowledgement options, nil if message is not tracked
+func (m *MsgParam) ackOptions() *model.AckOptions {
+	interval := logic.ParseRepeatInterval(m.Repeat)
+	if !parseBool(m.Ack) && len(m.AckCallback) <= 0 && interval <= 0 {
+		return nil
+	}
+	opts := &model.AckOptions{Callback: m.AckCallback, Interval: interval}
+	if interval > 0 {
+		opts.Repeat = m.RepeatCount
+		if opts.Repeat == 0 {
+			opts.Repeat = defaultRepeatCount
+		}
+	}
+	return opts
+}
+
+// ApplyTemplate render message template into empty fields
+func (m *MsgParam) ApplyTemplate(c *Core) error {
+	tpl, err := c.logic.GetTemplate(m.Template)
+	if err != nil {
+		return err
+	}
+	res, err := tpl.Render(m.Vars)
+	if err != nil {
+		return err
+	}
+	m.Title = tryStringValue(m.Title, res.Title)
+	m.Text = tryStringValue(m.Text, res.Text)
+	m.Sound = tryStringValue(m.Sound, res.Sound)
+	m.Format = tryStringValue(m.Format, res.Format)
+	if len(m.Actions) <= 0 {
+		m.Actions = res.Actions
+	}
+	return nil
+}
+
 func (m *MsgParam) parsePriorityFromForm(form *multipart.Form) {
 	if m.Priority <= 0 {
 		ps := form.Value["priority"]
@@ -219,88 +417,199 @@ func (m *MsgParam) parsePriorityFromForm(form *multipart.Form) {
 	}
 }
 
-func parseTimeContentItems(items map[string]interface{}) []*model.MsgTimeItem {
-	lst := []*model.MsgTimeItem{}
-	for k, v := range items {
-		switch val := v.(type) {
-		case int:
-			lst = append(lst, &model.MsgTimeItem{
-				Name:  k,
-				Value: int64(val),
-			})
-		case int64:
-			lst = append(lst, &model.MsgTimeItem{
-				Name:  k,
-				Value: val,
-			})
-		case float32:
-			lst = append(lst, &model.MsgTimeItem{
-				Name:  k,
-				Value: float64(val),
-			})
-		case float64:
-			lst = append(lst, &model.MsgTimeItem{
-				Name:  k,
-				Value: val,
-			})
-		case string:
-			if strings.ContainsRune(val, '.') {
-				if vv, err := strconv.ParseFloat(val, 64); err == nil {
-					lst = append(lst, &model.MsgTimeItem{
-						Name:  k,
-						Value: vv,
-					})
-					continue
-				}
-			} else {
-				if vv, err := strconv.ParseInt(val, 10, 64); err == nil {
-					lst = append(lst, &model.MsgTimeItem{
-						Name:  k,
-						Value: vv,
-					})
-					continue
-				}
-			}
-			lst = append(lst, &model.MsgTimeItem{
-				Name:  k,
-				Value: 0,
-			})
-		default:
-			lst = append(lst, &model.MsgTimeItem{
-				Name:  k,
-				Value: 0,
-			})
-		}
-	}
-	return lst
-}
-
-func parseTimeContentStringItems(items map[string]string) []*model.MsgTimeItem {
-	lst := []*model.MsgTimeItem{}
-	for k, v := range items {
-		if strings.ContainsRune(v, '.') {
-			if vv, err := strconv.ParseFloat(v, 64); err == nil {
-				lst = append(lst, &model.MsgTimeItem{
-					Name:  k,
-					Value: vv,
-				})
+// TimeItemError is field-level error of invalid timeline item
+type TimeItemError struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+// TimeItemsParam is ordered timeline items, accept both {"name": value} and [{"name": .., "value": .., "unit": ..}]
+type TimeItemsParam struct {
+	Items  []*model.MsgTimeItem
+	Errors []*TimeItemError
+}
+
+// UnmarshalJSON keep the order of timeline items in JSON
+func (t *TimeItemsParam) UnmarshalJSON(data []byte) error {
+	b := newTimeItemsBuilder()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tk, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tk {
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			var item map[string]interface{}
+			if err := dec.Decode(&item); err != nil {
+				b.fail(fmt.Sprintf("timeline.items[%d]", i), errInvalidTimeItem)
 				continue
 			}
-		} else {
-			if vv, err := strconv.ParseInt(v, 10, 64); err == nil {
-				lst = append(lst, &model.MsgTimeItem{
-					Name:  k,
-					Value: vv,
-				})
-				continue
+			name, _ := item["name"].(string)
+			b.add(fmt.Sprintf("timeline.items[%d]", i), name, item)
+		}
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			name, _ := key.(string)
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return err
 			}
+			b.add("timeline.items."+name, name, value)
 		}
-		lst = append(lst, &model.MsgTimeItem{
-			Name:  k,
-			Value: 0,
-		})
+	case nil:
+	default:
+		b.fail("timeline.items", errInvalidTimeItem)
+	}
+	t.Items, t.Errors = b.items, b.errors
+	return nil
+}
+
+type timeItemsBuilder struct {
+	items  []*model.MsgTimeItem
+	errors []*TimeItemError
+	names  map[string]bool
+}
+
+func newTimeItemsBuilder() *timeItemsBuilder {
+	return &timeItemsBuilder{items: []*model.MsgTimeItem{}, names: map[string]bool{}}
+}
+
+func (b *timeItemsBuilder) add(field string, name string, value interface{}) {
+	name = strings.TrimSpace(name)
+	if len(name) <= 0 {
+		b.fail(field, errEmptyTimeItemName)
+		return
+	}
+	if b.names[name] {
+		b.fail(field, errDuplicateTimeItem)
+		return
 	}
-	return lst
+	item, err := parseTimeItem(name, value)
+	if err != nil {
+		b.fail(field, err)
+		return
+	}
+	b.names[name] = true
+	b.items = append(b.items, item)
+}
+
+func (b *timeItemsBuilder) fail(field string, err error) {
+	b.errors = append(b.errors, &TimeItemError{Field: field, Msg: err.Error()})
+}
+
+func replyTimeItemErrors(ctx sendContext, errs []*TimeItemError) {
+	ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid timeline items", "errors": errs})
+}
+
+// parseTimeContentItems parse items sorted by name, field is format of param name for errors
+func parseTimeContentItems(field string, items map[string]interface{}) ([]*model.MsgTimeItem, []*TimeItemError) {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b := newTimeItemsBuilder()
+	for _, k := range keys {
+		b.add(fmt.Sprintf(field, k), k, items[k])
+	}
+	return b.items, b.errors
+}
+
+// parseTimeContentFormItems parse ordered `timeline-item` (name=value) fields, then `timeline-items[name]` fields sorted by name
+func parseTimeContentFormItems(pairs []string, items map[string]string) ([]*model.MsgTimeItem, []*TimeItemError) {
+	b := newTimeItemsBuilder()
+	for i, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) < 2 {
+			b.fail(fmt.Sprintf("timeline-item[%d]", i), errInvalidTimeItem)
+			continue
+		}
+		b.add(fmt.Sprintf("timeline-item[%d]", i), kv[0], kv[1])
+	}
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.add("timeline-items["+k+"]", k, items[k])
+	}
+	return b.items, b.errors
+}
+
+// parseTimeItem convert value into timeline item, object value is {"value": 21.5, "unit": "°C"}
+func parseTimeItem(name string, value interface{}) (*model.MsgTimeItem, error) {
+	item := &model.MsgTimeItem{Name: name}
+	switch val := value.(type) {
+	case int:
+		item.Value = int64(val)
+	case int64:
+		item.Value = val
+	case float32:
+		item.Value = float64(val)
+	case float64:
+		item.Value = val
+	case bool:
+		item.Value = val
+	case string:
+		if len(strings.TrimSpace(val)) <= 0 {
+			return nil, errInvalidTimeValue
+		}
+		item.Value, item.Unit = parseTimeValue(val)
+	case map[string]interface{}:
+		v, ok := val["value"]
+		if !ok {
+			return nil, errInvalidTimeValue
+		}
+		if _, ok := v.(map[string]interface{}); ok {
+			return nil, errInvalidTimeValue
+		}
+		it, err := parseTimeItem(name, v)
+		if err != nil {
+			return nil, err
+		}
+		if u, ok := val["unit"]; ok {
+			unit, ok := u.(string)
+			if !ok || len(unit) > timeItemMaxUnit {
+				return nil, errInvalidTimeUnit
+			}
+			if len(unit) > 0 {
+				it.Unit = unit
+			}
+		}
+		return it, nil
+	default:
+		return nil, errInvalidTimeValue
+	}
+	return item, nil
+}
+
+// parseTimeValue parse number, bool or string value, number can be followed by unit, e.g. "21.5°C"
+func parseTimeValue(value string) (interface{}, string) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "true":
+		return true, ""
+	case "false":
+		return false, ""
+	}
+	num, unit := value, ""
+	if m := timeValueUnitRegexp.FindStringSubmatch(value); m != nil {
+		num, unit = m[1], m[2]
+	}
+	if strings.ContainsRune(num, '.') {
+		if v, err := strconv.ParseFloat(num, 64); err == nil {
+			return v, unit
+		}
+	} else if v, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return v, unit
+	}
+	return value, ""
 }
 
 func parseTimestamp(t interface{}) *time.Time {
@@ -364,18 +673,28 @@ func tryFormValues(form *multipart.Form, name string, value []string) []string {
 	return value
 }
 
-func tryFormMap(form *multipart.Form, name string, items []*model.MsgTimeItem) []*model.MsgTimeItem {
-	if len(items) <= 0 {
-		l := len(name)
-		values := map[string]string{}
+func readFormMap(form *multipart.Form, name string) map[string]string {
+	l := len(name)
+	values := map[string]string{}
+	if form != nil {
 		for k, v := range form.Value {
 			if strings.HasPrefix(k, name) && len(k) > l+2 && k[l] == '[' && k[len(k)-1] == ']' && len(v) > 0 {
 				values[strings.TrimSpace(k[l+1:len(k)-1])] = v[0]
 			}
 		}
-		return parseTimeContentStringItems(values)
 	}
-	return items
+	return values
+}
+
+func stringMapToVars(values map[string]string) map[string]interface{} {
+	if len(values) <= 0 {
+		return nil
+	}
+	vars := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		vars[k] = v
+	}
+	return vars
 }
 
 func tryFormTimestamp(form *multipart.Form, name string, ts *time.Time) *time.Time {
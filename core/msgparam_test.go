@@ -1,12 +1,13 @@
 package core
 
 import (
+	"encoding/json"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/chanify/chanify/logic"
-	"github.com/chanify/chanify/model"
 	"github.com/gin-gonic/gin"
 )
 
@@ -34,22 +35,123 @@ func TestMsgAudioParam(t *testing.T) {
 	}
 }
 
+func TestMsgVideoParam(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory"}) // nolint: errcheck
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	msg := &MsgParam{}
+	if _, err := msg.ParseVideo(c, ctx); err != nil {
+		t.Error("Parse video params failed:", err)
+	}
+}
+
 func TestParseTimeContentItems(t *testing.T) {
 	items := map[string]interface{}{}
-	items["key1"] = 123
+	items["key3"] = 123
 	items["key2"] = int64(1234)
-	items["key3"] = float32(456)
-	if len(parseTimeContentItems(items)) != len(items) {
+	items["key1"] = float32(456)
+	lst, errs := parseTimeContentItems("timeline.items.%s", items)
+	if len(lst) != len(items) || len(errs) > 0 || lst[0].Name != "key1" || lst[2].Name != "key3" {
 		t.Error("Parse time content failed!")
 	}
+	items["bad"] = nil
+	if _, errs := parseTimeContentItems("timeline.items.%s", items); len(errs) != 1 || errs[0].Field != "timeline.items.bad" || errs[0].Msg != "invalid value" {
+		t.Error("Check invalid time content failed:", errs)
+	}
+}
+
+func TestParseTimeItem(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		res   interface{}
+		unit  string
+	}{
+		{"123", int64(123), ""},
+		{"-1.5", -1.5, ""},
+		{"21.5°C", 21.5, "°C"},
+		{"80 %", int64(80), "%"},
+		{"true", true, ""},
+		{"false", false, ""},
+		{"degraded", "degraded", ""},
+		{"1.2.3", "1.2.3", ""},
+		{true, true, ""},
+		{map[string]interface{}{"value": 3.5, "unit": "ms"}, 3.5, "ms"},
+	}
+	for _, tt := range tests {
+		if item, err := parseTimeItem("key", tt.value); err != nil || item.Value != tt.res || item.Unit != tt.unit {
+			t.Error("Parse time item failed:", tt.value, item, err)
+		}
+	}
+	invalids := []struct {
+		value interface{}
+		err   error
+	}{
+		{[]int{}, errInvalidTimeValue},
+		{nil, errInvalidTimeValue},
+		{" ", errInvalidTimeValue},
+		{map[string]interface{}{"unit": "ms"}, errInvalidTimeValue},
+		{map[string]interface{}{"value": map[string]interface{}{}}, errInvalidTimeValue},
+		{map[string]interface{}{"value": 1.0, "unit": 1.0}, errInvalidTimeUnit},
+		{map[string]interface{}{"value": 1.0, "unit": strings.Repeat("a", timeItemMaxUnit+1)}, errInvalidTimeUnit},
+	}
+	for _, tt := range invalids {
+		if _, err := parseTimeItem("key", tt.value); err != tt.err {
+			t.Error("Check invalid time item failed:", tt.value, err)
+		}
+	}
+}
+
+func TestParseTimeContentFormItems(t *testing.T) {
+	items, errs := parseTimeContentFormItems([]string{"temp=21.5°C", "status=ok"}, map[string]string{"b": "2", "a": "1"})
+	if len(errs) > 0 || len(items) != 4 || items[0].Name != "temp" || items[0].Unit != "°C" || items[1].Name != "status" || items[2].Name != "a" || items[3].Name != "b" {
+		t.Fatal("Parse form time items failed:", items, errs)
+	}
+	_, errs = parseTimeContentFormItems([]string{"temp", "=1", "x=1", "x=2"}, map[string]string{"y": ""})
+	expects := []TimeItemError{
+		{Field: "timeline-item[0]", Msg: "invalid item"},
+		{Field: "timeline-item[1]", Msg: "empty name"},
+		{Field: "timeline-item[3]", Msg: "duplicate name"},
+		{Field: "timeline-items[y]", Msg: "invalid value"},
+	}
+	if len(errs) != len(expects) {
+		t.Fatal("Check form time items errors failed:", errs)
+	}
+	for i, e := range expects {
+		if *errs[i] != e {
+			t.Error("Check form time item error failed:", i, errs[i])
+		}
+	}
 }
 
-func TestTryFormMap(t *testing.T) {
-	items := []*model.MsgTimeItem{
-		{Name: "123", Value: 456},
+func TestTimeItemsParamJSON(t *testing.T) {
+	var params struct {
+		Items TimeItemsParam `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(`{"items":{"z":1,"a":"up","m":{"value":2,"unit":"ms"}}}`), &params); err != nil {
+		t.Fatal("Unmarshal time items object failed:", err)
+	}
+	if its := params.Items.Items; len(its) != 3 || its[0].Name != "z" || its[1].Name != "a" || its[2].Unit != "ms" {
+		t.Error("Check time items object order failed:", its)
+	}
+	params.Items = TimeItemsParam{}
+	if err := json.Unmarshal([]byte(`{"items":[{"name":"b","value":true},{"name":"a","value":21.5,"unit":"°C"},{"value":1},"x",{"name":"c","value":[1]},{"name":"b","value":1}]}`), &params); err != nil {
+		t.Fatal("Unmarshal time items array failed:", err)
+	}
+	its, errs := params.Items.Items, params.Items.Errors
+	if len(its) != 2 || its[0].Name != "b" || its[0].Value != true || its[1].Unit != "°C" {
+		t.Error("Check time items array failed:", its)
+	}
+	if len(errs) != 4 || errs[0].Field != "timeline.items[2]" || errs[0].Msg != "empty name" || errs[1].Msg != "invalid item" || errs[2].Msg != "invalid value" || errs[3].Msg != "duplicate name" {
+		t.Error("Check time items array errors failed:", errs)
+	}
+	params.Items = TimeItemsParam{}
+	if err := json.Unmarshal([]byte(`{"items":"abc"}`), &params); err != nil || len(params.Items.Errors) != 1 || params.Items.Errors[0].Field != "timeline.items" {
+		t.Error("Check invalid time items failed:", err, params.Items.Errors)
 	}
-	if len(tryFormMap(nil, "test", items)) != len(items) {
-		t.Error("Try form map failed!")
+	if err := json.Unmarshal([]byte(`{"items":null}`), &params); err != nil || len(params.Items.Errors) != 0 {
+		t.Error("Check null time items failed:", err)
 	}
 }
 
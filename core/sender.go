@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"io"
+	"log"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/chanify/chanify/logic"
 	"github.com/chanify/chanify/model"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type sendContext interface {
@@ -24,21 +26,48 @@ func (c *Core) handleSender(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"res": http.StatusUnauthorized, "msg": "invalid token"})
 		return
 	}
-	text := ctx.Param("msg")
-	if len(text) <= 0 {
-		text = ctx.Query("text")
-		if len(text) <= 0 {
-			ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusNoContent, "msg": "no message content"})
+	params := &MsgParam{
+		Token:             token,
+		Text:              ctx.Param("msg"),
+		Title:             ctx.Query("title"),
+		Sound:             ctx.Query("sound"),
+		CopyText:          ctx.Query("copy"),
+		AutoCopy:          ctx.Query("autocopy"),
+		Format:            ctx.Query("format"),
+		Template:          ctx.Query("template"),
+		Vars:              stringMapToVars(ctx.QueryMap("vars")),
+		Priority:          parsePriority(ctx.Query("priority")),
+		InterruptionLevel: ctx.Query("interruption-level"),
+		Critical:          ctx.Query("critical"),
+		Volume:            parseVolume(ctx.Query("volume")),
+		Batch:             ctx.Query("batch"),
+		Ack:               ctx.Query("ack"),
+		AckCallback:       ctx.Query("ack-callback"),
+		Repeat:            ctx.Query("repeat"),
+		RepeatCount:       parseRepeatCount(ctx.Query("repeat-count")),
+		TTL:               queryTTL(ctx),
+		Actions:           ctx.QueryArray("action"),
+	}
+	if len(params.Text) <= 0 {
+		params.Text = ctx.Query("text")
+	}
+	if len(params.Template) > 0 {
+		if err := params.ApplyTemplate(c); err != nil {
+			replyTemplateError(ctx, err)
 			return
 		}
 	}
+	if len(params.Text) <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusNoContent, "msg": "no message content"})
+		return
+	}
 	msg := model.NewMessage(token)
-	msg, err = c.makeTextContent(msg, text, ctx.Query("title"), ctx.Query("copy"), ctx.Query("autocopy"), ctx.QueryArray("action"))
+	msg, err = c.makeFormatTextContent(msg, params.Format, params.Text, params.Title, params.CopyText, params.AutoCopy, params.Actions)
 	if err != nil {
-		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"res": http.StatusRequestEntityTooLarge, "msg": "too large text content"})
+		replyTextContentError(ctx, err)
 		return
 	}
-	c.sendMsg(ctx, token, msg.SoundName(ctx.Query("sound")).SetPriority(parsePriority(ctx.Query("priority"))).SetInterruptionLevel(ctx.Query("interruption-level")))
+	c.sendMsg(ctx, token, params.ApplyOptions(msg))
 }
 func (c *Core) handlePostSender(ctx *gin.Context) {
 	params := &MsgParam{}
@@ -48,9 +77,19 @@ func (c *Core) handlePostSender(ctx *gin.Context) {
 	params.Sound = ctx.Query("sound")
 	params.AutoCopy = ctx.Query("autocopy")
 	params.CopyText = ctx.Query("copy")
+	params.Format = ctx.Query("format")
+	params.Template = ctx.Query("template")
 	params.Filename = fileBaseName(ctx.Query("filename"))
 	params.Priority = parsePriority(ctx.Query("priority"))
 	params.InterruptionLevel = ctx.Query("interruption-level")
+	params.Critical = ctx.Query("critical")
+	params.Volume = parseVolume(ctx.Query("volume"))
+	params.Batch = ctx.Query("batch")
+	params.Ack = ctx.Query("ack")
+	params.AckCallback = ctx.Query("ack-callback")
+	params.Repeat = ctx.Query("repeat")
+	params.RepeatCount = parseRepeatCount(ctx.Query("repeat-count"))
+	params.TTL = queryTTL(ctx)
 	params.TimeContent.Code = ctx.Query("timeline-code")
 
 	var err error
@@ -65,15 +104,22 @@ func (c *Core) handlePostSender(ctx *gin.Context) {
 	case "text/plain", "text":
 		params.ParsePlainText(ctx)
 	case "application/json", "json":
-		params.ParseJSON(c, ctx)
+		if err := params.ParseJSON(c, ctx); err != nil {
+			replyActionError(ctx, err)
+			return
+		}
 	case "multipart/form-data":
 		parser = params.ParseFormData
-	case "image/png", "image/jpeg":
+	case "image/png", "image/jpeg", "image/gif", "image/webp", "image/tiff", "image/bmp", "image/heic", "image/heif", "image/avif":
 		parser = params.ParseImage
-	case "audio/mpeg":
+	case "audio/mpeg", "audio/mp3", "audio/mp4", "audio/x-m4a", "audio/m4a", "audio/aac", "audio/ogg", "audio/opus", "audio/wav", "audio/x-wav", "audio/wave":
 		parser = params.ParseAudio
 	default:
-		params.ParseForm(c, ctx)
+		if strings.HasPrefix(ctype, "video/") {
+			parser = params.ParseVideo
+		} else {
+			params.ParseForm(c, ctx)
+		}
 	}
 	if parser != nil {
 		msg, err = parser(c, ctx)
@@ -85,49 +131,87 @@ func (c *Core) handlePostSender(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"res": http.StatusUnauthorized, "msg": "invalid token format"})
 		return
 	}
+	if msg == nil && len(params.Template) > 0 {
+		if err := params.ApplyTemplate(c); err != nil {
+			replyTemplateError(ctx, err)
+			return
+		}
+	}
 	if msg == nil {
 		if len(params.Link) > 0 {
 			msg = model.NewMessage(params.Token).LinkContent(params.Link)
 		} else if len(params.TimeContent.Code) > 0 {
+			if len(params.TimeContent.Errors) > 0 {
+				replyTimeItemErrors(ctx, params.TimeContent.Errors)
+				return
+			}
 			msg = model.NewMessage(params.Token).TimelineContent(params.TimeContent.Code, params.Title, params.TimeContent.Timestamp, params.TimeContent.Items)
 		} else if len(params.Text) <= 0 {
 			ctx.JSON(http.StatusNoContent, gin.H{"res": http.StatusNoContent, "msg": "no message content"})
 			return
 		} else {
 			var err error
-			msg, err = c.makeTextContent(model.NewMessage(params.Token), params.Text, params.Title, params.CopyText, params.AutoCopy, params.Actions)
+			msg, err = c.makeFormatTextContent(model.NewMessage(params.Token), params.Format, params.Text, params.Title, params.CopyText, params.AutoCopy, params.Actions)
 			if err != nil {
-				ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"res": http.StatusRequestEntityTooLarge, "msg": "too large text content"})
+				replyTextContentError(ctx, err)
 				return
 			}
 		}
 	}
-	c.sendMsg(ctx, params.Token, msg.SoundName(params.Sound).SetPriority(params.Priority).SetInterruptionLevel(params.InterruptionLevel))
+	c.sendMsg(ctx, params.Token, params.ApplyOptions(msg))
 }
 
 func (c *Core) sendDirect(ctx sendContext, token *model.Token, msg *model.Message) {
 	uid := token.GetUserID()
+	if err := c.logic.SaveTimeline(uid, msg); err != nil && err != model.ErrNotImplemented {
+		log.Println("Save timeline failed:", fixLog(uid), err)
+	}
+	if c.holdMessage(ctx, uid, msg) {
+		return
+	}
+	if c.batchMessage(ctx, uid, msg) {
+		return
+	}
+	c.pushMessage(ctx, uid, msg)
+}
+
+func (c *Core) pushMessage(ctx sendContext, uid string, msg *model.Message) {
+	uuid := uuid.New().String()
+	if !c.pushAPNS(ctx, uuid, uid, msg) {
+		return
+	}
+	if err := c.logic.TrackAck(uuid, uid, msg); err != nil {
+		log.Println("Track message ack failed:", err)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"request-uid": uuid})
+}
+
+// pushAPNS push message to devices of user with request uid, error is replied if failed
+func (c *Core) pushAPNS(ctx sendContext, uuid string, uid string, msg *model.Message) bool {
 	key, err := c.logic.GetUserKey(uid)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid user"})
-		return
+		return false
 	}
 	devs, err := c.logic.GetDevices(uid)
 	if err != nil || len(devs) <= 0 {
 		ctx.JSON(http.StatusNotFound, gin.H{"res": http.StatusNotFound, "msg": "no devices found"})
-		return
+		return false
 	}
 	out := msg.EncryptData(key, uint64(time.Now().UTC().UnixNano()))
 	if len(out) > 4000 {
 		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"res": http.StatusRequestEntityTooLarge, "msg": "message body too large"})
-		return
+		return false
+	}
+	ttl, ok := msg.TTL()
+	if !ok {
+		ttl = logic.TTLDefault
 	}
-	uuid, n := c.logic.SendAPNS(uid, out, devs, int(msg.Priority), "passive", msg.IsTimeline())
-	if n <= 0 {
+	if _, n := c.logic.SendAPNSWithID(uuid, uid, out, devs, int(msg.Priority), "passive", msg.Sound, msg.IsTimeline(), ttl); n <= 0 {
 		ctx.JSON(http.StatusNotFound, gin.H{"res": http.StatusNotFound, "msg": "no devices send success"})
-		return
+		return false
 	}
-	ctx.JSON(http.StatusOK, gin.H{"request-uid": uuid})
+	return true
 }
 
 func (c *Core) sendForward(ctx sendContext, token *model.Token, msg *model.Message) {
@@ -154,18 +238,43 @@ func (c *Core) sendMsg(ctx sendContext, token *model.Token, msg *model.Message)
 		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid user"})
 		return
 	}
+	if err := c.logic.ValidateAck(msg.Ack()); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": err.Error()})
+		return
+	}
+	drop, err := c.logic.FilterMessage(token, msg)
+	if err != nil {
+		log.Println("Filter message failed:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "filter message failed"})
+		return
+	}
+	if drop {
+		ctx.JSON(http.StatusOK, gin.H{"request-uid": "", "msg": "message dropped"})
+		return
+	}
+	if err := c.saveTextFile(msg); err != nil {
+		log.Println("Save text file failed:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "save file failed"})
+		return
+	}
+	policy := c.logic.GetMessagePolicy(token)
+	policy.ApplySender(msg)
 	if u.IsServerless() {
+		policy.Apply(msg)
 		c.sendForward(ctx, token, msg)
 		return
 	}
+	c.applyChannel(token.GetUserID(), msg)
+	policy.Apply(msg)
 	c.sendDirect(ctx, token, msg)
 }
 
-func (c *Core) saveUploadImage(ctx *gin.Context, token *model.Token, data []byte) (*model.Message, error) {
+func (c *Core) saveUploadImage(ctx sendContext, token *model.Token, data []byte) (*model.Message, error) {
 	if len(data) <= 0 {
 		ctx.JSON(http.StatusNoContent, gin.H{"res": http.StatusNoContent, "msg": "no image content"})
 		return nil, ErrNoContent
 	}
+	data = prepareImage(data, c.logic.GetImageMaxSize())
 	path, err := c.logic.SaveFile("images", data)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid image content"})
@@ -174,7 +283,7 @@ func (c *Core) saveUploadImage(ctx *gin.Context, token *model.Token, data []byte
 	return model.NewMessage(token).ImageContent(path, createThumbnail(data), len(data)), nil
 }
 
-func (c *Core) saveUploadAudio(ctx *gin.Context, token *model.Token, fname string, title string, data []byte) (*model.Message, error) {
+func (c *Core) saveUploadAudio(ctx sendContext, token *model.Token, fname string, title string, data []byte) (*model.Message, error) {
 	if len(data) <= 0 {
 		ctx.JSON(http.StatusNoContent, gin.H{"res": http.StatusNoContent, "msg": "no audio content"})
 		return nil, ErrNoContent
@@ -184,10 +293,32 @@ func (c *Core) saveUploadAudio(ctx *gin.Context, token *model.Token, fname strin
 		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid audio content"})
 		return nil, ErrInvalidContent
 	}
-	return model.NewMessage(token).AudioContent(path, fname, title, 0, len(data)), nil
+	info := parseAudioInfo(data)
+	if len(title) <= 0 {
+		title = info.DisplayTitle()
+	}
+	return model.NewMessage(token).AudioContent(path, fname, title, info.Duration, len(data)), nil
+}
+
+func (c *Core) saveUploadVideo(ctx sendContext, token *model.Token, data []byte, poster []byte) (*model.Message, error) {
+	if len(data) <= 0 {
+		ctx.JSON(http.StatusNoContent, gin.H{"res": http.StatusNoContent, "msg": "no video content"})
+		return nil, ErrNoContent
+	}
+	path, err := c.logic.SaveFile("videos", data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid video content"})
+		return nil, ErrInvalidContent
+	}
+	var duration uint64
+	info := parseVideoInfo(data)
+	if info != nil {
+		duration = info.Duration
+	}
+	return model.NewMessage(token).VideoContent(path, createVideoThumbnail(info, poster), duration, len(data)), nil
 }
 
-func (c *Core) saveUploadFile(ctx *gin.Context, token *model.Token, data []byte, filename string, desc string, actions []string) (*model.Message, error) {
+func (c *Core) saveUploadFile(ctx sendContext, token *model.Token, data []byte, filename string, desc string, actions []string) (*model.Message, error) {
 	if len(data) <= 0 {
 		ctx.JSON(http.StatusNoContent, gin.H{"res": http.StatusNoContent, "msg": "no file content"})
 		return nil, ErrNoContent
@@ -201,7 +332,6 @@ func (c *Core) saveUploadFile(ctx *gin.Context, token *model.Token, data []byte,
 }
 
 func (c *Core) makeTextContent(msg *model.Message, text string, title string, copytext string, autocopy string, actions []string) (*model.Message, error) {
-	var fname string
 	if len(copytext) > 1000 {
 		return nil, ErrTooLargeContent
 	}
@@ -221,6 +351,17 @@ func (c *Core) makeTextContent(msg *model.Message, text string, title string, co
 	if !c.logic.CanFileStore() {
 		return nil, ErrTooLargeContent
 	}
+	// text file is saved by saveTextFile after message filters
+	return msg.TextFileContent("", "", "", "", 0, actions).SetTextFile(title, text), nil
+}
+
+// saveTextFile save full text of long text message into file store
+func (c *Core) saveTextFile(msg *model.Message) error {
+	title, text, ok := msg.TextFile()
+	if !ok {
+		return nil
+	}
+	var fname string
 	txts := []string{}
 	if len(title) > 0 {
 		txts = append(txts, title)
@@ -232,7 +373,7 @@ func (c *Core) makeTextContent(msg *model.Message, text string, title string, co
 	data := []byte(strings.Join(txts, "\n\n"))
 	path, err := c.logic.SaveFile("files", data)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if len(title) > 100 {
 		fname = string([]rune(title)[:100])
@@ -244,7 +385,62 @@ func (c *Core) makeTextContent(msg *model.Message, text string, title string, co
 	if len(fname) <= 0 {
 		fname = "text"
 	}
-	return msg.TextFileContent(path, fname+".txt", title, string([]rune(text)[:100])+"⋯", len(data), actions), nil
+	desc := text
+	if rs := []rune(text); len(rs) > 100 {
+		desc = string(rs[:100]) + "⋯"
+	}
+	msg.SavedTextFile(path, fname+".txt", title, desc, len(data))
+	return nil
+}
+
+func (c *Core) makeFormatTextContent(msg *model.Message, format string, text string, title string, copytext string, autocopy string, actions []string) (*model.Message, error) {
+	f, err := parseTextFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	if f == formatMarkdown {
+		return c.makeMarkdownContent(msg, text, title, copytext, autocopy, actions)
+	}
+	return c.makeTextContent(msg, text, title, copytext, autocopy, actions)
+}
+
+func (c *Core) makeMarkdownContent(msg *model.Message, text string, title string, copytext string, autocopy string, actions []string) (*model.Message, error) {
+	if len(actions) > 0 {
+		return nil, ErrMarkdownActions
+	}
+	md, err := normalizeMarkdown(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(copytext) > 1000 {
+		return nil, ErrTooLargeContent
+	}
+	plain := markdownPlainText(md)
+	if len(title)+len(md)+len(plain) < 2000 {
+		return msg.MarkdownContent(md, plain, title, copytext, autocopy), nil
+	}
+	return c.makeTextContent(msg, md, title, copytext, autocopy, actions)
+}
+
+func replyTextContentError(ctx sendContext, err error) {
+	switch err {
+	case ErrInvalidContent:
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid text content"})
+	case ErrNoContent:
+		ctx.JSON(http.StatusNoContent, gin.H{"res": http.StatusNoContent, "msg": "no message content"})
+	case ErrMarkdownActions:
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "markdown with actions is not supported"})
+	default:
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"res": http.StatusRequestEntityTooLarge, "msg": "too large text content"})
+	}
+}
+
+func replyTemplateError(ctx sendContext, err error) {
+	if err == logic.ErrNotFound {
+		ctx.JSON(http.StatusNotFound, gin.H{"res": http.StatusNotFound, "msg": "no template found"})
+		return
+	}
+	ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "render template failed: " + err.Error()})
 }
 
 func (c *Core) makeActionContent(msg *model.Message, text string, title string, actions []string) (*model.Message, error) {
@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"mime/multipart"
 	"net/http"
@@ -11,11 +12,14 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/chanify/chanify/logic"
 	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
 	"github.com/gin-gonic/gin"
 	"github.com/sideshow/apns2"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestSender(t *testing.T) {
@@ -215,6 +219,114 @@ func TestSenderPostJSON(t *testing.T) {
 	}
 }
 
+func TestSenderPostTimelineItems(t *testing.T) {
+	logic.APIEndpoint = "http://127.0.0.1"
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123"}) // nolint: errcheck
+	handler := c.APIHandler()
+	token := "CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg"
+	req := httptest.NewRequest("POST", "/v1/sender", strings.NewReader(`{
+		"token": "`+token+`",
+		"timeline": {
+			"code": "test-code",
+			"items": [
+				{"name": "temp", "value": 21.5, "unit": "°C"},
+				{"name": "status", "value": "degraded"}
+			]
+		}
+	}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatal("Send timeline items failed:", w.Code, w.Body.String())
+	}
+	req = httptest.NewRequest("POST", "/v1/sender", strings.NewReader(`{
+		"token": "`+token+`",
+		"timeline": {
+			"code": "test-code",
+			"items": [{"name": "temp", "value": null}, {"value": 1}]
+		}
+	}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"errors":[{"field":"timeline.items[0]","msg":"invalid value"},{"field":"timeline.items[1]","msg":"empty name"}]`) {
+		t.Fatal("Check invalid timeline items failed:", w.Code, w.Body.String())
+	}
+	data := url.Values{
+		"token":         {token},
+		"timeline-code": {"test-code"},
+		"timeline-item": {"temp=21.5°C", "status"},
+	}
+	req = httptest.NewRequest("POST", "/v1/sender", strings.NewReader(data.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"timeline-item[1]"`) {
+		t.Fatal("Check invalid form timeline items failed:", w.Code, w.Body.String())
+	}
+}
+
+func TestSenderPostMarkdown(t *testing.T) {
+	logic.APIEndpoint = "http://127.0.0.1"
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123"}) // nolint: errcheck
+	handler := c.APIHandler()
+	req := httptest.NewRequest("POST", "/v1/sender", strings.NewReader(`{
+		"text": "| a | b |\n|---|---|\n| 1 | 2 |",
+		"format": "markdown",
+		"token": "CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg"
+	}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Result().StatusCode != http.StatusInternalServerError {
+		t.Fatal("Send post markdown failed")
+	}
+	req = httptest.NewRequest("GET", "/v1/sender/CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg/123?format=html", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatal("Check invalid format failed")
+	}
+}
+
+func TestSenderTemplate(t *testing.T) {
+	logic.APIEndpoint = "http://127.0.0.1"
+	c := New()
+	defer c.Close()
+	tpls := []map[string]interface{}{
+		{"name": "deploy", "title": "{{.app}}", "text": "{{.app}} deployed: {{.version}}", "format": "markdown"},
+	}
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123", Templates: tpls}) // nolint: errcheck
+	handler := c.APIHandler()
+	token := "CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg"
+	tests := []struct {
+		method string
+		url    string
+		body   string
+		code   int
+	}{
+		{"POST", "/v1/sender", `{"token":"` + token + `","template":"deploy","vars":{"app":"web","version":1}}`, http.StatusInternalServerError},
+		{"POST", "/v1/sender?template=deploy", `{"token":"` + token + `","vars":{"app":"web"}}`, http.StatusBadRequest},
+		{"POST", "/v1/sender", `{"token":"` + token + `","template":"none"}`, http.StatusNotFound},
+		{"GET", "/v1/sender/" + token + "?template=deploy&vars[app]=web&vars[version]=1", "", http.StatusInternalServerError},
+		{"GET", "/v1/sender/" + token + "?template=none", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Result().StatusCode != tt.code {
+			t.Error("Send template message failed:", tt.url, w.Result().StatusCode)
+		}
+	}
+}
+
 func TestSenderPostImage(t *testing.T) {
 	fpath := filepath.Join(os.TempDir(), "files")
 	defer os.RemoveAll(fpath)
@@ -309,6 +421,53 @@ func TestSenderPostAudio(t *testing.T) {
 	}
 }
 
+func TestSenderPostVideo(t *testing.T) {
+	fpath := filepath.Join(os.TempDir(), "files")
+	defer os.RemoveAll(fpath)
+	logic.APIEndpoint = "http://127.0.0.1"
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123", FilePath: fpath}) // nolint: errcheck
+	handler := c.APIHandler()
+	req := httptest.NewRequest("POST", "/v1/sender", nil)
+	req.Header.Set("Token", "CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg")
+	req.Header.Set("Content-Type", "video/mp4")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	resp := w.Result()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatal("Check send post video failed", resp.StatusCode)
+	}
+}
+
+func TestSenderPostFormVideo(t *testing.T) {
+	fpath := filepath.Join(os.TempDir(), "files")
+	defer os.RemoveAll(fpath)
+
+	logic.APIEndpoint = "http://127.0.0.1"
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123", FilePath: fpath}) // nolint: errcheck
+	handler := c.APIHandler()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	partToken, _ := writer.CreateFormField("token")                                                                                    // nolint: errcheck
+	partToken.Write([]byte("CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg")) // nolint: errcheck
+	partVideo, _ := writer.CreateFormFile("video", "video.mp4")
+	partVideo.Write([]byte("")) // nolint: errcheck
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/v1/sender", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	resp := w.Result()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatal("Check send post video failed", resp.StatusCode)
+	}
+}
+
 func TestSenderPostFormFile(t *testing.T) {
 	fpath := filepath.Join(os.TempDir(), "files")
 	defer os.RemoveAll(fpath)
@@ -338,10 +497,14 @@ func TestSenderPostFormFile(t *testing.T) {
 }
 
 type MockAPNSPusher struct {
-	Error error
+	Error        error
+	Notification *apns2.Notification
+	Count        int
 }
 
 func (m *MockAPNSPusher) Push(n *apns2.Notification) (*apns2.Response, error) {
+	m.Count++
+	m.Notification = n
 	return &apns2.Response{}, m.Error
 }
 
@@ -390,6 +553,68 @@ func TestSendDirect(t *testing.T) {
 	}
 }
 
+func TestSendDirectCritical(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true})                                                                                                                         // nolint: errcheck
+	tk, _ := model.ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRw..c2lnbg")                                                                                            // nolint: errcheck
+	c.logic.UpsertUser("ABOO6TSIXKSEVIJKXLDQSUXQRXUAOXGGYY", "BGaP1ekObDB0bRkmvxkvfFXCLSk46mO7rW8PikP8sWsA_97yij0s0U7ioA9dWEoz41TrUP8Z88XzQ_Tl8AOoJF4", false)                                         // nolint: errcheck
+	c.logic.BindDevice("ABOO6TSIXKSEVIJKXLDQSUXQRXUAOXGGYY", "B3BC1B875EDA13986801B1004B4ABF5760C197F4", "BDuFNLkmxyK0-NN3H3oKzzOtISq1w17-JAibD7X4pljYl6IEaEglWkKD5Iw537h-DYxAooXkHtu6un078sm7IiQ", 1) // nolint: errcheck
+	c.logic.UpdatePushToken("ABOO6TSIXKSEVIJKXLDQSUXQRXUAOXGGYY", "B3BC1B875EDA13986801B1004B4ABF5760C197F4", "aGVsbG8", false)                                                                        // nolint: errcheck
+	pusher := &MockAPNSPusher{}
+	logic.MockPusher = pusher
+	defer func() { logic.MockPusher = nil }()
+	params := &MsgParam{Critical: "1", Volume: 0.5, Batch: "5m"}
+	lc := &luaSendContext{}
+	c.sendDirect(lc, tk, params.ApplyOptions(model.NewMessage(tk).TextContent("hello", "", "", "")))
+	if lc.code != http.StatusOK || pusher.Notification == nil {
+		t.Fatal("Send critical message failed:", lc.code, lc.String())
+	}
+	data, _ := json.Marshal(pusher.Notification.Payload)
+	if !strings.Contains(string(data), `"critical":1`) || !strings.Contains(string(data), `"volume":0.5`) || !strings.Contains(string(data), `"interruption-level":"critical"`) {
+		t.Error("Check critical payload failed:", string(data))
+	}
+}
+
+func TestSendMsgTTL(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true})                                                                                                                         // nolint: errcheck
+	tk, _ := model.ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRw..c2lnbg")                                                                                            // nolint: errcheck
+	c.logic.UpsertUser("ABOO6TSIXKSEVIJKXLDQSUXQRXUAOXGGYY", "BGaP1ekObDB0bRkmvxkvfFXCLSk46mO7rW8PikP8sWsA_97yij0s0U7ioA9dWEoz41TrUP8Z88XzQ_Tl8AOoJF4", false)                                         // nolint: errcheck
+	c.logic.BindDevice("ABOO6TSIXKSEVIJKXLDQSUXQRXUAOXGGYY", "B3BC1B875EDA13986801B1004B4ABF5760C197F4", "BDuFNLkmxyK0-NN3H3oKzzOtISq1w17-JAibD7X4pljYl6IEaEglWkKD5Iw537h-DYxAooXkHtu6un078sm7IiQ", 1) // nolint: errcheck
+	c.logic.UpdatePushToken("ABOO6TSIXKSEVIJKXLDQSUXQRXUAOXGGYY", "B3BC1B875EDA13986801B1004B4ABF5760C197F4", "aGVsbG8", false)                                                                        // nolint: errcheck
+	ttl := 300
+	c.logic.UpsertChannel("ABOO6TSIXKSEVIJKXLDQSUXQRXUAOXGGYY", &model.Channel{Name: "disk", TTL: &ttl}) // nolint: errcheck
+	pusher := &MockAPNSPusher{}
+	logic.MockPusher = pusher
+	defer func() { logic.MockPusher = nil }()
+	send := func(params *MsgParam, channel string) time.Time {
+		lc := &luaSendContext{}
+		pusher.Notification = nil
+		c.sendMsg(lc, tk, params.ApplyOptions(model.NewMessage(tk).TextContent("disk 90% full", "", "", "").SetChannelName(channel)))
+		if lc.code != http.StatusOK || pusher.Notification == nil {
+			t.Fatal("Send message with ttl failed:", lc.code, lc.String())
+		}
+		return pusher.Notification.Expiration
+	}
+	if exp := time.Until(send(&MsgParam{}, "")); exp < 23*time.Hour || exp > 24*time.Hour {
+		t.Error("Check default expiration failed:", exp)
+	}
+	if exp := send(&MsgParam{TTL: "0"}, ""); exp.IsZero() || exp.Unix() != 0 {
+		t.Error("Check deliver now expiration failed:", exp)
+	}
+	if exp := time.Until(send(&MsgParam{TTL: "10m"}, "")); exp < 9*time.Minute || exp > 10*time.Minute {
+		t.Error("Check ttl expiration failed:", exp)
+	}
+	if exp := time.Until(send(&MsgParam{}, "disk")); exp < 4*time.Minute || exp > 5*time.Minute {
+		t.Error("Check channel ttl expiration failed:", exp)
+	}
+	if exp := send(&MsgParam{TTL: "0"}, "disk"); exp.Unix() != 0 {
+		t.Error("Check override channel ttl failed:", exp)
+	}
+}
+
 func TestSendDirectWatch(t *testing.T) {
 	c := New()
 	defer c.Close()
@@ -549,6 +774,9 @@ func TestSaveAudioFile(t *testing.T) {
 	if _, err := c.saveUploadAudio(ctx, tk, "", "test audio", []byte("123")); err != nil {
 		t.Error("Save audio failed", err)
 	}
+	if _, err := c.saveUploadAudio(ctx, tk, "", "", testMP3Data()); err != nil {
+		t.Error("Save mp3 audio failed", err)
+	}
 }
 
 func TestSaveIAudioFileFailed(t *testing.T) {
@@ -563,6 +791,34 @@ func TestSaveIAudioFileFailed(t *testing.T) {
 	}
 }
 
+func TestSaveVideoFile(t *testing.T) {
+	fpath := filepath.Join(os.TempDir(), "files")
+	defer os.RemoveAll(fpath)
+	os.MkdirAll(fpath+"/videos/", os.ModePerm) // nolint: errcheck
+
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", FilePath: fpath}) // nolint: errcheck
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	tk, _ := model.ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRzIUx5tXg-Vym58og7aZw05IkoDvse8..c2lnbg") // nolint: errcheck
+	if _, err := c.saveUploadVideo(ctx, tk, testMP4Data(), nil); err != nil {
+		t.Error("Save video failed", err)
+	}
+}
+
+func TestSaveVideoFileFailed(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory"}) // nolint: errcheck
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	c.saveUploadVideo(ctx, nil, []byte("123"), nil) // nolint: errcheck
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatal("Check save video failed")
+	}
+}
+
 func TestSaveFile(t *testing.T) {
 	fpath := filepath.Join(os.TempDir(), "files")
 	defer os.RemoveAll(fpath)
@@ -656,7 +912,11 @@ func TestTooLargeSaveTextFailed(t *testing.T) {
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Request, _ = http.NewRequest("GET", "/", nil)
-	if _, err := c.makeTextContent(model.NewMessage(tk), strings.Repeat("1", 500), strings.Repeat("2", 2000), "", "1", nil); err == nil {
+	msg, err := c.makeTextContent(model.NewMessage(tk), strings.Repeat("1", 500), strings.Repeat("2", 2000), "", "1", nil)
+	if err != nil {
+		t.Fatal("Make too large text failed:", err)
+	}
+	if err := c.saveTextFile(msg); err == nil {
 		t.Error("Check save too large text failed")
 	}
 }
@@ -698,3 +958,99 @@ func TestSendActionFailed(t *testing.T) {
 		t.Error("Check send action failed", err)
 	}
 }
+
+func TestSenderFilter(t *testing.T) {
+	logic.APIEndpoint = "http://127.0.0.1"
+	logic.RegisterMessageFilter("core-test", logic.MessageFilterFunc(func(msg *logic.FilterMessage) error {
+		switch msg.Text {
+		case "drop":
+			msg.Drop = true
+		case "error":
+			return errors.New("filter error")
+		}
+		return nil
+	}))
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123", Filters: []map[string]interface{}{{"name": "test", "handler": "core-test"}}}) // nolint: errcheck
+	handler := c.APIHandler()
+	tests := map[string]string{
+		"drop":  "message dropped",
+		"error": "filter message failed",
+		"send":  "send message failed",
+	}
+	for text, ret := range tests {
+		req := httptest.NewRequest("GET", "/v1/sender/CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg/"+text, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if !strings.Contains(w.Body.String(), ret) {
+			t.Error("Check sender filter failed:", text, w.Body.String())
+		}
+	}
+}
+
+func TestSenderFilterLongText(t *testing.T) {
+	logic.RegisterMessageFilter("core-test-long", logic.MessageFilterFunc(func(msg *logic.FilterMessage) error {
+		msg.Drop = strings.HasPrefix(msg.Text, "drop")
+		msg.Text = strings.ReplaceAll(msg.Text, "secret=abc", "secret=***")
+		return nil
+	}))
+	fpath := t.TempDir()
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true, FilePath: fpath, Filters: []map[string]interface{}{{"name": "test", "handler": "core-test-long"}}}) // nolint: errcheck
+	_, uid := newDNDTestUser(t, c, false)
+	tk := newDNDTestToken(uid)
+	readFiles := func() string {
+		data := []string{}
+		filepath.Walk(fpath, func(path string, info os.FileInfo, err error) error { // nolint: errcheck
+			if err == nil && !info.IsDir() {
+				d, _ := os.ReadFile(path)
+				data = append(data, string(d))
+			}
+			return nil
+		})
+		return strings.Join(data, "\n")
+	}
+	msg, err := c.makeTextContent(model.NewMessage(tk), "drop"+strings.Repeat("1", 2000)+"secret=abc", "", "", "", nil)
+	if err != nil {
+		t.Fatal("Make long text failed:", err)
+	}
+	c.sendMsg(&luaSendContext{}, tk, msg)
+	if files := readFiles(); len(files) > 0 {
+		t.Error("Check dropped long text saved failed")
+	}
+	msg, _ = c.makeTextContent(model.NewMessage(tk), strings.Repeat("1", 2000)+"secret=abc", "", "", "", nil)
+	c.sendMsg(&luaSendContext{}, tk, msg)
+	if files := readFiles(); strings.Contains(files, "secret=abc") || !strings.Contains(files, "secret=***") {
+		t.Error("Check filter long text failed")
+	}
+	var content pb.MsgContent
+	proto.Unmarshal(msg.Content, &content) // nolint: errcheck
+	if content.Type != pb.MsgType_File || len(content.File) <= 0 || content.Filename != "text.txt" || content.Text != strings.Repeat("1", 100)+"⋯" {
+		t.Error("Check long text file content failed:", content.Type, content.File, content.Filename)
+	}
+}
+
+func TestSendMsgPolicy(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true, Policy: map[string]interface{}{ // nolint: errcheck
+		"max-interruption-level": "active",
+		"ignore-sound":           true,
+		"passive-channels":       []interface{}{"logs"},
+	}})
+	_, uid := newDNDTestUser(t, c, false)
+	c.logic.UpsertChannel(uid, &model.Channel{Name: "alerts", Sound: "bell", InterruptionLevel: "time-sensitive"}) // nolint: errcheck
+	tk := newDNDTestToken(uid)
+	msg := model.NewMessage(tk).TextContent("hello", "", "", "").SoundName("alarm").SetChannelName("alerts")
+	c.sendMsg(&luaSendContext{}, tk, msg)
+	if msg.Sound.GetName() != "bell" || msg.InterruptionLevel != pb.InterruptionLevel_IlActive {
+		t.Error("Check send policy failed:", msg.Sound, msg.InterruptionLevel)
+	}
+	msg = model.NewMessage(tk).TextContent("hello", "", "", "").SetChannelName("logs").SetCritical(true, 1)
+	c.sendMsg(&luaSendContext{}, tk, msg)
+	if msg.Sound != nil || msg.InterruptionLevel != pb.InterruptionLevel_IlPassive {
+		t.Error("Check send passive channel policy failed:", msg.Sound, msg.InterruptionLevel)
+	}
+}
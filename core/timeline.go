@@ -0,0 +1,50 @@
+package core
+
+import (
+	"net/http"
+
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+)
+
+// handleTimeline return timeline codes of user, or history points of code
+func (c *Core) handleTimeline(ctx *gin.Context) {
+	var params struct {
+		Nonce  uint64 `json:"nonce"`
+		UserID string `json:"user"`
+		Code   string `json:"code"`
+		Since  int64  `json:"since"`
+		Until  int64  `json:"until"`
+		Limit  int    `json:"limit"`
+	}
+	if err := c.bindBodyJSON(ctx, &params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "invalid params"})
+		return
+	}
+	if !c.verifyServerfulUser(ctx, params.UserID) {
+		return
+	}
+	if len(params.Code) <= 0 {
+		codes, err := c.logic.GetTimelineCodes(params.UserID)
+		if err != nil {
+			replyTimelineError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"uid": params.UserID, "codes": codes})
+		return
+	}
+	pts, err := c.logic.GetTimeline(params.UserID, params.Code, params.Since, params.Until, params.Limit)
+	if err != nil {
+		replyTimelineError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"uid": params.UserID, "code": params.Code, "points": pts})
+}
+
+func replyTimelineError(ctx *gin.Context, err error) {
+	if err == model.ErrNotImplemented {
+		ctx.JSON(http.StatusNotImplemented, gin.H{"res": http.StatusNotImplemented, "msg": "timeline history not supported"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "get timeline failed"})
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimelineHandler(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true}) // nolint: errcheck
+	sk, uid := newDNDTestUser(t, c, false)
+	handler := c.APIHandler()
+	post := func(body string, sign bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/rest/v1/timeline", strings.NewReader(body))
+		if sign {
+			sig, _ := sk.Sign([]byte(body))
+			req.Header.Set("CHUserSign", crypto.Base64Encode.EncodeToString(sig))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	tk := newDNDTestToken(uid)
+	ts := time.Now().Add(-time.Minute)
+	c.sendDirect(&luaSendContext{}, tk, model.NewMessage(tk).TimelineContent("server", "", &ts, []*model.MsgTimeItem{{Name: "status", Value: "degraded"}, {Name: "temp", Value: 21.5, Unit: "°C"}}))
+	c.sendDirect(&luaSendContext{}, tk, model.NewMessage(tk).TimelineContent("server", "", nil, []*model.MsgTimeItem{{Name: "up", Value: true}}))
+	c.sendDirect(&luaSendContext{}, tk, model.NewMessage(tk).TextContent("hello", "", "", ""))
+
+	w := post(`{"nonce":1,"user":"`+uid+`"}`, true)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"codes":["server"]`) {
+		t.Fatal("Get timeline codes failed:", w.Code, w.Body.String())
+	}
+	w = post(`{"nonce":2,"user":"`+uid+`","code":"server"}`, true)
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `{"name":"status","value":"degraded"}`) || !strings.Contains(body, `{"name":"temp","value":21.5,"unit":"°C"}`) || !strings.Contains(body, `{"name":"up","value":true}`) {
+		t.Fatal("Get timeline points failed:", w.Code, body)
+	}
+	if strings.Index(body, `"status"`) > strings.Index(body, `"up"`) {
+		t.Error("Check timeline points order failed:", body)
+	}
+	w = post(`{"nonce":3,"user":"`+uid+`","code":"server","limit":1}`, true)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"status"`) || !strings.Contains(w.Body.String(), `"up"`) {
+		t.Error("Get latest timeline point failed:", w.Code, w.Body.String())
+	}
+	if w := post(`{`, true); w.Code != http.StatusBadRequest {
+		t.Error("Check timeline invalid params failed:", w.Code)
+	}
+	if w := post(`{"nonce":4,"user":"`+uid+`"}`, false); w.Code != http.StatusUnauthorized {
+		t.Error("Check timeline sign failed:", w.Code)
+	}
+}
+
+func TestReplyTimelineError(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	replyTimelineError(ctx, model.ErrNotImplemented)
+	if w.Code != http.StatusNotImplemented {
+		t.Error("Check timeline not implemented failed:", w.Code)
+	}
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	replyTimelineError(ctx, logic.ErrNotFound)
+	if w.Code != http.StatusInternalServerError {
+		t.Error("Check timeline failed:", w.Code)
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"encoding/json"
+	"image"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
@@ -14,6 +15,7 @@ import (
 	"github.com/chanify/chanify/crypto"
 	"github.com/chanify/chanify/model"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/image/bmp"
 	"golang.org/x/image/tiff"
 	"golang.org/x/image/webp"
 )
@@ -23,6 +25,10 @@ const (
 	gifHeader  = "GIF"
 	riffHeader = "RIFF"
 	webpHeader = "WEBP"
+	bmpHeader  = "BM"
+
+	previewMaxSize = 64
+	previewMaxData = 1024
 )
 
 func (c *Core) bindBodyJSON(ctx *gin.Context, obj interface{}) error {
@@ -104,6 +110,57 @@ func parsePriority(priority string) int {
 	return 0
 }
 
+func parseRepeatCount(count string) int {
+	if len(count) > 0 {
+		if n, err := strconv.Atoi(count); err == nil {
+			return n
+		}
+		return -1
+	}
+	return 0
+}
+
+// queryTTL return ttl of message from query, expires is alias of ttl
+func queryTTL(ctx *gin.Context) string {
+	return tryStringValue(ctx.Query("ttl"), ctx.Query("expires"))
+}
+
+// jsonTTLValue return ttl of message from json number or string, the first one set is used
+func jsonTTLValue(values ...interface{}) string {
+	for _, v := range values {
+		switch val := v.(type) {
+		case string:
+			if len(val) > 0 {
+				return val
+			}
+		case float64:
+			return strconv.FormatFloat(val, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+func parseCritical(critical string) bool {
+	switch strings.ToLower(critical) {
+	case "1", "true", "on", "yes":
+		return true
+	}
+	return false
+}
+
+func parseBool(value string) bool {
+	return parseCritical(value)
+}
+
+func parseVolume(volume string) float64 {
+	if len(volume) > 0 {
+		if v, err := strconv.ParseFloat(volume, 64); err == nil && v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
 func parseImageContentType(data []byte) string {
 	if len(data) > 12 {
 		str := string(data[:12])
@@ -115,35 +172,62 @@ func parseImageContentType(data []byte) string {
 			return "image/tiff"
 		} else if strings.HasPrefix(str, riffHeader) && strings.HasPrefix(string(str[8:]), webpHeader) {
 			return "image/webp"
+		} else if strings.HasPrefix(str, bmpHeader) {
+			return "image/bmp"
+		} else if str[4:8] == "ftyp" {
+			switch str[8:12] {
+			case "avif", "avis":
+				return "image/avif"
+			case "heic", "heix", "heim", "heis", "mif1", "msf1":
+				return "image/heic"
+			}
 		}
 	}
 	return "image/jpeg"
 }
 
 func createThumbnail(data []byte) *model.Thumbnail {
+	var cfg image.Config
+	var err error
 	switch parseImageContentType(data) {
 	case "image/png":
-		if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
-		}
+		cfg, err = png.DecodeConfig(bytes.NewReader(data))
 	case "image/gif":
-		if cfg, err := gif.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
-		}
+		cfg, err = gif.DecodeConfig(bytes.NewReader(data))
 	case "image/tiff":
-		if cfg, err := tiff.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
-		}
+		cfg, err = tiff.DecodeConfig(bytes.NewReader(data))
 	case "image/webp":
-		if cfg, err := webp.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
+		cfg, err = webp.DecodeConfig(bytes.NewReader(data))
+	case "image/bmp":
+		cfg, err = bmp.DecodeConfig(bytes.NewReader(data))
+	case "image/heic", "image/avif":
+		if w, h := parseHEIFSize(data); w > 0 && h > 0 {
+			return model.NewThumbnail(w, h)
 		}
+		return nil
 	default:
-		if cfg, err := jpeg.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
+		cfg, err = jpeg.DecodeConfig(bytes.NewReader(data))
+		if err == nil && parseImageOrientation(data) >= 5 {
+			cfg.Width, cfg.Height = cfg.Height, cfg.Width
 		}
 	}
-	return nil
+	if err != nil {
+		return nil
+	}
+	return model.NewThumbnail(cfg.Width, cfg.Height)
+}
+
+func createPreview(data []byte) []byte {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	img = orientImage(scaleImage(img, previewMaxSize), parseImageOrientation(data))
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: 50}); err != nil || out.Len() > previewMaxData {
+		return nil
+	}
+	return out.Bytes()
 }
 
 func fileBaseName(path string) string {
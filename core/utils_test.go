@@ -128,3 +128,40 @@ func TestJsonString(t *testing.T) {
 		t.Fatal("Check unmarshal json failed")
 	}
 }
+
+func TestJSONTTLValue(t *testing.T) {
+	var data struct {
+		TTL     interface{} `json:"ttl"`
+		Expires interface{} `json:"expires"`
+	}
+	tests := map[string]string{
+		`{"ttl":0}`:                 "0",
+		`{"ttl":300}`:               "300",
+		`{"ttl":"5m","expires":60}`: "5m",
+		`{"ttl":"","expires":60}`:   "60",
+		`{"ttl":true}`:              "",
+		`{}`:                        "",
+	}
+	for body, want := range tests {
+		data.TTL, data.Expires = nil, nil
+		json.Unmarshal([]byte(body), &data) // nolint: errcheck
+		if v := jsonTTLValue(data.TTL, data.Expires); v != want {
+			t.Error("Check json ttl value failed:", body, v)
+		}
+	}
+}
+
+func TestParseCritical(t *testing.T) {
+	if !parseCritical("1") || !parseCritical("TRUE") || parseCritical("0") || parseCritical("") {
+		t.Error("Check parse critical failed")
+	}
+	if parseVolume("0.5") != 0.5 || parseVolume("-1") != 0 || parseVolume("abc") != 0 {
+		t.Error("Check parse volume failed")
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	if !parseBool("yes") || !parseBool("On") || parseBool("no") || parseBool("") {
+		t.Error("Check parse bool failed")
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/binary"
+
+	"github.com/chanify/chanify/model"
+)
+
+// VideoInfo define video metadata
+type VideoInfo struct {
+	Width    int
+	Height   int
+	Duration uint64 // milliseconds
+}
+
+func parseVideoContentType(data []byte) string {
+	if len(data) > 12 && string(data[4:8]) == "ftyp" && string(data[8:12]) == "qt  " {
+		return "video/quicktime"
+	}
+	return "video/mp4"
+}
+
+func parseVideoInfo(data []byte) *VideoInfo {
+	moov := findMP4Box(data, "moov")
+	if moov == nil {
+		return nil
+	}
+	info := &VideoInfo{}
+	if mvhd := findMP4Box(moov, "mvhd"); len(mvhd) > 0 {
+		var timescale, duration uint64
+		if mvhd[0] == 1 && len(mvhd) >= 32 {
+			timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+			duration = binary.BigEndian.Uint64(mvhd[24:32])
+		} else if len(mvhd) >= 20 {
+			timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+			duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+		}
+		if timescale > 0 {
+			info.Duration = duration * 1000 / timescale
+		}
+	}
+	walkMP4Boxes(moov, func(name string, body []byte) bool {
+		if name != "trak" {
+			return true
+		}
+		if tkhd := findMP4Box(body, "tkhd"); len(tkhd) > 0 {
+			offset := 76
+			if tkhd[0] == 1 {
+				offset = 88
+			}
+			if len(tkhd) >= offset+8 {
+				w := int(binary.BigEndian.Uint32(tkhd[offset:]) >> 16)
+				h := int(binary.BigEndian.Uint32(tkhd[offset+4:]) >> 16)
+				if w > 0 && h > 0 {
+					info.Width = w
+					info.Height = h
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return info
+}
+
+func createVideoThumbnail(info *VideoInfo, poster []byte) *model.Thumbnail {
+	var thumbnail *model.Thumbnail
+	if info != nil && info.Width > 0 && info.Height > 0 {
+		thumbnail = model.NewThumbnail(info.Width, info.Height)
+	} else if len(poster) > 0 {
+		thumbnail = createThumbnail(poster)
+	}
+	if thumbnail != nil && len(poster) > 0 {
+		thumbnail.SetPreview(createPreview(poster))
+	}
+	return thumbnail
+}
+
+func findMP4Box(data []byte, name string) []byte {
+	var ret []byte
+	walkMP4Boxes(data, func(n string, body []byte) bool {
+		if n == name {
+			ret = body
+			return false
+		}
+		return true
+	})
+	return ret
+}
+
+func walkMP4Boxes(data []byte, fn func(name string, body []byte) bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		name := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return
+		}
+		if !fn(name, data[header:size]) {
+			return
+		}
+		data = data[size:]
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+)
+
+func mp4Box(name string, body ...[]byte) []byte {
+	size := 8
+	for _, b := range body {
+		size += len(b)
+	}
+	out := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(out, uint32(size))
+	copy(out[4:], name)
+	for _, b := range body {
+		out = append(out, b...)
+	}
+	return out
+}
+
+func testMP4Data() []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 12345)
+	tkhdAudio := make([]byte, 84)
+	tkhdVideo := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhdVideo[76:], 1920<<16)
+	binary.BigEndian.PutUint32(tkhdVideo[80:], 1080<<16)
+	return append(mp4Box("ftyp", []byte("isom\x00\x00\x02\x00")), mp4Box("moov",
+		mp4Box("mvhd", mvhd),
+		mp4Box("trak", mp4Box("tkhd", tkhdAudio)),
+		mp4Box("trak", mp4Box("tkhd", tkhdVideo)),
+	)...)
+}
+
+func TestParseVideoInfo(t *testing.T) {
+	info := parseVideoInfo(testMP4Data())
+	if info == nil {
+		t.Fatal("Parse video info failed")
+	}
+	if info.Duration != 12345 || info.Width != 1920 || info.Height != 1080 {
+		t.Error("Check video info failed:", info)
+	}
+	if parseVideoInfo([]byte("123")) != nil {
+		t.Error("Check invalid video info failed")
+	}
+	if parseVideoInfo(mp4Box("moov", []byte{0, 0, 0, 1, 'm', 'v', 'h', 'd'})) == nil {
+		t.Error("Check truncated video info failed")
+	}
+}
+
+func TestParseVideoContentType(t *testing.T) {
+	if parseVideoContentType(testMP4Data()) != "video/mp4" {
+		t.Error("Check mp4 content type failed")
+	}
+	if parseVideoContentType(mp4Box("ftyp", []byte("qt  \x00\x00\x02\x00"))) != "video/quicktime" {
+		t.Error("Check quicktime content type failed")
+	}
+}
+
+func TestCreateVideoThumbnail(t *testing.T) {
+	if createVideoThumbnail(nil, nil) != nil {
+		t.Error("Check empty video thumbnail failed")
+	}
+	if createVideoThumbnail(parseVideoInfo(testMP4Data()), nil) == nil {
+		t.Error("Create video thumbnail failed")
+	}
+	dPNG, _ := base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAACAQMAAACjTyRkAAAABGdBTUEAALGPC/xhBQAAACBjSFJNAAB6JgAAgIQAAPoAAACA6AAAdTAAAOpgAAA6mAAAF3CculE8AAAABlBMVEWZAAD///+fsNhWAAAAAWJLR0QB/wIt3gAAAAd0SU1FB+UDHRczLl5aCAkAAAAMSURBVAjXY2BgYAAAAAQAASc0JwoAAAAldEVYdGRhdGU6Y3JlYXRlADIwMjEtMDMtMjlUMjM6NTE6NDYrMDA6MDCUDk5dAAAAJXRFWHRkYXRlOm1vZGlmeQAyMDIxLTAzLTI5VDIzOjUxOjQ2KzAwOjAw5VP24QAAAABJRU5ErkJggg==")
+	if createVideoThumbnail(nil, dPNG) == nil {
+		t.Error("Create video poster thumbnail failed")
+	}
+	if len(createPreview(dPNG)) <= 0 {
+		t.Error("Create poster preview failed")
+	}
+	if createPreview([]byte("123")) != nil {
+		t.Error("Check invalid poster preview failed")
+	}
+}
@@ -2,11 +2,14 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strings"
 
+	"github.com/chanify/chanify/logic"
 	"github.com/chanify/chanify/model"
 	"github.com/gin-gonic/gin"
 	lua "github.com/yuin/gopher-lua"
@@ -21,21 +24,50 @@ func (c *Core) handlePostWebhook(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"res": http.StatusNotFound, "msg": "no webhook found"})
 		return
 	}
+	code, ctype, data, err := c.runWebhook(ctx, webhook)
+	if err == logic.ErrWebhookUnauthorized {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"res": http.StatusUnauthorized, "msg": err.Error()})
+		return
+	}
+	var se *logic.WebhookSendError
+	if errors.As(err, &se) {
+		ctx.Data(se.Code, "application/json; charset=utf-8", []byte(se.Response))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": err.Error()})
+		return
+	}
+	ctx.DataFromReader(code, int64(len(data)), ctype, strings.NewReader(data), map[string]string{})
+}
+
+func (c *Core) runWebhook(ctx *gin.Context, webhook *logic.Webhook) (int, string, string, error) {
 	ctx.Set(coreKey, c)
+	var body []byte
 	if ctx.Request.Body != nil {
-		if body, err := io.ReadAll(ctx.Request.Body); err == nil {
+		if data, err := io.ReadAll(ctx.Request.Body); err == nil {
+			body = data
 			ctx.Set(gin.BodyBytesKey, body)
 		}
 	}
-	l := lua.NewState()
-	defer l.Close()
-	initHttpLua(l, ctx)
-	if err := webhook.DoCall(l); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": err.Error()})
-		return
+	if err := webhook.Verify(ctx.GetHeader, body); err != nil {
+		return 0, "", "", err
 	}
-	code, ctype, data := getHttpLuaReturn(l)
-	ctx.DataFromReader(code, int64(len(data)), ctype, strings.NewReader(data), map[string]string{})
+	if handler := webhook.Handler(); handler != nil {
+		code, data, err := handler.ServeWebhook(&webhookContext{c: c, ctx: ctx, webhook: webhook})
+		return code, "text/plain; charset=utf-8", data, err
+	}
+	var code int
+	var ctype, data string
+	err := webhook.Call(ctx.Request.Context(), func(l *lua.LState) error {
+		initHttpLua(l, ctx)
+		if err := webhook.DoCall(l); err != nil {
+			return err
+		}
+		code, ctype, data = getHttpLuaReturn(l)
+		return nil
+	})
+	return code, ctype, data, err
 }
 
 func initHttpLua(l *lua.LState, ctx *gin.Context) {
@@ -111,7 +143,8 @@ func luaContextGetRequest(l *lua.LState) int {
 }
 
 type luaSendContext struct {
-	msg string
+	code int
+	msg  string
 }
 
 func luaContextSend(l *lua.LState) int {
@@ -129,31 +162,140 @@ func luaContextSend(l *lua.LState) int {
 		text = string(args)
 	case *lua.LTable:
 		opts = args
-		text = opts.RawGetString("text").String()
+		text = luaGetOptsString(opts, "text")
 	}
-	if len(text) <= 0 {
-		l.Push(lua.LString(`{"res":204,"msg":"no message content"}`))
-		return 1
+	params := &MsgParam{
+		Text:              text,
+		Title:             luaGetOptsString(opts, "title"),
+		Sound:             luaGetOptsString(opts, "sound"),
+		CopyText:          luaGetOptsString(opts, "copy"),
+		AutoCopy:          luaGetOptsString(opts, "autocopy"),
+		Format:            luaGetOptsString(opts, "format"),
+		Template:          luaGetOptsString(opts, "template"),
+		Priority:          parsePriority(luaGetOptsString(opts, "priority")),
+		InterruptionLevel: luaGetOptsString(opts, "interruption-level"),
+		Critical:          luaGetOptsString(opts, "critical"),
+		Volume:            parseVolume(luaGetOptsString(opts, "volume")),
+		Ack:               luaGetOptsString(opts, "ack"),
+		AckCallback:       luaGetOptsString(opts, "ack-callback"),
+		Repeat:            luaGetOptsString(opts, "repeat"),
+		RepeatCount:       parseRepeatCount(luaGetOptsString(opts, "repeat-count")),
+		TTL:               tryStringValue(luaGetOptsString(opts, "ttl"), luaGetOptsString(opts, "expires")),
 	}
-	tk := luaGetOptsString(opts, "token")
+	if vars, ok := opts.RawGetString("vars").(*lua.LTable); ok {
+		params.Vars = logic.LuaTableToMap(vars)
+	}
+	if len(params.Template) > 0 {
+		if err := params.ApplyTemplate(c); err != nil {
+			lc := &luaSendContext{}
+			replyTemplateError(lc, err)
+			l.Push(lua.LString(lc.String()))
+			return 1
+		}
+	}
+	lc := &luaSendContext{}
+	c.sendWebhookMessage(lc, ctx, luaGetOptsString(opts, "token"), func(token *model.Token) (*model.Message, error) {
+		if acts := luaGetOptsActions(opts, "action"); len(acts) > 0 {
+			actions, err := c.makeActions(token.GetUserID(), acts)
+			if err != nil {
+				replyActionError(lc, err)
+				return nil, err
+			}
+			params.Actions = actions
+		}
+		return c.makeLuaMessage(lc, token, params, opts)
+	}, params)
+	l.Push(lua.LString(lc.String()))
+	return 1
+}
+
+func (c *Core) sendWebhookMessage(lc *luaSendContext, ctx *gin.Context, tk string, makeMsg func(token *model.Token) (*model.Message, error), params *MsgParam) {
 	if len(tk) <= 0 {
 		tk = getToken(ctx)
 	}
+	dr, dryRun := ctx.Get(dryRunKey)
 	token, err := c.parseToken(tk)
 	if err != nil {
-		l.Push(lua.LString(`{"res":401,"msg":"invalid token"}`))
-		return 1
+		if !dryRun {
+			lc.JSON(http.StatusUnauthorized, gin.H{"res": http.StatusUnauthorized, "msg": "invalid token"})
+			return
+		}
+		if token, err = model.ParseToken(tk); err != nil {
+			token = &model.Token{}
+		}
 	}
-	msg := model.NewMessage(token)
-	msg, err = c.makeTextContent(msg, text, luaGetOptsString(opts, "title"), luaGetOptsString(opts, "copy"), luaGetOptsString(opts, "autocopy"), luaGetOptsArray(opts, "action"))
+	msg, err := makeMsg(token)
 	if err != nil {
-		l.Push(lua.LString(`{"res":413,"msg":"too large text content"}`))
-		return 1
+		return
 	}
-	lc := &luaSendContext{}
-	c.sendMsg(lc, token, msg.SoundName(luaGetOptsString(opts, "sound")).SetPriority(parsePriority(luaGetOptsString(opts, "priority"))).SetInterruptionLevel(luaGetOptsString(opts, "interruption-level")))
-	l.Push(lua.LString(lc.String()))
-	return 1
+	params.ApplyOptions(msg)
+	if dryRun {
+		if err := c.saveTextFile(msg); err != nil {
+			lc.JSON(http.StatusInternalServerError, gin.H{"res": http.StatusInternalServerError, "msg": "save file failed"})
+			return
+		}
+		dr.(*WebhookDryRun).record(lc, msg)
+		return
+	}
+	c.sendMsg(lc, token, msg)
+}
+
+func (c *Core) makeLuaMessage(lc *luaSendContext, token *model.Token, params *MsgParam, opts *lua.LTable) (*model.Message, error) {
+	filename := fileBaseName(luaGetOptsString(opts, "filename"))
+	for _, key := range []string{"image", "audio", "file"} {
+		data := luaGetOptsString(opts, key)
+		if len(data) <= 0 {
+			continue
+		}
+		if !c.logic.CanFileStore() {
+			lc.JSON(http.StatusBadRequest, gin.H{"res": http.StatusBadRequest, "msg": "no file storage"})
+			return nil, ErrInvalidContent
+		}
+		switch key {
+		case "image":
+			return c.saveUploadImage(lc, token, []byte(data))
+		case "audio":
+			return c.saveUploadAudio(lc, token, filename, params.Title, []byte(data))
+		default:
+			if len(filename) <= 0 {
+				filename = "file"
+			}
+			return c.saveUploadFile(lc, token, []byte(data), filename, params.Text, params.Actions)
+		}
+	}
+	if link := luaGetOptsString(opts, "link"); len(link) > 0 {
+		return model.NewMessage(token).LinkContent(link), nil
+	}
+	if tl, ok := opts.RawGetString("timeline").(*lua.LTable); ok {
+		if code := luaGetOptsString(tl, "code"); len(code) > 0 {
+			var ts interface{}
+			switch v := tl.RawGetString("timestamp").(type) {
+			case lua.LNumber:
+				ts = int64(v)
+			case lua.LString:
+				ts = string(v)
+			}
+			var items []*model.MsgTimeItem
+			if its, ok := tl.RawGetString("items").(*lua.LTable); ok {
+				var errs []*TimeItemError
+				if items, errs = luaGetTimeItems(its); len(errs) > 0 {
+					replyTimeItemErrors(lc, errs)
+					return nil, ErrInvalidContent
+				}
+			}
+			return model.NewMessage(token).TimelineContent(code, params.Title, parseTimestamp(ts), items), nil
+		}
+	}
+	if len(params.Text) <= 0 {
+		lc.JSON(http.StatusNoContent, gin.H{"res": http.StatusNoContent, "msg": "no message content"})
+		return nil, ErrNoContent
+	}
+	msg, err := c.makeFormatTextContent(model.NewMessage(token), params.Format, params.Text, params.Title, params.CopyText, params.AutoCopy, params.Actions)
+	if err != nil {
+		replyTextContentError(lc, err)
+		return nil, err
+	}
+	return msg, nil
 }
 
 func (l *luaSendContext) String() string {
@@ -161,12 +303,14 @@ func (l *luaSendContext) String() string {
 }
 
 func (l *luaSendContext) JSON(code int, obj interface{}) {
+	l.code = code
 	if val, err := json.Marshal(obj); err == nil {
 		l.msg = string(val)
 	}
 }
 
 func (l *luaSendContext) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string) {
+	l.code = code
 	if val, err := io.ReadAll(reader); err == nil {
 		l.msg = string(val)
 	}
@@ -184,12 +328,22 @@ func luaGetOptsString(opts *lua.LTable, key string) string {
 	}
 }
 
-func luaGetOptsArray(opts *lua.LTable, key string) []string {
-	actions := []string{}
+func luaGetOptsActions(opts *lua.LTable, key string) []*ActionParam {
+	actions := []*ActionParam{}
 	val := opts.RawGetString(key)
 	if v, ok := val.(*lua.LTable); ok {
 		v.ForEach(func(idx, value lua.LValue) {
-			actions = append(actions, value.String())
+			if act, ok := value.(*lua.LTable); ok {
+				actions = append(actions, &ActionParam{
+					Type:   luaGetOptsString(act, "type"),
+					Name:   luaGetOptsString(act, "name"),
+					Link:   luaGetOptsString(act, "link"),
+					Method: luaGetOptsString(act, "method"),
+					Body:   luaGetOptsString(act, "body"),
+				})
+				return
+			}
+			actions = append(actions, parseActionParam(value.String()))
 		})
 	}
 	return actions
@@ -244,3 +398,32 @@ func luaContextGetHeader(l *lua.LState) int {
 	}
 	return 1
 }
+
+// luaGetTimeItems read timeline items from {name = value} or {{name = .., value = .., unit = ..}}
+func luaGetTimeItems(tbl *lua.LTable) ([]*model.MsgTimeItem, []*TimeItemError) {
+	if tbl.Len() <= 0 {
+		return parseTimeContentItems("timeline.items.%s", luaIntegerValues(logic.LuaTableToMap(tbl)))
+	}
+	b := newTimeItemsBuilder()
+	for i := 1; i <= tbl.Len(); i++ {
+		field := fmt.Sprintf("timeline.items[%d]", i-1)
+		it, ok := tbl.RawGetInt(i).(*lua.LTable)
+		if !ok {
+			b.fail(field, errInvalidTimeItem)
+			continue
+		}
+		item := luaIntegerValues(logic.LuaTableToMap(it))
+		name, _ := item["name"].(string)
+		b.add(field, name, item)
+	}
+	return b.items, b.errors
+}
+
+func luaIntegerValues(values map[string]interface{}) map[string]interface{} {
+	for k, v := range values {
+		if f, ok := v.(float64); ok && f == math.Trunc(f) {
+			values[k] = int64(f)
+		}
+	}
+	return values
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+type webhookAdapterCase struct {
+	plugin   string
+	fixture  string
+	query    string
+	headers  map[string]string
+	env      map[string]interface{}
+	code     int
+	title    string
+	text     []string
+	priority int
+	level    string
+}
+
+type webhookAdapterSent struct {
+	msg     pb.Message
+	content pb.MsgContent
+}
+
+// runWebhookAdapter post fixture to adapter of a test user, return the pushed message or nil if nothing is sent
+func runWebhookAdapter(t *testing.T, tc *webhookAdapterCase) *webhookAdapterSent {
+	body, err := os.ReadFile(filepath.Join("testdata", "webhook", tc.fixture))
+	if err != nil {
+		t.Fatal("Read fixture failed:", tc.fixture, err)
+	}
+	c := New()
+	defer c.Close()
+	whs := []map[string]interface{}{
+		{"name": tc.plugin, "file": filepath.Join("..", "plugin", "webhook", tc.plugin+".lua"), "env": tc.env},
+	}
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", Registerable: true, WebHooks: whs}) // nolint: errcheck
+	_, uid := newDNDTestUser(t, c, false)
+	c.logic.BindDevice(uid, "B3BC1B875EDA13986801B1004B4ABF5760C197F4", "BDuFNLkmxyK0-NN3H3oKzzOtISq1w17-JAibD7X4pljYl6IEaEglWkKD5Iw537h-DYxAooXkHtu6un078sm7IiQ", 1) // nolint: errcheck
+	c.logic.UpdatePushToken(uid, "B3BC1B875EDA13986801B1004B4ABF5760C197F4", "aGVsbG8", false)                                                                        // nolint: errcheck
+	pusher := &MockAPNSPusher{}
+	logic.MockPusher = pusher
+	defer func() { logic.MockPusher = nil }()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/webhook/"+tc.plugin+"/"+newSignedTestToken(t, c, uid)+tc.query, bytes.NewReader(body))
+	for k, v := range tc.headers {
+		req.Header.Set(k, v)
+	}
+	c.APIHandler().ServeHTTP(w, req)
+	code := tc.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	if w.Result().StatusCode != code {
+		t.Fatal("Check webhook adapter status failed:", tc.fixture, w.Result().StatusCode, w.Body.String())
+	}
+	if pusher.Notification == nil {
+		return nil
+	}
+	var payload struct {
+		Msg string `json:"msg"`
+	}
+	data, _ := json.Marshal(pusher.Notification.Payload)
+	json.Unmarshal(data, &payload) // nolint: errcheck
+	data, _ = crypto.Base64Encode.DecodeString(payload.Msg)
+	key, _ := c.logic.GetUserKey(uid)
+	aesgcm, _ := model.NewAESGCM(key)
+	if len(data) < 12 {
+		t.Fatal("Check webhook adapter payload failed:", tc.fixture)
+	}
+	out, err := aesgcm.Open(nil, data[:12], data[12:], key[32:64])
+	if err != nil {
+		t.Fatal("Decrypt webhook adapter message failed:", tc.fixture, err)
+	}
+	sent := &webhookAdapterSent{}
+	if err := proto.Unmarshal(out, &sent.msg); err != nil {
+		t.Fatal("Unmarshal webhook adapter message failed:", tc.fixture, err)
+	}
+	if err := proto.Unmarshal(sent.msg.Content, &sent.content); err != nil {
+		t.Fatal("Unmarshal webhook adapter content failed:", tc.fixture, err)
+	}
+	return sent
+}
+
+var webhookAdapterLevels = map[string]pb.InterruptionLevel{
+	"active":         pb.InterruptionLevel_IlActive,
+	"passive":        pb.InterruptionLevel_IlPassive,
+	"time-sensitive": pb.InterruptionLevel_IlTimeSensitive,
+}
+
+func TestWebhookAdapters(t *testing.T) {
+	tests := []*webhookAdapterCase{
+		{plugin: "alertmanager", fixture: "alertmanager_firing.json", title: "[FIRING:2] HighCPU", text: []string{"[FIRING] CPU usage above 90% (web-1:9100)", "(web-2:9100)"}, priority: 10, level: "time-sensitive"},
+		{plugin: "alertmanager", fixture: "alertmanager_resolved.json", title: "[RESOLVED] DiskFull", text: []string{"[RESOLVED] Disk usage back to normal (db-1:9100)"}, priority: 5, level: "passive"},
+		{plugin: "grafana", fixture: "grafana_firing.json", title: "[FIRING:1] API latency Services", text: []string{"[FIRING] p99 latency is high", "value=1.53"}, priority: 10, level: "active"},
+		{plugin: "grafana", fixture: "grafana_legacy_ok.json", title: "[OK] Memory usage", text: []string{"Memory usage is normal"}, priority: 5, level: "passive"},
+		{plugin: "gitlab", fixture: "gitlab_pipeline_failed.json", headers: map[string]string{"X-Gitlab-Event": "Pipeline Hook"}, title: "GitLab gitlab-org/gitlab-test", text: []string{"pipeline #31 failed on main"}, priority: 10, level: "time-sensitive"},
+		{plugin: "gitlab", fixture: "gitlab_push.json", headers: map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "secret"}, env: map[string]interface{}{"secret_token": "secret"}, title: "GitLab mike/diaspora", text: []string{"John Smith pushed 2 commit(s) to main", "- Update Catalan translation to e38cb41.", "- fixed readme"}, priority: 5, level: "passive"},
+		{plugin: "gitea", fixture: "gitea_pull_request.json", headers: map[string]string{"X-Gitea-Event": "pull_request"}, title: "Gitea acme/app", text: []string{"pull request #7 opened:\nAdd dark mode"}, priority: 10, level: "active"},
+		{plugin: "gitea", fixture: "gitea_push.json", headers: map[string]string{"X-Gitea-Event": "push"}, title: "Gitea acme/app", text: []string{"bob pushed 1 commit(s) to develop", "- Fix login redirect"}, priority: 5, level: "passive"},
+		{plugin: "sentry", fixture: "sentry_issue.json", headers: map[string]string{"Sentry-Hook-Resource": "issue"}, title: "Sentry issue created", text: []string{"ZeroDivisionError: division by zero", "app.views in divide", "https://sentry.io/organizations/acme/issues/1170820242/"}, priority: 10, level: "time-sensitive"},
+		{plugin: "sentry", fixture: "sentry_metric_resolved.json", headers: map[string]string{"Sentry-Hook-Resource": "metric_alert"}, title: "Resolved: High error rate", text: []string{"1000 events in the last 10 minutes"}, priority: 5, level: "passive"},
+		{plugin: "uptimekuma", fixture: "uptimekuma_down.json", title: "[DOWN] Website", text: []string{"connect ECONNREFUSED 10.0.0.5:443", "https://example.com"}, priority: 10, level: "time-sensitive"},
+		{plugin: "uptimekuma", fixture: "uptimekuma_up.json", title: "[UP] Website", text: []string{"200 - OK"}, priority: 5, level: "passive"},
+		{plugin: "json", fixture: "json_alert.json", query: "?title_field=alert.name", title: "Backup job", text: []string{"Nightly backup failed after 3 retries"}, priority: 10, level: "time-sensitive"},
+		{plugin: "json", fixture: "json_alert.json", env: map[string]interface{}{"title_field": "alert.state", "status_field": "alert.state"}, title: "error", text: []string{"Nightly backup failed"}, priority: 10, level: "time-sensitive"},
+	}
+	for _, tc := range tests {
+		sent := runWebhookAdapter(t, tc)
+		if sent == nil {
+			t.Error("Check webhook adapter send failed:", tc.fixture)
+			continue
+		}
+		if sent.content.Title != tc.title {
+			t.Error("Check webhook adapter title failed:", tc.fixture, sent.content.Title)
+		}
+		for _, s := range tc.text {
+			if !strings.Contains(sent.content.Text, s) {
+				t.Error("Check webhook adapter text failed:", tc.fixture, sent.content.Text)
+			}
+		}
+		if int(sent.msg.Priority) != tc.priority {
+			t.Error("Check webhook adapter priority failed:", tc.fixture, sent.msg.Priority)
+		}
+		if sent.msg.InterruptionLevel != webhookAdapterLevels[tc.level] {
+			t.Error("Check webhook adapter interruption level failed:", tc.fixture, sent.msg.InterruptionLevel)
+		}
+	}
+}
+
+func TestWebhookAdaptersVerify(t *testing.T) {
+	body, _ := os.ReadFile(filepath.Join("testdata", "webhook", "gitea_push.json"))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body) // nolint: errcheck
+	sign := hex.EncodeToString(mac.Sum(nil))
+	tests := []*webhookAdapterCase{
+		{plugin: "gitlab", fixture: "gitlab_push.json", headers: map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "wrong"}, env: map[string]interface{}{"secret_token": "secret"}, code: http.StatusUnauthorized},
+		{plugin: "gitea", fixture: "gitea_push.json", headers: map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": "00"}, env: map[string]interface{}{"secret_token": "secret"}, code: http.StatusUnauthorized},
+		{plugin: "gitea", fixture: "gitea_push.json", headers: map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign}, env: map[string]interface{}{"secret_token": "secret"}},
+		{plugin: "sentry", fixture: "sentry_issue.json", headers: map[string]string{"Sentry-Hook-Resource": "issue", "Sentry-Hook-Signature": sign}, env: map[string]interface{}{"client_secret": "secret"}, code: http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		sent := runWebhookAdapter(t, tc)
+		if (tc.code == 0) != (sent != nil) {
+			t.Error("Check webhook adapter verify failed:", tc.plugin, tc.fixture)
+		}
+	}
+}
+
+func TestWebhookAdaptersInvalidPayload(t *testing.T) {
+	for _, name := range []string{"alertmanager", "grafana", "gitlab", "gitea", "sentry", "uptimekuma", "json"} {
+		c := New()
+		whs := []map[string]interface{}{
+			{"name": name, "file": filepath.Join("..", "plugin", "webhook", name+".lua")},
+		}
+		c.Init(&logic.Options{DBUrl: "nosql://?secret=123", WebHooks: whs}) // nolint: errcheck
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/webhook/"+name+"/token", strings.NewReader("not json"))
+		c.APIHandler().ServeHTTP(w, req)
+		if w.Result().StatusCode != http.StatusBadRequest {
+			t.Error("Check webhook adapter invalid payload failed:", name, w.Result().StatusCode)
+		}
+		c.Close()
+	}
+}
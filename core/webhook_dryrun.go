@@ -0,0 +1,40 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+)
+
+const dryRunKey = "_chanify/webhook/dryrun"
+
+// WebhookDryRun is the result of running webhook without sending messages
+type WebhookDryRun struct {
+	Code        int
+	ContentType string
+	Body        string
+	Messages    []*model.Message
+}
+
+// DryRunWebhook run webhook with request, messages are recorded instead of pushed and token is not verified
+func (c *Core) DryRunWebhook(name string, req *http.Request) (*WebhookDryRun, error) {
+	webhook, err := c.logic.GetWebhook(name)
+	if err != nil {
+		return nil, err
+	}
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = req
+	ctx.Params = gin.Params{{Key: "name", Value: name}}
+	ret := &WebhookDryRun{}
+	ctx.Set(dryRunKey, ret)
+	ret.Code, ret.ContentType, ret.Body, err = c.runWebhook(ctx, webhook)
+	return ret, err
+}
+
+func (r *WebhookDryRun) record(ctx sendContext, msg *model.Message) {
+	r.Messages = append(r.Messages, msg)
+	ctx.JSON(http.StatusOK, gin.H{"request-uid": fmt.Sprintf("dry-run-%d", len(r.Messages))})
+}
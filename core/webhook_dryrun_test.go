@@ -0,0 +1,56 @@
+package core
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDryRunWebhook(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "echo.lua"), []byte(`
+local req = ctx:request()
+local ret = ctx:send({ text = req:body(), title = ctx:env("title"), sound = "1", priority = 10 })
+ctx:send({ text = "second", token = "invalid" })
+return 201, ret
+`), 0644) // nolint: errcheck
+	os.WriteFile(filepath.Join(dir, "fail.lua"), []byte(`error("boom")`), 0644) // nolint: errcheck
+
+	c := New()
+	defer c.Close()
+	whs := []map[string]interface{}{
+		{"name": "echo", "file": "echo.lua", "env": map[string]interface{}{"title": "Dry"}},
+		{"name": "fail", "file": "fail.lua"},
+		{"name": "native", "handler": "alertmanager"},
+	}
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123", PluginPath: dir, WebHooks: whs}) // nolint: errcheck
+	req, _ := http.NewRequest("POST", "/v1/webhook/echo", strings.NewReader("hello"))
+	ret, err := c.DryRunWebhook("echo", req)
+	if err != nil || ret.Code != 201 || ret.Body != `{"request-uid":"dry-run-1"}` {
+		t.Fatal("Check dry run webhook failed:", err, ret)
+	}
+	if len(ret.Messages) != 2 || ret.Messages[0].Priority != 10 || ret.Messages[0].Sound.Name != "1" {
+		t.Fatal("Check dry run webhook messages failed:", ret.Messages)
+	}
+	var content pb.MsgContent
+	if err := proto.Unmarshal(ret.Messages[0].Content, &content); err != nil || content.Text != "hello" || content.Title != "Dry" {
+		t.Error("Check dry run webhook content failed:", err, content.Text, content.Title)
+	}
+	req, _ = http.NewRequest("POST", "/v1/webhook/fail", nil)
+	if _, err := c.DryRunWebhook("fail", req); err == nil {
+		t.Error("Check dry run webhook error failed")
+	}
+	req, _ = http.NewRequest("POST", "/v1/webhook/native", strings.NewReader(`{"status":"firing","alerts":[{"status":"firing","labels":{"alertname":"Down"}}]}`))
+	if ret, err := c.DryRunWebhook("native", req); err != nil || ret.Code != http.StatusOK || len(ret.Messages) != 1 {
+		t.Error("Check dry run native webhook failed:", err, ret)
+	}
+	if _, err := c.DryRunWebhook("none", req); err == nil {
+		t.Error("Check dry run webhook not found failed")
+	}
+}
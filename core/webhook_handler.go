@@ -0,0 +1,92 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+)
+
+type webhookContext struct {
+	c       *Core
+	ctx     *gin.Context
+	webhook *logic.Webhook
+}
+
+func (w *webhookContext) Env(key string) interface{} {
+	return w.webhook.Env(key)
+}
+
+func (w *webhookContext) Token() string {
+	return getToken(w.ctx)
+}
+
+func (w *webhookContext) URL() *url.URL {
+	return w.ctx.Request.URL
+}
+
+func (w *webhookContext) Body() []byte {
+	if cb, ok := w.ctx.Get(gin.BodyBytesKey); ok {
+		if cbb, ok := cb.([]byte); ok {
+			return cbb
+		}
+	}
+	return []byte{}
+}
+
+func (w *webhookContext) Query(key string) (string, bool) {
+	return w.ctx.GetQuery(key)
+}
+
+func (w *webhookContext) Header(key string) string {
+	return w.ctx.GetHeader(key)
+}
+
+// Send message and return the response of sender, error is returned if sending failed
+func (w *webhookContext) Send(m *logic.WebhookMessage) (string, error) {
+	params := &MsgParam{
+		Text:              m.Text,
+		Title:             m.Title,
+		Sound:             m.Sound,
+		CopyText:          m.CopyText,
+		AutoCopy:          m.AutoCopy,
+		Format:            m.Format,
+		Template:          m.Template,
+		Vars:              m.Vars,
+		Priority:          m.Priority,
+		InterruptionLevel: m.InterruptionLevel,
+		Volume:            m.Volume,
+		Actions:           m.Actions,
+	}
+	if m.Critical {
+		params.Critical = "1"
+	}
+	lc := &luaSendContext{}
+	if len(params.Template) > 0 {
+		if err := params.ApplyTemplate(w.c); err != nil {
+			replyTemplateError(lc, err)
+			return lc.String(), &logic.WebhookSendError{Code: lc.code, Response: lc.String()}
+		}
+	}
+	w.c.sendWebhookMessage(lc, w.ctx, m.Token, func(token *model.Token) (*model.Message, error) {
+		if len(m.Link) > 0 {
+			return model.NewMessage(token).LinkContent(m.Link), nil
+		}
+		if len(params.Text) <= 0 {
+			lc.JSON(http.StatusNoContent, gin.H{"res": http.StatusNoContent, "msg": "no message content"})
+			return nil, ErrNoContent
+		}
+		msg, err := w.c.makeFormatTextContent(model.NewMessage(token), params.Format, params.Text, params.Title, params.CopyText, params.AutoCopy, params.Actions)
+		if err != nil {
+			replyTextContentError(lc, err)
+			return nil, err
+		}
+		return msg, nil
+	}, params)
+	if lc.code != http.StatusOK {
+		return lc.String(), &logic.WebhookSendError{Code: lc.code, Response: lc.String()}
+	}
+	return lc.String(), nil
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chanify/chanify/logic"
+)
+
+func TestWebhookNativeHandler(t *testing.T) {
+	logic.APIEndpoint = "http://127.0.0.1"
+	logic.RegisterWebhookHandler("core-test", logic.WebhookHandlerFunc(func(ctx logic.WebhookContext) (int, string, error) {
+		action, _ := ctx.Query("action")
+		switch action {
+		case "echo":
+			return http.StatusCreated, strings.Join([]string{ctx.Token(), string(ctx.Body()), ctx.Header("X-Test"), ctx.Env("name").(string), ctx.URL().Path}, ","), nil
+		case "empty":
+			_, err := ctx.Send(&logic.WebhookMessage{})
+			return http.StatusOK, "", err
+		case "template":
+			_, err := ctx.Send(&logic.WebhookMessage{Template: "none"})
+			return http.StatusOK, "", err
+		case "token":
+			_, err := ctx.Send(&logic.WebhookMessage{Token: "abc", Text: "hello"})
+			return http.StatusOK, "", err
+		case "send":
+			ret, err := ctx.Send(&logic.WebhookMessage{Text: "hello", Priority: 10, InterruptionLevel: "active"})
+			return http.StatusOK, ret, err
+		}
+		return 0, "", errors.New("unknown action")
+	}))
+
+	c := New()
+	defer c.Close()
+	whs := []map[string]interface{}{
+		{"name": "native", "handler": "core-test", "env": map[string]interface{}{"Name": "native"}},
+	}
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123", WebHooks: whs}) // nolint: errcheck
+	token := "CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg"
+	tests := map[string]int{
+		"echo":     http.StatusCreated,
+		"empty":    http.StatusNoContent,
+		"template": http.StatusNotFound,
+		"token":    http.StatusUnauthorized,
+		"send":     http.StatusInternalServerError,
+		"unknown":  http.StatusBadRequest,
+	}
+	for action, code := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/webhook/native/"+token+"?action="+action, strings.NewReader("body"))
+		req.Header.Set("X-Test", "header")
+		c.APIHandler().ServeHTTP(w, req)
+		if w.Result().StatusCode != code {
+			t.Error("Check native webhook failed:", action, w.Result().StatusCode, w.Body.String())
+		}
+		if action == "echo" && w.Body.String() != token+",body,header,native,/v1/webhook/native/"+token {
+			t.Error("Check native webhook context failed:", w.Body.String())
+		}
+		if action == "send" && !strings.Contains(w.Body.String(), `"res":500`) {
+			t.Error("Check native webhook send failed:", w.Body.String())
+		}
+	}
+}
+
+func TestWebhookVerify(t *testing.T) {
+	c := New()
+	defer c.Close()
+	whs := []map[string]interface{}{
+		{"name": "alert", "handler": "alertmanager", "env": map[string]interface{}{"token": "abc"}, "verify": map[string]interface{}{"type": "bearer", "secret_env": "token"}},
+	}
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123", WebHooks: whs}) // nolint: errcheck
+	tests := map[string]int{
+		"":           http.StatusUnauthorized,
+		"Bearer abd": http.StatusUnauthorized,
+		"Bearer abc": http.StatusBadRequest,
+	}
+	for auth, code := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/webhook/alert", strings.NewReader("{}"))
+		req.Header.Set("Authorization", auth)
+		c.APIHandler().ServeHTTP(w, req)
+		if w.Result().StatusCode != code {
+			t.Error("Check webhook verify failed:", auth, w.Result().StatusCode, w.Body.String())
+		}
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"testing"
 
 	"github.com/chanify/chanify/logic"
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
 	"github.com/gin-gonic/gin"
 	lua "github.com/yuin/gopher-lua"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestWebHook(t *testing.T) {
@@ -36,6 +39,8 @@ func TestWebHook(t *testing.T) {
 		assert(req:query("xyz") == nil, "query error")
 		assert(req:query("abc") == "123", "query error")
 		assert(ctx:env("z") == nil, "env error")
+		assert(getmetatable(ctx).__index.leak == nil, "metatable leak")
+		getmetatable(ctx).__index.leak = true
 		return 201,ctx:env("x")`
 	fs.WriteString(s) // nolint: errcheck
 	fs.Sync()         // nolint: errcheck
@@ -48,12 +53,14 @@ func TestWebHook(t *testing.T) {
 		{"name": "github", "file": "x.lua"},
 	}
 	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", PluginPath: dir, WebHooks: whs}) // nolint: errcheck
-	w := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(w)
-	ctx.Request, _ = http.NewRequest("POST", "/v1/webhook/github?abc=123", nil)
-	c.APIHandler().ServeHTTP(w, ctx.Request)
-	if w.Result().StatusCode != 201 {
-		t.Fatal("Do webhook failed")
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request, _ = http.NewRequest("POST", "/v1/webhook/github?abc=123", nil)
+		c.APIHandler().ServeHTTP(w, ctx.Request)
+		if w.Result().StatusCode != 201 {
+			t.Fatal("Do webhook failed:", i, w.Body.String())
+		}
 	}
 }
 
@@ -86,6 +93,26 @@ func TestWebHookFailed(t *testing.T) {
 	}
 }
 
+func TestWebHookTimeout(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "loop.lua")
+	os.WriteFile(fpath, []byte("while true do end"), 0644) // nolint: errcheck
+
+	c := New()
+	defer c.Close()
+	whs := []map[string]interface{}{
+		{"name": "loop", "file": fpath, "timeout": "100ms"},
+	}
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", PluginPath: dir, WebHooks: whs}) // nolint: errcheck
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request, _ = http.NewRequest("POST", "/v1/webhook/loop", strings.NewReader(`{}`))
+	c.APIHandler().ServeHTTP(w, ctx.Request)
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatal("Check webhook timeout failed")
+	}
+}
+
 func TestWebHookNotFound(t *testing.T) {
 	c := New()
 	defer c.Close()
@@ -210,6 +237,125 @@ func TestLuaContextSend(t *testing.T) {
 	}
 }
 
+func TestLuaContextSendTemplate(t *testing.T) {
+	logic.APIEndpoint = "http://127.0.0.1"
+	c := New()
+	defer c.Close()
+	tpls := []map[string]interface{}{
+		{"name": "alert", "text": "{{.name}} is {{.status}}"},
+	}
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123", Templates: tpls}) // nolint: errcheck
+	l := lua.NewState()
+	defer l.Close()
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request, _ = http.NewRequest("POST", "/v1/webhook/alert?token=CNjo6ua-WhIiQUJPTzZUU0lYS1NFVklKS1hMRFFTVVhRUlhVQU9YR0dZWQ..faqRNWqzTW3Fjg4xh9CS_p8IItEHjSQiYzJjxcqf_tg", nil)
+	ctx.Set(coreKey, c)
+	initHttpLua(l, ctx)
+	tests := map[string]string{
+		`return ctx:send({template="alert",vars={name="db",status="down"}})`: `"res":500`,
+		`return ctx:send({template="alert",vars={name="db"}})`:               `"res":400`,
+		`return ctx:send({template="none"})`:                                 `"res":404`,
+	}
+	for code, expected := range tests {
+		if err := l.DoString(code); err != nil {
+			t.Fatal(err)
+		}
+		if res := l.Get(-1).String(); !strings.Contains(res, expected) {
+			t.Error("Send lua template failed:", code, res)
+		}
+		l.Pop(1)
+	}
+}
+
+func TestLuaMakeMessage(t *testing.T) {
+	fpath := filepath.Join(os.TempDir(), "files")
+	defer os.RemoveAll(fpath)
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "sqlite://?mode=memory", FilePath: fpath})                                                              // nolint: errcheck
+	tk, _ := model.ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRzIUx5tXg-Vym58og7aZw05IkoDvse8..c2lnbg") // nolint: errcheck
+	l := lua.NewState()
+	defer l.Close()
+	tests := map[string]pb.MsgType{
+		`return {image="\137PNG\r\n\026\n"}`:                                                            pb.MsgType_Image,
+		`return {audio="ID3", filename="a/b.mp3"}`:                                                      pb.MsgType_Audio,
+		`return {file="abc", text="desc"}`:                                                              pb.MsgType_File,
+		`return {link="https://www.chanify.net"}`:                                                       pb.MsgType_Link,
+		`return {timeline={code="cpu", timestamp=1620000000000, items={load=1, usage=12.5, name="x"}}}`: pb.MsgType_Timeline,
+		`return {text="**abc**", format="markdown"}`:                                                    pb.MsgType_Text,
+	}
+	for code, typ := range tests {
+		if err := l.DoString(code); err != nil {
+			t.Fatal(err)
+		}
+		opts := l.Get(-1).(*lua.LTable)
+		l.Pop(1)
+		lc := &luaSendContext{}
+		msg, err := c.makeLuaMessage(lc, tk, &MsgParam{Text: luaGetOptsString(opts, "text"), Format: luaGetOptsString(opts, "format")}, opts)
+		if err != nil {
+			t.Fatal("Make lua message failed:", code, err, lc.String())
+		}
+		var ctx pb.MsgContent
+		if err := proto.Unmarshal(msg.Content, &ctx); err != nil || ctx.Type != typ {
+			t.Error("Check lua message type failed:", code, ctx.Type)
+		}
+		if typ == pb.MsgType_Timeline && (ctx.TimeContent.Timestamp != 1620000000000 || len(ctx.TimeContent.TimeItems) != 3) {
+			t.Error("Check lua timeline message failed")
+		}
+		if typ == pb.MsgType_Audio && ctx.Filename != "b.mp3" {
+			t.Error("Check lua audio filename failed")
+		}
+	}
+	lc := &luaSendContext{}
+	if _, err := c.makeLuaMessage(lc, tk, &MsgParam{}, l.NewTable()); err != ErrNoContent {
+		t.Error("Check lua empty message failed")
+	}
+}
+
+func TestLuaGetTimeItems(t *testing.T) {
+	l := lua.NewState()
+	defer l.Close()
+	if err := l.DoString(`return {{name="temp", value=21.5, unit="°C"}, {name="count", value=3}, {name="up", value=true}}`); err != nil {
+		t.Fatal(err)
+	}
+	items, errs := luaGetTimeItems(l.Get(-1).(*lua.LTable))
+	if len(errs) > 0 || len(items) != 3 || items[0].Name != "temp" || items[0].Unit != "°C" || items[1].Value != int64(3) || items[2].Value != true {
+		t.Fatal("Get lua time items failed:", items, errs)
+	}
+	if err := l.DoString(`return {{value=1}, "x", {name="a", value={}}}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, errs := luaGetTimeItems(l.Get(-1).(*lua.LTable)); len(errs) != 3 || errs[1].Field != "timeline.items[1]" || errs[1].Msg != "invalid item" {
+		t.Fatal("Check invalid lua time items failed:", errs)
+	}
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123"}) // nolint: errcheck
+	if err := l.DoString(`return {timeline={code="cpu", items={load={}}}}`); err != nil {
+		t.Fatal(err)
+	}
+	lc := &luaSendContext{}
+	if _, err := c.makeLuaMessage(lc, &model.Token{}, &MsgParam{}, l.Get(-1).(*lua.LTable)); err != ErrInvalidContent || !strings.Contains(lc.String(), "timeline.items.load") {
+		t.Error("Check lua invalid timeline failed:", err, lc.String())
+	}
+}
+
+func TestLuaMakeMessageNoStorage(t *testing.T) {
+	c := New()
+	defer c.Close()
+	c.Init(&logic.Options{DBUrl: "nosql://?secret=123"})                                                                                 // nolint: errcheck
+	tk, _ := model.ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRzIUx5tXg-Vym58og7aZw05IkoDvse8..c2lnbg") // nolint: errcheck
+	l := lua.NewState()
+	defer l.Close()
+	opts := l.NewTable()
+	opts.RawSetString("image", lua.LString("abc"))
+	lc := &luaSendContext{}
+	if _, err := c.makeLuaMessage(lc, tk, &MsgParam{}, opts); err != ErrInvalidContent || !strings.Contains(lc.String(), "no file storage") {
+		t.Error("Check lua message without storage failed")
+	}
+}
+
 func TestLuaContextSendFailed(t *testing.T) {
 	l := lua.NewState()
 	defer l.Close()
@@ -230,16 +376,20 @@ func TestLuaSendContext(t *testing.T) {
 	}
 }
 
-func TestLuaGetOptsArray(t *testing.T) {
+func TestLuaGetOptsActions(t *testing.T) {
 	l := lua.NewState()
 	defer l.Close()
 	tbl := l.NewTable()
 	arr := l.NewTable()
 	tbl.RawSetString("a", arr)
-	arr.Append(lua.LNumber(10))
-	arr.Append(lua.LNumber(20))
-	ss := luaGetOptsArray(tbl, "a")
-	if len(ss) != 2 && ss[1] == "20" {
-		t.Error("Check opts array failed")
+	arr.Append(lua.LString("open|http://127.0.0.1"))
+	act := l.NewTable()
+	act.RawSetString("type", lua.LString("copy"))
+	act.RawSetString("name", lua.LString("Copy"))
+	act.RawSetString("body", lua.LString("abc"))
+	arr.Append(act)
+	acts := luaGetOptsActions(tbl, "a")
+	if len(acts) != 2 || acts[0].Link != "http://127.0.0.1" || acts[1].Type != "copy" || acts[1].Body != "abc" {
+		t.Error("Check opts actions failed")
 	}
 }
@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/chanify/chanify/model"
+)
+
+// repeat-until-acked limits
+const (
+	AckMinInterval = 30 * time.Second
+	AckMaxInterval = 24 * time.Hour
+	AckMaxRepeat   = 10
+)
+
+const (
+	ackNamespace = "ack"
+	ackExpires   = 24 * time.Hour
+)
+
+// error define for message acknowledgement
+var (
+	ErrInvalidAck = errors.New("invalid ack options")
+)
+
+// AckRecord is acknowledgement state of sent message
+type AckRecord struct {
+	UID      string `json:"uid"`
+	Device   string `json:"device,omitempty"`
+	Acked    int64  `json:"acked,omitempty"`
+	Callback string `json:"callback,omitempty"`
+	Interval int64  `json:"interval,omitempty"`
+	Repeat   int    `json:"repeat,omitempty"`
+	Timeline bool   `json:"timeline,omitempty"`
+	Message  []byte `json:"message,omitempty"`
+}
+
+// ParseRepeatInterval parse repeat interval from duration (e.g. 5m) or seconds, 0 if disabled
+func ParseRepeatInterval(interval string) time.Duration {
+	return parseDuration(interval, AckMinInterval, AckMaxInterval)
+}
+
+// ValidateAck check acknowledgement options, callback must be http(s) url of allowed callback hosts
+func (l *Logic) ValidateAck(opts *model.AckOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if len(opts.Callback) > 0 {
+		u, err := url.Parse(opts.Callback)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) <= 0 {
+			return ErrInvalidAck
+		}
+		if err := checkAllowHost(l.callbackHosts, u); err != nil {
+			return err
+		}
+	}
+	if opts.Repeat < 0 || opts.Repeat > AckMaxRepeat || (opts.Repeat > 0 && opts.Interval <= 0) {
+		return ErrInvalidAck
+	}
+	return nil
+}
+
+// TrackAck record sent message for acknowledgement, and schedule repeat if needed
+func (l *Logic) TrackAck(reqID string, uid string, msg *model.Message) error {
+	opts := msg.Ack()
+	if opts == nil {
+		return nil
+	}
+	rec := &AckRecord{
+		UID:      uid,
+		Callback: opts.Callback,
+		Interval: int64(opts.Interval / time.Second),
+		Repeat:   opts.Repeat,
+	}
+	if rec.Repeat > 0 {
+		rec.Timeline = msg.IsTimeline()
+		rec.Message = msg.Marshal()
+	}
+	return l.saveAck(reqID, rec, true)
+}
+
+// GetAck return acknowledgement state of request
+func (l *Logic) GetAck(reqID string) (*AckRecord, error) {
+	data, err := l.db.GetPluginValue(ackNamespace, reqID)
+	if err != nil {
+		if err == sql.ErrNoRows || err == model.ErrNotImplemented {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var rec AckRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// AckMessage mark request of user acked by device, return true if it is the first ack
+func (l *Logic) AckMessage(reqID string, uid string, uuid string) (*AckRecord, bool, error) {
+	rec, err := l.GetAck(reqID)
+	if err != nil {
+		return nil, false, err
+	}
+	if rec.UID != uid {
+		return nil, false, ErrNotFound
+	}
+	if rec.Acked > 0 {
+		return rec, false, nil
+	}
+	rec.Device = uuid
+	rec.Acked = time.Now().Unix()
+	rec.Repeat = 0
+	rec.Message = nil
+	return rec, true, l.saveAck(reqID, rec, false)
+}
+
+// NextRepeat take out message to repeat if request is not acked yet
+func (l *Logic) NextRepeat(reqID string) (*AckRecord, *model.Message, error) {
+	rec, err := l.GetAck(reqID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rec.Acked > 0 || rec.Repeat <= 0 {
+		return rec, nil, nil
+	}
+	h := &model.HeldMessage{Timeline: rec.Timeline, Data: rec.Message}
+	msg, err := h.Message()
+	if err != nil {
+		return nil, nil, err
+	}
+	rec.Repeat--
+	return rec, msg, l.saveAck(reqID, rec, rec.Repeat > 0)
+}
+
+func (l *Logic) saveAck(reqID string, rec *AckRecord, schedule bool) error {
+	data, _ := json.Marshal(rec)
+	interval := time.Duration(rec.Interval) * time.Second
+	expires := time.Now().Add(ackExpires + interval*time.Duration(rec.Repeat))
+	if err := l.db.SetPluginValue(ackNamespace, reqID, data, expires.Unix()); err != nil {
+		return err
+	}
+	if schedule && rec.Repeat > 0 {
+		return l.db.HoldMessage(&model.HeldMessage{
+			UID:     rec.UID,
+			Mode:    HeldModeRepeat,
+			Deliver: time.Now().Add(interval).Unix(),
+			Data:    []byte(reqID),
+		})
+	}
+	return nil
+}
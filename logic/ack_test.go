@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chanify/chanify/model"
+)
+
+func TestParseRepeatInterval(t *testing.T) {
+	if ParseRepeatInterval("") != 0 || ParseRepeatInterval("abc") != 0 {
+		t.Error("Check empty repeat interval failed")
+	}
+	if ParseRepeatInterval("1s") != AckMinInterval || ParseRepeatInterval("48h") != AckMaxInterval || ParseRepeatInterval("300") != 5*time.Minute {
+		t.Error("Check repeat interval failed")
+	}
+}
+
+func TestValidateAck(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", CallbackHosts: []string{"127.0.0.1"}})
+	defer l.Close()
+	if l.ValidateAck(nil) != nil || l.ValidateAck(&model.AckOptions{Callback: "https://127.0.0.1/ack", Interval: time.Minute, Repeat: 3}) != nil {
+		t.Error("Check valid ack options failed")
+	}
+	for _, opts := range []*model.AckOptions{
+		{Callback: "ftp://127.0.0.1/ack"},
+		{Repeat: 3},
+		{Interval: time.Minute, Repeat: AckMaxRepeat + 1},
+		{Repeat: -1},
+	} {
+		if l.ValidateAck(opts) != ErrInvalidAck {
+			t.Error("Check invalid ack options failed:", opts)
+		}
+	}
+	for _, link := range []string{"http://localhost/ack", "http://169.254.169.254/latest"} {
+		if err := l.ValidateAck(&model.AckOptions{Callback: link}); err != ErrHostNotAllowed {
+			t.Error("Check ack callback host failed:", link, err)
+		}
+	}
+}
+
+func TestAckMessage(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory"})
+	defer l.Close()
+	if _, err := l.GetAck("req"); err != ErrNotFound {
+		t.Fatal("Check ack not found failed:", err)
+	}
+	if err := l.TrackAck("none", "abc", model.NewMessage(&model.Token{})); err != nil {
+		t.Fatal("Check track message without ack failed:", err)
+	}
+	if _, err := l.GetAck("none"); err != ErrNotFound {
+		t.Fatal("Check untracked ack failed:", err)
+	}
+	msg := model.NewMessage(&model.Token{}).TextContent("hello", "", "", "").SetAck(&model.AckOptions{Interval: time.Minute, Repeat: 2})
+	if err := l.TrackAck("req", "abc", msg); err != nil {
+		t.Fatal("Track ack failed:", err)
+	}
+	held, _ := l.PopHeldMessages(time.Now().Add(time.Minute), 10)
+	if len(held) != 1 || held[0].Mode != HeldModeRepeat || string(held[0].Data) != "req" {
+		t.Fatal("Check repeat scheduled failed:", held)
+	}
+	rec, repeat, err := l.NextRepeat("req")
+	if err != nil || repeat == nil || rec.Repeat != 1 {
+		t.Fatal("Next repeat failed:", err, rec)
+	}
+	if held, _ := l.PopHeldMessages(time.Now().Add(time.Minute), 10); len(held) != 1 {
+		t.Fatal("Check next repeat scheduled failed:", len(held))
+	}
+	if _, _, err := l.AckMessage("req", "xyz", "dev"); err != ErrNotFound {
+		t.Error("Check ack other user failed:", err)
+	}
+	rec, first, err := l.AckMessage("req", "abc", "dev")
+	if err != nil || !first || rec.Acked <= 0 || rec.Device != "dev" {
+		t.Fatal("Ack message failed:", err, rec)
+	}
+	if _, first, _ := l.AckMessage("req", "abc", "dev2"); first {
+		t.Error("Check ack again failed")
+	}
+	if _, repeat, err := l.NextRepeat("req"); err != nil || repeat != nil {
+		t.Error("Check repeat acked message failed:", err)
+	}
+}
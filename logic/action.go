@@ -0,0 +1,130 @@
+package logic
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/google/uuid"
+)
+
+const (
+	callbackExpires   = 7 * 24 * time.Hour
+	callbackMaxBody   = 1024
+	callbackNamespace = "callback"
+)
+
+// error define for message actions
+var (
+	ErrInvalidAction   = errors.New("invalid action")
+	ErrInvalidCallback = errors.New("invalid callback")
+	ErrCallbackDone    = errors.New("callback already done")
+)
+
+// Callback request sent by node when callback action is tapped
+type Callback struct {
+	ID      string `json:"n"`
+	UID     string `json:"i"`
+	URL     string `json:"u"`
+	Method  string `json:"m,omitempty"`
+	Body    string `json:"b,omitempty"`
+	Expires int64  `json:"e"`
+}
+
+// NewCallback create callback request for devices of user, only http(s) url of allowed callback hosts is accepted
+func (l *Logic) NewCallback(uid string, link string, method string, body string) (*Callback, error) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) <= 0 {
+		return nil, ErrInvalidAction
+	}
+	if err := checkAllowHost(l.callbackHosts, u); err != nil {
+		return nil, err
+	}
+	method = strings.ToUpper(method)
+	switch method {
+	case "":
+		method = "POST"
+	case "GET", "POST", "PUT", "PATCH", "DELETE":
+	default:
+		return nil, ErrInvalidAction
+	}
+	if len(body) > callbackMaxBody {
+		return nil, ErrInvalidAction
+	}
+	return &Callback{
+		ID:      uuid.New().String(),
+		UID:     uid,
+		URL:     link,
+		Method:  method,
+		Body:    body,
+		Expires: time.Now().Add(callbackExpires).Unix(),
+	}, nil
+}
+
+// MakeCallbackURL sign callback into node callback url
+func (l *Logic) MakeCallbackURL(cb *Callback) string {
+	data, _ := json.Marshal(cb)
+	return l.Endpoint + "/v1/callback/" + crypto.Base64Encode.EncodeToString(data) + "." + crypto.Base64Encode.EncodeToString(l.signCallback(data))
+}
+
+// ParseCallback verify signed callback and check expires
+func (l *Logic) ParseCallback(token string) (*Callback, error) {
+	ss := strings.SplitN(token, ".", 2)
+	if len(ss) < 2 {
+		return nil, ErrInvalidCallback
+	}
+	data, err := crypto.Base64Encode.DecodeString(ss[0])
+	if err != nil {
+		return nil, ErrInvalidCallback
+	}
+	sign, err := crypto.Base64Encode.DecodeString(ss[1])
+	if err != nil || !hmac.Equal(sign, l.signCallback(data)) {
+		return nil, ErrInvalidCallback
+	}
+	var cb Callback
+	if err := json.Unmarshal(data, &cb); err != nil || len(cb.ID) <= 0 || len(cb.UID) <= 0 || cb.Expires < time.Now().Unix() {
+		return nil, ErrInvalidCallback
+	}
+	if err := l.CheckCallbackURL(cb.URL); err != nil {
+		return nil, err
+	}
+	return &cb, nil
+}
+
+// DoneCallback record callback as done, ErrCallbackDone if it has been done before
+func (l *Logic) DoneCallback(cb *Callback) error {
+	n, err := l.db.IncrPluginValue(callbackNamespace, cb.ID, 1, cb.Expires)
+	if err != nil {
+		return err
+	}
+	if n > 1 {
+		return ErrCallbackDone
+	}
+	return nil
+}
+
+// ReleaseCallback clear done record of callback, so that a failed callback can be retried
+func (l *Logic) ReleaseCallback(cb *Callback) error {
+	return l.db.DelPluginValue(callbackNamespace, cb.ID)
+}
+
+// CheckCallbackURL check url is http(s) url of allowed callback hosts
+func (l *Logic) CheckCallbackURL(link string) error {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ErrInvalidCallback
+	}
+	return checkAllowHost(l.callbackHosts, u)
+}
+
+func (l *Logic) signCallback(data []byte) []byte {
+	mac := hmac.New(sha256.New, l.secKey.MarshalSecretKey())
+	mac.Write([]byte("callback:")) // nolint: errcheck
+	mac.Write(data)                // nolint: errcheck
+	return mac.Sum(nil)
+}
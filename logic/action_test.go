@@ -0,0 +1,89 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCallback(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", CallbackHosts: []string{"127.0.0.1", " *.Example.com "}})
+	defer l.Close()
+	if _, err := l.NewCallback("u1", "ftp://127.0.0.1/abc", "", ""); err != ErrInvalidAction {
+		t.Error("Check callback scheme failed:", err)
+	}
+	if _, err := l.NewCallback("u1", "http://127.0.0.1/abc", "HEAD", ""); err != ErrInvalidAction {
+		t.Error("Check callback method failed:", err)
+	}
+	if _, err := l.NewCallback("u1", "http://127.0.0.1/abc", "", strings.Repeat("a", callbackMaxBody+1)); err != ErrInvalidAction {
+		t.Error("Check callback body failed:", err)
+	}
+	for _, link := range []string{"http://localhost/abc", "http://169.254.169.254/latest", "https://example.com.evil/abc"} {
+		if _, err := l.NewCallback("u1", link, "", ""); err != ErrHostNotAllowed {
+			t.Error("Check callback host failed:", link, err)
+		}
+	}
+	cb, err := l.NewCallback("u1", "https://127.0.0.1/abc", "put", "{}")
+	if err != nil || cb.Method != "PUT" || cb.Body != "{}" {
+		t.Error("Create callback failed:", err)
+	}
+	if cb, _ := l.NewCallback("u1", "https://api.example.com/abc", "", ""); cb.Method != "POST" {
+		t.Error("Check default callback method failed:", cb.Method)
+	}
+	l2, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory"})
+	defer l2.Close()
+	if _, err := l2.NewCallback("u1", "https://127.0.0.1/abc", "", ""); err != ErrHostNotAllowed {
+		t.Error("Check empty callback hosts failed:", err)
+	}
+}
+
+func TestCallbackURL(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", Endpoint: "http://node", CallbackHosts: []string{"127.0.0.1"}})
+	defer l.Close()
+	cb, _ := l.NewCallback("u1", "https://127.0.0.1/abc", "", "ok")
+	link := l.MakeCallbackURL(cb)
+	if !strings.HasPrefix(link, "http://node/v1/callback/") {
+		t.Fatal("Check callback url failed:", link)
+	}
+	token := strings.TrimPrefix(link, "http://node/v1/callback/")
+	if res, err := l.ParseCallback(token); err != nil || res.URL != cb.URL || res.Body != "ok" {
+		t.Error("Parse callback failed:", err)
+	}
+	if _, err := l.ParseCallback(token + "a"); err != ErrInvalidCallback {
+		t.Error("Check callback sign failed:", err)
+	}
+	if _, err := l.ParseCallback("abc"); err != ErrInvalidCallback {
+		t.Error("Check callback format failed:", err)
+	}
+	l.callbackHosts = nil
+	if _, err := l.ParseCallback(token); err != ErrHostNotAllowed {
+		t.Error("Check callback host removed failed:", err)
+	}
+	cb.Expires = 1
+	if _, err := l.ParseCallback(strings.TrimPrefix(l.MakeCallbackURL(cb), "http://node/v1/callback/")); err != ErrInvalidCallback {
+		t.Error("Check callback expires failed:", err)
+	}
+	l.callbackHosts = []string{"127.0.0.1"}
+	cb, _ = l.NewCallback("", "https://127.0.0.1/abc", "", "ok")
+	if _, err := l.ParseCallback(strings.TrimPrefix(l.MakeCallbackURL(cb), "http://node/v1/callback/")); err != ErrInvalidCallback {
+		t.Error("Check callback without user failed:", err)
+	}
+}
+
+func TestDoneCallback(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", CallbackHosts: []string{"127.0.0.1"}})
+	defer l.Close()
+	cb1, _ := l.NewCallback("u1", "https://127.0.0.1/abc", "", "")
+	cb2, _ := l.NewCallback("u1", "https://127.0.0.1/abc", "", "")
+	if cb1.ID == cb2.ID {
+		t.Fatal("Check callback id failed")
+	}
+	if err := l.DoneCallback(cb1); err != nil {
+		t.Error("Done callback failed:", err)
+	}
+	if err := l.DoneCallback(cb1); err != ErrCallbackDone {
+		t.Error("Check callback done twice failed:", err)
+	}
+	if err := l.DoneCallback(cb2); err != nil {
+		t.Error("Done other callback failed:", err)
+	}
+}
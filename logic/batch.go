@@ -0,0 +1,64 @@
+package logic
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/chanify/chanify/model"
+)
+
+// batch window limits
+const (
+	BatchMinWindow = 30 * time.Second
+	BatchMaxWindow = 24 * time.Hour
+)
+
+// ParseBatchWindow parse batch window from duration (e.g. 5m) or seconds, 0 if disabled
+func ParseBatchWindow(batch string) time.Duration {
+	return parseDuration(batch, BatchMinWindow, BatchMaxWindow)
+}
+
+func loadBatches(opts []map[string]interface{}) map[string]time.Duration {
+	batches := map[string]time.Duration{}
+	for _, item := range opts {
+		channel, ok := readOptString(item, "channel")
+		if !ok || len(channel) <= 0 {
+			continue
+		}
+		var window time.Duration
+		switch val := item["window"].(type) {
+		case string:
+			window = ParseBatchWindow(val)
+		case int:
+			window = ParseBatchWindow(strconv.Itoa(val))
+		}
+		if window <= 0 {
+			log.Println("Invalid batch window for channel:", channel)
+			continue
+		}
+		batches[channel] = window
+		log.Println("Batch channel:", channel, window)
+	}
+	return batches
+}
+
+// GetChannelBatch return batch window of user channel, 0 if disabled
+func (l *Logic) GetChannelBatch(channel string) time.Duration {
+	if len(channel) <= 0 {
+		return 0
+	}
+	return l.batches[channel]
+}
+
+// BatchMessage hold message until the end of batch window, group by token if perToken else by channel
+func (l *Logic) BatchMessage(uid string, perToken bool, window time.Duration, msg *model.Message) (time.Time, error) {
+	mode := HeldModeBatchChannel
+	if perToken {
+		mode = HeldModeBatchToken
+	}
+	now := time.Now().Unix()
+	w := int64(window / time.Second)
+	deliver := (now/w + 1) * w
+	return time.Unix(deliver, 0), l.db.HoldMessage(model.NewHeldMessage(uid, mode, deliver, msg))
+}
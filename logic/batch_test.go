@@ -0,0 +1,60 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chanify/chanify/model"
+)
+
+func TestParseBatchWindow(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":      0,
+		"0":     0,
+		"-5m":   0,
+		"abc":   0,
+		"300":   5 * time.Minute,
+		" 5m ":  5 * time.Minute,
+		"1s":    BatchMinWindow,
+		"1":     BatchMinWindow,
+		"48h":   BatchMaxWindow,
+		"1h30m": 90 * time.Minute,
+	}
+	for batch, window := range tests {
+		if w := ParseBatchWindow(batch); w != window {
+			t.Error("Check parse batch window failed:", batch, w)
+		}
+	}
+}
+
+func TestChannelBatch(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", Batches: []map[string]interface{}{
+		{"channel": "logs", "window": "5m"},
+		{"channel": "ci", "window": 60},
+		{"channel": "bad", "window": "x"},
+		{"window": "5m"},
+	}})
+	defer l.Close()
+	tests := map[string]time.Duration{
+		"":     0,
+		"logs": 5 * time.Minute,
+		"ci":   time.Minute,
+		"bad":  0,
+		"none": 0,
+	}
+	for channel, window := range tests {
+		if w := l.GetChannelBatch(channel); w != window {
+			t.Error("Check channel batch failed:", channel, w)
+		}
+	}
+	msg := model.NewMessage(&model.Token{}).TextContent("hello", "", "", "")
+	deliver, err := l.BatchMessage("abc", true, time.Minute, msg)
+	if err != nil || deliver.Unix()%60 != 0 || deliver.Before(time.Now()) || deliver.After(time.Now().Add(time.Minute)) {
+		t.Fatal("Batch message failed:", deliver, err)
+	}
+	l.BatchMessage("abc", false, time.Minute, msg) // nolint: errcheck
+	held, _ := l.PopHeldMessages(deliver, 10)
+	if len(held) != 2 || held[0].Mode != HeldModeBatchToken || held[1].Mode != HeldModeBatchChannel {
+		t.Error("Check batch messages failed:", len(held))
+	}
+}
@@ -0,0 +1,86 @@
+package logic
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/chanify/chanify/model"
+)
+
+const (
+	channelMaxCount = 100
+	channelMaxName  = 64
+	channelMaxIcon  = 1024
+	channelMaxSound = 255
+)
+
+// error define for user channels
+var (
+	ErrInvalidChannel  = errors.New("invalid channel")
+	ErrTooManyChannels = errors.New("too many channels")
+)
+
+// ValidateChannel check user channel options
+func ValidateChannel(ch *model.Channel) error {
+	ch.Name = strings.TrimSpace(ch.Name)
+	if len(ch.Name) <= 0 || len(ch.Name) > channelMaxName || len(ch.Icon) > channelMaxIcon || len(ch.Sound) > channelMaxSound {
+		return ErrInvalidChannel
+	}
+	if ch.Priority < 0 {
+		return ErrInvalidChannel
+	}
+	if ch.TTL != nil && (*ch.TTL < 0 || time.Duration(*ch.TTL)*time.Second > TTLMax) {
+		return ErrInvalidChannel
+	}
+	switch ch.InterruptionLevel {
+	case "", "active", "passive", "time-sensitive":
+	default:
+		return ErrInvalidChannel
+	}
+	return nil
+}
+
+// GetChannels return user channels
+func (l *Logic) GetChannels(uid string) ([]*model.Channel, error) {
+	return l.db.GetChannels(uid)
+}
+
+// GetChannel return user channel with name, nil if not found
+func (l *Logic) GetChannel(uid string, name string) (*model.Channel, error) {
+	if len(name) <= 0 {
+		return nil, nil
+	}
+	ch, err := l.db.GetChannel(uid, name)
+	if err == sql.ErrNoRows || err == model.ErrNotImplemented {
+		return nil, nil
+	}
+	return ch, err
+}
+
+// UpsertChannel create or update user channel
+func (l *Logic) UpsertChannel(uid string, ch *model.Channel) error {
+	if err := ValidateChannel(ch); err != nil {
+		return err
+	}
+	old, err := l.GetChannel(uid, ch.Name)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		chs, err := l.db.GetChannels(uid)
+		if err != nil {
+			return err
+		}
+		if len(chs) >= channelMaxCount {
+			return ErrTooManyChannels
+		}
+	}
+	return l.db.UpsertChannel(uid, ch)
+}
+
+// DeleteChannel remove user channel
+func (l *Logic) DeleteChannel(uid string, name string) error {
+	return l.db.DeleteChannel(uid, name)
+}
@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/chanify/chanify/model"
+)
+
+func TestValidateChannel(t *testing.T) {
+	zero, day, year, negative := 0, 86400, 365*86400, -1
+	valids := []*model.Channel{
+		{Name: "logs"},
+		{Name: " alerts ", Sound: "bell", Priority: 10, InterruptionLevel: "time-sensitive"},
+		{Name: "disk", TTL: &zero},
+		{Name: "cpu", TTL: &day},
+	}
+	for _, ch := range valids {
+		if err := ValidateChannel(ch); err != nil {
+			t.Error("Validate channel failed:", ch, err)
+		}
+	}
+	invalids := []*model.Channel{
+		{Name: ""},
+		{Name: "  "},
+		{Name: strings.Repeat("a", channelMaxName+1)},
+		{Name: "logs", Icon: strings.Repeat("a", channelMaxIcon+1)},
+		{Name: "logs", Sound: strings.Repeat("a", channelMaxSound+1)},
+		{Name: "logs", Priority: -1},
+		{Name: "logs", InterruptionLevel: "critical"},
+		{Name: "logs", TTL: &negative},
+		{Name: "logs", TTL: &year},
+	}
+	for _, ch := range invalids {
+		if err := ValidateChannel(ch); err != ErrInvalidChannel {
+			t.Error("Check invalid channel failed:", ch.Name, err)
+		}
+	}
+}
+
+func TestChannels(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory"})
+	defer l.Close()
+	if ch, err := l.GetChannel("abc", "logs"); ch != nil || err != nil {
+		t.Fatal("Check not found channel failed:", ch, err)
+	}
+	if ch, err := l.GetChannel("abc", ""); ch != nil || err != nil {
+		t.Fatal("Check empty channel failed:", ch, err)
+	}
+	if err := l.UpsertChannel("abc", &model.Channel{Name: "logs", Priority: 5}); err != nil {
+		t.Fatal("Upsert channel failed:", err)
+	}
+	if ch, err := l.GetChannel("abc", "logs"); err != nil || ch.Priority != 5 {
+		t.Fatal("Get channel failed:", ch, err)
+	}
+	if err := l.UpsertChannel("abc", &model.Channel{Name: "logs", InterruptionLevel: "x"}); err != ErrInvalidChannel {
+		t.Fatal("Check upsert invalid channel failed:", err)
+	}
+	for i := 1; i < channelMaxCount; i++ {
+		l.UpsertChannel("abc", &model.Channel{Name: fmt.Sprintf("ch%d", i)}) // nolint: errcheck
+	}
+	if err := l.UpsertChannel("abc", &model.Channel{Name: "more"}); err != ErrTooManyChannels {
+		t.Fatal("Check too many channels failed:", err)
+	}
+	if err := l.UpsertChannel("abc", &model.Channel{Name: "logs", Priority: 10}); err != nil {
+		t.Fatal("Update channel at limit failed:", err)
+	}
+	if err := l.DeleteChannel("abc", "logs"); err != nil {
+		t.Fatal("Delete channel failed:", err)
+	}
+	if chs, err := l.GetChannels("abc"); err != nil || len(chs) != channelMaxCount-1 {
+		t.Fatal("Get channels failed:", len(chs), err)
+	}
+
+	ls, _ := NewLogic(&Options{Secret: "123"})
+	defer ls.Close()
+	if ch, err := ls.GetChannel("abc", "logs"); ch != nil || err != nil {
+		t.Fatal("Check serverless channel failed:", ch, err)
+	}
+}
@@ -0,0 +1,253 @@
+package logic
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+)
+
+// do-not-disturb actions
+const (
+	DNDNone    = ""
+	DNDPassive = "passive"
+	DNDHold    = "hold"
+	DNDDigest  = "digest"
+)
+
+// held message modes
+const (
+	HeldModeHold         = 1
+	HeldModeDigest       = 2
+	HeldModeBatchToken   = 3
+	HeldModeBatchChannel = 4
+	HeldModeRepeat       = 5 // data is request uid of ack record
+)
+
+const (
+	dndMaxSchedules = 16
+	dndMaxSize      = 4096
+)
+
+// ErrInvalidDND is returned when do-not-disturb policy is invalid
+var ErrInvalidDND = errors.New("invalid dnd policy")
+
+var dndWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// DNDSchedule is quiet hours from start to end (HH:MM) on days, window ends next day if end is not after start
+type DNDSchedule struct {
+	Days  string `json:"days,omitempty"` // e.g. "mon-fri,sun", empty for every day
+	Start string `json:"start"`
+	End   string `json:"end"`
+
+	days  [7]bool
+	start int
+	end   int
+}
+
+// DNDPolicy is per user do-not-disturb policy
+type DNDPolicy struct {
+	Timezone      string         `json:"timezone,omitempty"`
+	Schedules     []*DNDSchedule `json:"schedules"`
+	MinPriority   int            `json:"min-priority,omitempty"`   // messages with priority not less than it break through
+	Action        string         `json:"action,omitempty"`         // passive (default), hold or digest
+	TimeSensitive bool           `json:"time-sensitive,omitempty"` // time-sensitive messages are not held or digested
+
+	loc *time.Location
+}
+
+// ParseDNDPolicy parse and validate do-not-disturb policy from json
+func ParseDNDPolicy(data []byte) (*DNDPolicy, error) {
+	if len(data) > dndMaxSize {
+		return nil, ErrInvalidDND
+	}
+	p := &DNDPolicy{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDND, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate policy and prepare schedules
+func (p *DNDPolicy) Validate() error {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timezone %s", ErrInvalidDND, p.Timezone)
+	}
+	p.loc = loc
+	switch p.Action {
+	case DNDNone:
+		p.Action = DNDPassive
+	case DNDPassive, DNDHold, DNDDigest:
+	default:
+		return fmt.Errorf("%w: invalid action %s", ErrInvalidDND, p.Action)
+	}
+	if len(p.Schedules) <= 0 || len(p.Schedules) > dndMaxSchedules {
+		return fmt.Errorf("%w: 1 to %d schedules required", ErrInvalidDND, dndMaxSchedules)
+	}
+	for _, s := range p.Schedules {
+		if s == nil {
+			return fmt.Errorf("%w: invalid schedule", ErrInvalidDND)
+		}
+		if err := s.parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DNDSchedule) parse() error {
+	var err error
+	if s.start, err = parseDNDClock(s.Start); err != nil {
+		return err
+	}
+	if s.end, err = parseDNDClock(s.End); err != nil {
+		return err
+	}
+	days := strings.ToLower(strings.ReplaceAll(s.Days, " ", ""))
+	if len(days) <= 0 || days == "*" {
+		for i := range s.days {
+			s.days[i] = true
+		}
+		return nil
+	}
+	for _, item := range strings.Split(days, ",") {
+		from, to := item, item
+		if idx := strings.Index(item, "-"); idx >= 0 {
+			from, to = item[:idx], item[idx+1:]
+		}
+		fd, ok1 := parseDNDWeekday(from)
+		td, ok2 := parseDNDWeekday(to)
+		if !ok1 || !ok2 {
+			return fmt.Errorf("%w: invalid days %s", ErrInvalidDND, s.Days)
+		}
+		for d := fd; ; d = (d + 1) % 7 {
+			s.days[d] = true
+			if d == td {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseDNDWeekday(day string) (time.Weekday, bool) {
+	if len(day) < 3 {
+		return 0, false
+	}
+	d, ok := dndWeekdays[day[:3]]
+	return d, ok
+}
+
+func parseDNDClock(clock string) (int, error) {
+	items := strings.Split(clock, ":")
+	if len(items) == 2 {
+		h, err1 := strconv.Atoi(items[0])
+		m, err2 := strconv.Atoi(items[1])
+		if err1 == nil && err2 == nil && h >= 0 && h < 24 && m >= 0 && m < 60 {
+			return h*60 + m, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: invalid time %s", ErrInvalidDND, clock)
+}
+
+// QuietUntil return the end of current quiet hours, false if now is not in quiet hours
+func (p *DNDPolicy) QuietUntil(now time.Time) (time.Time, bool) {
+	loc := p.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	t := now.In(loc)
+	var until time.Time
+	for _, s := range p.Schedules {
+		for _, offset := range []int{0, -1} {
+			day := t.AddDate(0, 0, offset)
+			if !s.days[day.Weekday()] {
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), s.start/60, s.start%60, 0, 0, loc)
+			duration := s.end - s.start
+			if duration <= 0 {
+				duration += 24 * 60
+			}
+			end := start.Add(time.Duration(duration) * time.Minute)
+			if !t.Before(start) && t.Before(end) && end.After(until) {
+				until = end
+			}
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// Check message with policy, return action and the end of quiet hours, critical alerts always break through
+func (p *DNDPolicy) Check(msg *model.Message, now time.Time) (string, time.Time) {
+	until, ok := p.QuietUntil(now)
+	if !ok || msg.IsCritical() {
+		return DNDNone, until
+	}
+	if p.MinPriority > 0 && int(msg.Priority) >= p.MinPriority {
+		return DNDNone, until
+	}
+	if p.TimeSensitive && p.Action != DNDPassive && msg.InterruptionLevel == pb.InterruptionLevel_IlTimeSensitive {
+		return DNDNone, until
+	}
+	return p.Action, until
+}
+
+// GetUserDND return do-not-disturb policy of user, nil if not set
+func (l *Logic) GetUserDND(uid string) (*DNDPolicy, error) {
+	data, err := l.db.GetUserDND(uid)
+	if err == sql.ErrNoRows || err == model.ErrNotImplemented {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseDNDPolicy(data)
+}
+
+// SetUserDND update do-not-disturb policy of user, nil to clear
+func (l *Logic) SetUserDND(uid string, p *DNDPolicy) error {
+	if p == nil {
+		return l.db.SetUserDND(uid, nil)
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(p)
+	if len(data) > dndMaxSize {
+		return ErrInvalidDND
+	}
+	return l.db.SetUserDND(uid, data)
+}
+
+// HoldMessage hold message until deliver time, action is hold or digest
+func (l *Logic) HoldMessage(uid string, action string, deliver time.Time, msg *model.Message) error {
+	mode := HeldModeHold
+	if action == DNDDigest {
+		mode = HeldModeDigest
+	}
+	return l.db.HoldMessage(model.NewHeldMessage(uid, mode, deliver.Unix(), msg))
+}
+
+// PopHeldMessages take out held messages which should be delivered before now
+func (l *Logic) PopHeldMessages(now time.Time, limit int) ([]*model.HeldMessage, error) {
+	return l.db.PopHeldMessages(now.Unix(), limit)
+}
@@ -0,0 +1,183 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chanify/chanify/model"
+)
+
+func TestParseDNDPolicy(t *testing.T) {
+	p, err := ParseDNDPolicy([]byte(`{"timezone":"Asia/Shanghai","schedules":[{"days":"Mon-Fri","start":"22:00","end":"07:00"}]}`))
+	if err != nil || p.Action != DNDPassive || p.loc == nil {
+		t.Fatal("Parse dnd policy failed:", err)
+	}
+	s := p.Schedules[0]
+	if s.start != 22*60 || s.end != 7*60 || s.days[time.Sunday] || !s.days[time.Monday] || !s.days[time.Friday] || s.days[time.Saturday] {
+		t.Error("Check dnd schedule failed:", s)
+	}
+	invalids := []string{
+		`{`,
+		`{"schedules":[]}`,
+		`{"timezone":"Mars/Base","schedules":[{"start":"22:00","end":"07:00"}]}`,
+		`{"action":"mute","schedules":[{"start":"22:00","end":"07:00"}]}`,
+		`{"schedules":[null]}`,
+		`{"schedules":[{"start":"24:00","end":"07:00"}]}`,
+		`{"schedules":[{"start":"22:00","end":"7"}]}`,
+		`{"schedules":[{"days":"mo","start":"22:00","end":"07:00"}]}`,
+		`{"schedules":[{"days":"mon-xyz","start":"22:00","end":"07:00"}]}`,
+		`{"schedules":[{"start":"22:00","end":"07:00"}],"timezone":"` + string(make([]byte, dndMaxSize)) + `"}`,
+	}
+	for _, data := range invalids {
+		if _, err := ParseDNDPolicy([]byte(data)); err == nil {
+			t.Error("Check invalid dnd policy failed:", data)
+		}
+	}
+}
+
+func TestDNDScheduleDays(t *testing.T) {
+	tests := map[string][]time.Weekday{
+		"":              {time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+		"*":             {time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+		"sat, sunday":   {time.Saturday, time.Sunday},
+		"fri-mon":       {time.Friday, time.Saturday, time.Sunday, time.Monday},
+		"tue,thu-thu":   {time.Tuesday, time.Thursday},
+		"Wednesday-wed": {time.Wednesday},
+	}
+	for days, expect := range tests {
+		s := &DNDSchedule{Days: days, Start: "00:00", End: "01:00"}
+		if err := s.parse(); err != nil {
+			t.Fatal("Parse dnd days failed:", days, err)
+		}
+		cnt := 0
+		for _, d := range s.days {
+			if d {
+				cnt++
+			}
+		}
+		for _, d := range expect {
+			if !s.days[d] {
+				t.Error("Check dnd days failed:", days, d)
+			}
+		}
+		if cnt != len(expect) {
+			t.Error("Check dnd days count failed:", days, cnt)
+		}
+	}
+}
+
+func TestDNDQuietUntil(t *testing.T) {
+	p, _ := ParseDNDPolicy([]byte(`{"timezone":"UTC","schedules":[{"days":"mon-fri","start":"22:00","end":"07:00"},{"days":"sat","start":"12:00","end":"14:00"},{"days":"sun","start":"09:00","end":"09:00"}]}`))
+	day := func(d int, h int, m int) time.Time {
+		// 2021-05-03 is Monday
+		return time.Date(2021, 5, 3+d, h, m, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		now   time.Time
+		until time.Time
+		quiet bool
+	}{
+		{day(0, 21, 59), time.Time{}, false},
+		{day(0, 22, 0), day(1, 7, 0), true},
+		{day(1, 6, 59), day(1, 7, 0), true},
+		{day(1, 7, 0), time.Time{}, false},
+		{day(0, 6, 0), day(0, 9, 0), true}, // full day window from sunday 09:00
+		{day(0, 9, 0), time.Time{}, false},
+		{day(4, 23, 0), day(5, 7, 0), true},
+		{day(5, 6, 0), day(5, 7, 0), true},
+		{day(5, 13, 0), day(5, 14, 0), true},
+		{day(5, 23, 0), time.Time{}, false},
+		{day(6, 9, 0), day(7, 9, 0), true},
+		{day(7, 8, 0), day(7, 9, 0), true},
+	}
+	for _, tc := range tests {
+		until, quiet := p.QuietUntil(tc.now)
+		if quiet != tc.quiet || !until.Equal(tc.until) {
+			t.Error("Check quiet until failed:", tc.now, until, quiet)
+		}
+	}
+	// 22:00 in Shanghai is 14:00 UTC
+	p, _ = ParseDNDPolicy([]byte(`{"timezone":"Asia/Shanghai","schedules":[{"start":"22:00","end":"07:00"}]}`))
+	if until, quiet := p.QuietUntil(day(0, 14, 30)); !quiet || !until.Equal(day(0, 23, 0)) {
+		t.Error("Check quiet until timezone failed:", until, quiet)
+	}
+	if _, quiet := (&DNDPolicy{Schedules: []*DNDSchedule{{}}}).QuietUntil(day(0, 0, 0)); quiet {
+		t.Error("Check unparsed dnd policy failed")
+	}
+}
+
+func TestDNDCheck(t *testing.T) {
+	now := time.Date(2021, 5, 3, 23, 0, 0, 0, time.UTC)
+	p, _ := ParseDNDPolicy([]byte(`{"action":"hold","min-priority":10,"time-sensitive":true,"schedules":[{"start":"22:00","end":"07:00"}]}`))
+	msg := model.NewMessage(&model.Token{}).TextContent("hello", "", "", "")
+	if action, until := p.Check(msg, now); action != DNDHold || until.Hour() != 7 {
+		t.Error("Check dnd hold failed:", action, until)
+	}
+	if action, _ := p.Check(msg, now.Add(-2*time.Hour)); action != DNDNone {
+		t.Error("Check dnd outside quiet hours failed:", action)
+	}
+	if action, _ := p.Check(msg.SetPriority(10), now); action != DNDNone {
+		t.Error("Check dnd min priority failed:", action)
+	}
+	msg = model.NewMessage(&model.Token{}).SetInterruptionLevel("time-sensitive")
+	if action, _ := p.Check(msg, now); action != DNDNone {
+		t.Error("Check dnd time sensitive failed:", action)
+	}
+	p.Action = DNDPassive
+	if action, _ := p.Check(msg, now); action != DNDPassive {
+		t.Error("Check dnd time sensitive passive failed:", action)
+	}
+	p.Action = DNDHold
+	p.TimeSensitive = false
+	if action, _ := p.Check(msg, now); action != DNDHold {
+		t.Error("Check dnd time sensitive not allowed failed:", action)
+	}
+	msg = model.NewMessage(&model.Token{}).SetCritical(true, 0)
+	if action, _ := p.Check(msg, now); action != DNDNone {
+		t.Error("Check dnd critical alert failed:", action)
+	}
+}
+
+func TestUserDND(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory"})
+	defer l.Close()
+	if p, err := l.GetUserDND("abc"); p != nil || err != nil {
+		t.Fatal("Check empty user dnd failed:", err)
+	}
+	p := &DNDPolicy{Action: DNDDigest, Schedules: []*DNDSchedule{{Start: "22:00", End: "07:00"}}}
+	if err := l.SetUserDND("abc", p); err != nil {
+		t.Fatal("Set user dnd failed:", err)
+	}
+	if p, err := l.GetUserDND("abc"); err != nil || p.Action != DNDDigest || p.Schedules[0].end != 7*60 {
+		t.Fatal("Get user dnd failed:", err)
+	}
+	if err := l.SetUserDND("abc", &DNDPolicy{}); err == nil {
+		t.Error("Check set invalid user dnd failed")
+	}
+	if err := l.SetUserDND("abc", nil); err != nil {
+		t.Error("Clear user dnd failed:", err)
+	}
+	if p, err := l.GetUserDND("abc"); p != nil || err != nil {
+		t.Error("Check clear user dnd failed:", err)
+	}
+	l.db.SetUserDND("abc", []byte("{")) // nolint: errcheck
+	if _, err := l.GetUserDND("abc"); err == nil {
+		t.Error("Check invalid stored user dnd failed")
+	}
+
+	now := time.Now()
+	msg := model.NewMessage(&model.Token{}).TextContent("hello", "", "", "")
+	l.HoldMessage("abc", DNDHold, now, msg)                // nolint: errcheck
+	l.HoldMessage("abc", DNDDigest, now, msg)              // nolint: errcheck
+	l.HoldMessage("abc", DNDHold, now.Add(time.Hour), msg) // nolint: errcheck
+	held, err := l.PopHeldMessages(now, 10)
+	if err != nil || len(held) != 2 || held[0].Mode != HeldModeHold || held[1].Mode != HeldModeDigest {
+		t.Error("Check held messages failed:", err, held)
+	}
+
+	ls, _ := NewLogic(&Options{DBUrl: "nosql://?secret=123"})
+	defer ls.Close()
+	if p, err := ls.GetUserDND("abc"); p != nil || err != nil {
+		t.Error("Check nosql user dnd failed:", err)
+	}
+}
@@ -0,0 +1,201 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	lua "github.com/yuin/gopher-lua"
+	"google.golang.org/protobuf/proto"
+)
+
+// FilterMessage is outgoing message passed through message filters before encryption
+type FilterMessage struct {
+	UserID            string // read only
+	Type              string // read only, e.g. Text, Link, Timeline
+	Channel           string // user channel name, change to reroute message
+	Title             string
+	Text              string
+	Markdown          string
+	Sound             string
+	Priority          int
+	InterruptionLevel string
+	Drop              bool // set to drop message
+}
+
+// MessageFilter rewrite or drop outgoing message, error rejects the message
+type MessageFilter interface {
+	FilterMessage(msg *FilterMessage) error
+}
+
+// MessageFilterFunc is an adapter to use function as message filter
+type MessageFilterFunc func(msg *FilterMessage) error
+
+// FilterMessage calls f(msg)
+func (f MessageFilterFunc) FilterMessage(msg *FilterMessage) error {
+	return f(msg)
+}
+
+var (
+	messageFilterMutex sync.RWMutex
+	messageFilters     = map[string]MessageFilter{}
+)
+
+// RegisterMessageFilter register native message filter with name, use `handler: <name>` in filter config
+func RegisterMessageFilter(name string, filter MessageFilter) {
+	messageFilterMutex.Lock()
+	defer messageFilterMutex.Unlock()
+	messageFilters[strings.ToLower(name)] = filter
+}
+
+func getMessageFilter(name string) (MessageFilter, bool) {
+	messageFilterMutex.RLock()
+	defer messageFilterMutex.RUnlock()
+	filter, ok := messageFilters[strings.ToLower(name)]
+	return filter, ok
+}
+
+type messageFilter struct {
+	name    string
+	handler MessageFilter
+	webhook *Webhook
+}
+
+// loadFilters load message filters in config order
+func (p *pluginManager) loadFilters(path string, ftOpts []map[string]interface{}) {
+	for _, opts := range ftOpts {
+		name, ok := readOptString(opts, "name")
+		if !ok || len(name) <= 0 {
+			continue
+		}
+		filter := &messageFilter{name: name}
+		if hname, ok := readOptString(opts, "handler"); ok && len(hname) > 0 {
+			handler, ok := getMessageFilter(hname)
+			if !ok {
+				log.Println("Message filter handler not found:", hname)
+				continue
+			}
+			filter.handler = handler
+		} else if file, ok := readOptString(opts, "file"); ok && len(file) > 0 {
+			webhook := newWebhook(name, opts)
+			if !p.loadLua(webhook, file, path, opts, "filter.") {
+				continue
+			}
+			filter.webhook = webhook
+		} else {
+			continue
+		}
+		p.filters = append(p.filters, filter)
+		log.Println("Load message filter:", name)
+	}
+}
+
+// FilterMessage run filter with message
+func (f *messageFilter) FilterMessage(msg *FilterMessage) error {
+	if f.handler != nil {
+		return f.handler.FilterMessage(msg)
+	}
+	w := f.webhook
+	return w.Call(context.Background(), func(l *lua.LState) error {
+		mt := l.NewTypeMetatable("filter_ctx")
+		if _, ok := l.GetField(mt, "__index").(*lua.LTable); !ok {
+			l.SetField(mt, "__index", l.NewTable())
+		}
+		ctx := l.NewUserData()
+		ctx.Metatable = mt
+		l.SetGlobal("ctx", ctx)
+		tbl := l.NewTable()
+		tbl.RawSetString("user", lua.LString(msg.UserID))
+		tbl.RawSetString("type", lua.LString(msg.Type))
+		tbl.RawSetString("channel", lua.LString(msg.Channel))
+		tbl.RawSetString("title", lua.LString(msg.Title))
+		tbl.RawSetString("text", lua.LString(msg.Text))
+		tbl.RawSetString("markdown", lua.LString(msg.Markdown))
+		tbl.RawSetString("sound", lua.LString(msg.Sound))
+		tbl.RawSetString("priority", lua.LNumber(msg.Priority))
+		tbl.RawSetString("interruption_level", lua.LString(msg.InterruptionLevel))
+		l.SetGlobal("msg", tbl)
+		if err := w.DoCall(l); err != nil {
+			return err
+		}
+		if l.GetTop() > 0 && l.Get(1) == lua.LFalse {
+			msg.Drop = true
+			return nil
+		}
+		msg.Channel = lua.LVAsString(tbl.RawGetString("channel"))
+		msg.Title = lua.LVAsString(tbl.RawGetString("title"))
+		msg.Text = lua.LVAsString(tbl.RawGetString("text"))
+		msg.Markdown = lua.LVAsString(tbl.RawGetString("markdown"))
+		msg.Sound = lua.LVAsString(tbl.RawGetString("sound"))
+		msg.Priority = int(lua.LVAsNumber(tbl.RawGetString("priority")))
+		msg.InterruptionLevel = lua.LVAsString(tbl.RawGetString("interruption_level"))
+		return nil
+	})
+}
+
+// FilterMessage pass message through all message filters, return true if message is dropped
+func (l *Logic) FilterMessage(tk *model.Token, msg *model.Message) (bool, error) {
+	if l.webhookManger == nil || len(l.webhookManger.filters) <= 0 {
+		return false, nil
+	}
+	var content pb.MsgContent
+	if err := proto.Unmarshal(msg.Content, &content); err != nil {
+		return false, err
+	}
+	fm := &FilterMessage{
+		UserID:            tk.GetUserID(),
+		Type:              content.Type.String(),
+		Channel:           msg.ChannelName(),
+		Title:             content.Title,
+		Text:              content.Text,
+		Markdown:          content.Markdown,
+		Sound:             msg.Sound.GetName(),
+		Priority:          int(msg.Priority),
+		InterruptionLevel: msg.InterruptionLevelName(),
+	}
+	title, text, isFile := msg.TextFile()
+	if isFile {
+		fm.Title, fm.Text = title, text
+	}
+	orig := *fm
+	for _, f := range l.webhookManger.filters {
+		if err := f.FilterMessage(fm); err != nil {
+			return false, fmt.Errorf("message filter %s: %w", f.name, err)
+		}
+		if fm.Drop {
+			return true, nil
+		}
+	}
+	if isFile && (fm.Title != orig.Title || fm.Text != orig.Text) {
+		msg.SetTextFile(fm.Title, fm.Text)
+	} else if fm.Title != orig.Title || fm.Text != orig.Text || fm.Markdown != orig.Markdown {
+		content.Title = fm.Title
+		content.Text = fm.Text
+		content.Markdown = fm.Markdown
+		msg.Content, _ = proto.Marshal(&content)
+	}
+	if fm.Channel != orig.Channel {
+		msg.SetChannelName(fm.Channel)
+	}
+	if fm.Sound != orig.Sound {
+		old := msg.Sound
+		msg.DropSound().SoundName(fm.Sound)
+		if old != nil && msg.Sound != nil {
+			msg.Sound.Type = old.Type
+			msg.Sound.Volume = old.Volume
+		}
+	}
+	if fm.Priority != orig.Priority {
+		msg.Priority = 0
+		msg.SetPriority(fm.Priority)
+	}
+	if fm.InterruptionLevel != orig.InterruptionLevel {
+		msg.InterruptionLevel = pb.InterruptionLevel_IlActive
+		msg.SetInterruptionLevel(fm.InterruptionLevel)
+	}
+	return false, nil
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMessageFilter(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "redact.lua"), []byte(`
+msg.text = string.gsub(msg.text, "secret=%w+", "secret=***")
+if string.find(msg.text, "PROD DOWN") then
+	msg.priority = 10
+	msg.interruption_level = "time-sensitive"
+	msg.sound = "alarm"
+	msg.channel = ctx:env("channel")
+end
+if msg.title == "drop" then
+	return false
+end
+`), 0644) // nolint: errcheck
+	os.WriteFile(filepath.Join(dir, "error.lua"), []byte(`if msg.title == "error" then error("boom") end`), 0644) // nolint: errcheck
+	RegisterMessageFilter("test-title", MessageFilterFunc(func(msg *FilterMessage) error {
+		if msg.Title == "native" {
+			msg.Title = "[" + msg.UserID + "] " + msg.Type
+		}
+		return nil
+	}))
+	l, err := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", PluginPath: dir, Filters: []map[string]interface{}{
+		{"name": "redact", "file": "redact.lua", "env": map[string]interface{}{"channel": "ops"}},
+		{"name": "error", "file": "error.lua"},
+		{"name": "title", "handler": "test-title"},
+		{"name": "none", "handler": "not-found"},
+		{"name": "missing", "file": "not-found.lua"},
+		{"name": "empty"},
+		{"file": "error.lua"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if len(l.webhookManger.filters) != 3 {
+		t.Fatal("Check load message filters failed:", len(l.webhookManger.filters))
+	}
+	tk := &model.Token{}
+	msg := model.NewMessage(tk).TextContent("PROD DOWN secret=abc", "native", "", "")
+	if drop, err := l.FilterMessage(tk, msg); err != nil || drop {
+		t.Fatal("Check filter message failed:", err, drop)
+	}
+	var content pb.MsgContent
+	proto.Unmarshal(msg.Content, &content) // nolint: errcheck
+	if content.Text != "PROD DOWN secret=***" || content.Title != "[] Text" {
+		t.Error("Check filter message content failed:", content.Text, content.Title)
+	}
+	if msg.Priority != 10 || msg.Sound.GetName() != "alarm" || msg.InterruptionLevel != pb.InterruptionLevel_IlTimeSensitive || msg.ChannelName() != "ops" {
+		t.Error("Check filter message options failed:", msg.Priority, msg.Sound, msg.InterruptionLevel, msg.ChannelName())
+	}
+	msg = model.NewMessage(tk).TextContent("hello", "drop", "", "")
+	if drop, err := l.FilterMessage(tk, msg); err != nil || !drop {
+		t.Error("Check filter drop message failed:", err, drop)
+	}
+	msg = model.NewMessage(tk).TextContent("hello", "error", "", "")
+	if _, err := l.FilterMessage(tk, msg); err == nil {
+		t.Error("Check filter error message failed")
+	}
+	msg = model.NewMessage(tk).TextContent("hello", "", "", "")
+	orig := append([]byte{}, msg.Content...)
+	for i := 0; i < 3; i++ {
+		if drop, err := l.FilterMessage(tk, msg); err != nil || drop {
+			t.Fatal("Check filter pooled message failed:", err, drop)
+		}
+	}
+	if string(orig) != string(msg.Content) || msg.Sound != nil || msg.Priority != 0 {
+		t.Error("Check filter unchanged message failed")
+	}
+	msg.Content = []byte{0xff}
+	if _, err := l.FilterMessage(tk, msg); err == nil {
+		t.Error("Check filter invalid message failed")
+	}
+}
+
+func TestMessageFilterNone(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory"})
+	defer l.Close()
+	if drop, err := l.FilterMessage(&model.Token{}, &model.Message{}); drop || err != nil {
+		t.Error("Check no message filter failed")
+	}
+	f := &messageFilter{handler: MessageFilterFunc(func(msg *FilterMessage) error { return errors.New("failed") })}
+	if f.FilterMessage(&FilterMessage{}) == nil {
+		t.Error("Check native message filter error failed")
+	}
+}
+
+func TestMessageFilterKeyword(t *testing.T) {
+	l, err := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", PluginPath: filepath.Join("..", "plugin"), Filters: []map[string]interface{}{
+		{"name": "keyword", "file": "filter/keyword.lua", "env": map[string]interface{}{"channel": "ops", "mute": "[skip]"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	tk := &model.Token{}
+	msg := model.NewMessage(tk).TextContent("PROD DOWN token=abc password=123", "CI", "", "")
+	if drop, err := l.FilterMessage(tk, msg); err != nil || drop {
+		t.Fatal("Check keyword filter failed:", err, drop)
+	}
+	var content pb.MsgContent
+	proto.Unmarshal(msg.Content, &content) // nolint: errcheck
+	if content.Text != "PROD DOWN token=*** password=***" || msg.Priority != 10 || msg.ChannelName() != "ops" {
+		t.Error("Check keyword filter message failed:", content.Text, msg.Priority, msg.ChannelName())
+	}
+	msg = model.NewMessage(tk).TextContent("build [skip]", "CI", "", "")
+	if drop, err := l.FilterMessage(tk, msg); err != nil || !drop {
+		t.Error("Check keyword filter drop failed:", err, drop)
+	}
+}
@@ -13,10 +13,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/chanify/chanify/crypto"
 	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
 	"github.com/google/uuid"
 	"github.com/sideshow/apns2"
 	"github.com/sideshow/apns2/payload"
@@ -38,17 +40,23 @@ var (
 
 // Options for init logic
 type Options struct {
-	Name         string
-	Version      string
-	Endpoint     string
-	DataPath     string
-	FilePath     string
-	PluginPath   string
-	DBUrl        string
-	Secret       string
-	Registerable bool
-	RegUsers     []string
-	WebHooks     []map[string]interface{}
+	Name          string
+	Version       string
+	Endpoint      string
+	DataPath      string
+	FilePath      string
+	ImageMaxSize  int
+	PluginPath    string
+	DBUrl         string
+	Secret        string
+	Registerable  bool
+	RegUsers      []string
+	WebHooks      []map[string]interface{}
+	Templates     []map[string]interface{}
+	Filters       []map[string]interface{}
+	Batches       []map[string]interface{}
+	Policy        map[string]interface{}
+	CallbackHosts []string
 }
 
 // Logic instance
@@ -67,7 +75,13 @@ type Logic struct {
 	infoSign      string
 	whitelist     map[string]bool
 	filepath      string
+	imageMaxSize  int
 	webhookManger *pluginManager
+	templates     map[string]*Template
+	batches       map[string]time.Duration
+	policy        *MessagePolicy
+	tokenPolicies map[string]*MessagePolicy
+	callbackHosts []string
 
 	apnsPClient *apns2.Client
 	apnsDClient *apns2.Client
@@ -103,7 +117,7 @@ func NewLogic(opts *Options) (*Logic, error) {
 		Name:         opts.Name,
 		Version:      opts.Version,
 		Endpoint:     opts.Endpoint,
-		Features:     []string{"platform.watchos", "msg.text", "msg.link", "msg.action"},
+		Features:     []string{"platform.watchos", "msg.text", "msg.link", "msg.action", "msg.action.callback"},
 	}
 	if l.registerable {
 		log.Println("Register user enabled")
@@ -139,17 +153,27 @@ func NewLogic(opts *Options) (*Logic, error) {
 			TeamID:  "P4XS4AVCLW",
 		}
 		l.filepath = opts.FilePath
+		l.imageMaxSize = opts.ImageMaxSize
 		l.apnsPClient = apns2.NewTokenClient(tk).Production()
 		l.apnsDClient = apns2.NewTokenClient(tk).Development()
 		if len(l.filepath) > 0 {
-			l.Features = append(l.Features, "msg.image", "msg.audio", "msg.file", "msg.timeline")
+			l.Features = append(l.Features, "msg.image", "msg.audio", "msg.video", "msg.file", "msg.timeline")
 			fixPath(filepath.Join(l.filepath, "images")) // nolint: errcheck
 			fixPath(filepath.Join(l.filepath, "audios")) // nolint: errcheck
+			fixPath(filepath.Join(l.filepath, "videos")) // nolint: errcheck
 			fixPath(filepath.Join(l.filepath, "files"))  // nolint: errcheck
 			log.Println("Files path:", l.filepath)
 		}
 	}
-	l.webhookManger = loadWebhookPlugin(opts.PluginPath, opts.WebHooks)
+	l.webhookManger = loadWebhookPlugin(opts.PluginPath, opts.WebHooks, l.db)
+	l.webhookManger.loadFilters(opts.PluginPath, opts.Filters)
+	l.templates = loadTemplates(opts.Templates)
+	l.batches = loadBatches(opts.Batches)
+	l.policy, l.tokenPolicies = loadPolicies(opts.Policy)
+	for _, h := range opts.CallbackHosts {
+		l.callbackHosts = appendAllowHost(l.callbackHosts, h)
+	}
+	log.Println("Find", len(l.callbackHosts), "callback host(s) in allow list")
 	l.InitInfo()
 	log.Printf("Node server name: %s, version: %s, serverless: %v, node-id: %s\n", l.Name, l.Version, l.srvless, l.NodeID)
 	return l, nil
@@ -172,6 +196,11 @@ func (l *Logic) CanFileStore() bool {
 	return len(l.filepath) > 0
 }
 
+// GetImageMaxSize return max width or height for stored images, 0 for unlimited
+func (l *Logic) GetImageMaxSize() int {
+	return l.imageMaxSize
+}
+
 // GetUser find user info with user id
 func (l *Logic) GetUser(uid string) (*model.User, error) {
 	return l.db.GetUser(uid)
@@ -240,6 +269,11 @@ func (l *Logic) GetDeviceKey(uuid string) ([]byte, error) {
 	return l.db.GetDeviceKey(uuid)
 }
 
+// GetUserDeviceKey return device key with device uuid, error if device is not bound to user
+func (l *Logic) GetUserDeviceKey(uid string, uuid string) ([]byte, error) {
+	return l.db.GetUserDeviceKey(uid, uuid)
+}
+
 // GetDevices return all devices with user id
 func (l *Logic) GetDevices(uid string) ([]*model.Device, error) {
 	return l.db.GetDevices(uid)
@@ -297,24 +331,39 @@ func (l *Logic) SaveFile(tname string, data []byte) (string, error) {
 	return filepath.Join("/files/"+tname, name), nil
 }
 
+// GetTemplate with name
+func (l *Logic) GetTemplate(name string) (*Template, error) {
+	if tpl, ok := l.templates[strings.ToLower(name)]; ok {
+		return tpl, nil
+	}
+	return nil, ErrNotFound
+}
+
 // GetWebhook with name
 func (l *Logic) GetWebhook(name string) (*Webhook, error) {
 	return l.webhookManger.GetWebhook(name)
 }
 
 // SendAPNS send message to APNS
-func (l *Logic) SendAPNS(uid string, data []byte, devices []*model.Device, priority int, interruptionLevel string, isTimeline bool) (string, int) {
-	uuid := uuid.New().String()
+func (l *Logic) SendAPNS(uid string, data []byte, devices []*model.Device, priority int, interruptionLevel string, sound *pb.Sound, isTimeline bool) (string, int) {
+	return l.SendAPNSWithID(uuid.New().String(), uid, data, devices, priority, interruptionLevel, sound, isTimeline, TTLDefault)
+}
+
+// SendAPNSWithID send message to APNS with request uid, which is passed to client as req for acknowledgement, apns drops message after ttl
+func (l *Logic) SendAPNSWithID(uuid string, uid string, data []byte, devices []*model.Device, priority int, interruptionLevel string, sound *pb.Sound, isTimeline bool, ttl time.Duration) (string, int) {
 	encodeMSG := crypto.Base64Encode.EncodeToString(data)
-	payloadIOS := payload.NewPayload().MutableContent().AlertLocKey("NewMsg").Custom("uid", uid).Custom("src", l.NodeID).Custom("msg", encodeMSG)
-	payloadOSX := payload.NewPayload().ContentAvailable().Custom("uid", uid).Custom("src", l.NodeID).Custom("msg", encodeMSG)
+	payloadIOS := payload.NewPayload().MutableContent().AlertLocKey("NewMsg").Custom("uid", uid).Custom("src", l.NodeID).Custom("req", uuid).Custom("msg", encodeMSG)
+	payloadOSX := payload.NewPayload().ContentAvailable().Custom("uid", uid).Custom("src", l.NodeID).Custom("req", uuid).Custom("msg", encodeMSG)
 	if len(interruptionLevel) > 0 {
 		payloadIOS = payloadIOS.InterruptionLevel(payload.EInterruptionLevel(interruptionLevel))
 		payloadOSX = payloadOSX.InterruptionLevel(payload.EInterruptionLevel(interruptionLevel))
 	}
+	if sound != nil && sound.Type == pb.SoundType_CriticalSound {
+		payloadIOS = criticalPayload(payloadIOS, sound)
+	}
 	notification := &apns2.Notification{
 		ApnsID:     uuid,
-		Expiration: time.Now().Add(24 * time.Hour),
+		Expiration: apnsExpiration(ttl, time.Now()),
 	}
 	if priority == 5 { // only 10 or 5
 		notification.Priority = priority
@@ -344,6 +393,19 @@ func (l *Logic) SendAPNS(uid string, data []byte, devices []*model.Device, prior
 	return uuid, n
 }
 
+// criticalPayload set critical alert sound, requires critical alerts entitlement of client
+func criticalPayload(p *payload.Payload, sound *pb.Sound) *payload.Payload {
+	name := sound.Name
+	if len(name) <= 0 || name == "1" {
+		name = "default"
+	}
+	volume := sound.Volume
+	if volume <= 0 || volume > 1 {
+		volume = 1
+	}
+	return p.SoundName(name).SoundVolume(volume).InterruptionLevel(payload.InterruptionLevelCritical)
+}
+
 func (l *Logic) getAPNS(sandbox bool) APNSPusher {
 	if MockPusher != nil {
 		return MockPusher
@@ -1,11 +1,15 @@
 package logic
 
 import (
+	"encoding/json"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/chanify/chanify/crypto"
 	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+	"github.com/sideshow/apns2/payload"
 )
 
 func TestLogic(t *testing.T) {
@@ -138,3 +142,14 @@ func TestLoadFile(t *testing.T) {
 		t.Error("Check open empty file failed")
 	}
 }
+
+func TestCriticalPayload(t *testing.T) {
+	data, _ := json.Marshal(criticalPayload(payload.NewPayload(), &pb.Sound{Name: "1"}))
+	if !strings.Contains(string(data), `"critical":1`) || !strings.Contains(string(data), `"name":"default"`) || !strings.Contains(string(data), `"volume":1`) || !strings.Contains(string(data), `"interruption-level":"critical"`) {
+		t.Error("Check default critical payload failed:", string(data))
+	}
+	data, _ = json.Marshal(criticalPayload(payload.NewPayload(), &pb.Sound{Name: "bell", Volume: 0.5}))
+	if !strings.Contains(string(data), `"name":"bell"`) || !strings.Contains(string(data), `"volume":0.5`) {
+		t.Error("Check critical payload failed:", string(data))
+	}
+}
@@ -27,6 +27,9 @@ var luaMods = map[string]map[string]lua.LGFunction{
 		"equal": luaCryptoEqual,
 		"hmac":  luaCryptoHmac,
 	},
+	"alert": {
+		"level": luaAlertLevel,
+	},
 }
 
 func luaHEXDecode(l *lua.LState) int {
@@ -165,8 +168,29 @@ func luaCryptoHmac(l *lua.LState) int {
 	return 1
 }
 
-func initLua(l *lua.LState) {
+// luaAlertLevel return priority and interruption level of alert severity, resolved alerts are passive
+func luaAlertLevel(l *lua.LState) int {
+	priority, level := 10, "active"
+	if l.OptBool(2, false) {
+		priority, level = 5, "passive"
+	} else {
+		switch strings.ToLower(l.OptString(1, "")) {
+		case "critical", "fatal", "error", "high", "page":
+			level = "time-sensitive"
+		case "info", "low", "debug", "none":
+			priority, level = 5, "passive"
+		}
+	}
+	l.Push(lua.LNumber(priority))
+	l.Push(lua.LString(level))
+	return 2
+}
+
+func initLua(l *lua.LState, names ...string) {
 	for v, m := range luaMods {
+		if len(names) > 0 && !containsString(names, v) {
+			continue
+		}
 		mod := m
 		l.PreloadModule(v, func(l *lua.LState) int {
 			mod := l.SetFuncs(l.NewTable(), mod)
@@ -175,3 +199,11 @@ func initLua(l *lua.LState) {
 		})
 	}
 }
+
+// LuaTableToMap convert lua table to go map
+func LuaTableToMap(tbl *lua.LTable) map[string]interface{} {
+	if m, ok := luaLValue2Interface(tbl).(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,173 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	luaHttpTimeout     = 10 * time.Second
+	luaHttpMaxBody     = 1 << 20
+	luaHttpMaxRedirect = 5
+)
+
+// variable define
+var (
+	ErrHostNotAllowed = errors.New("host not allowed")
+	ErrBodyTooLarge   = errors.New("response body too large")
+	ErrInvalidScheme  = errors.New("invalid url scheme")
+)
+
+type luaHttpClient struct {
+	client  *http.Client
+	maxBody int64
+	hosts   []string
+}
+
+// newLuaHttpClient read http_allow_hosts, http_timeout and http_max_body from webhook env
+func newLuaHttpClient(env map[string]interface{}) *luaHttpClient {
+	c := &luaHttpClient{
+		maxBody: luaHttpMaxBody,
+	}
+	timeout := luaHttpTimeout
+	switch v := env["http_timeout"].(type) {
+	case string:
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			timeout = d
+		}
+	case int:
+		if v > 0 {
+			timeout = time.Duration(v) * time.Second
+		}
+	}
+	switch v := env["http_max_body"].(type) {
+	case int:
+		if v > 0 {
+			c.maxBody = int64(v)
+		}
+	case int64:
+		if v > 0 {
+			c.maxBody = v
+		}
+	}
+	switch v := env["http_allow_hosts"].(type) {
+	case string:
+		for _, h := range strings.Split(v, ",") {
+			c.addHost(h)
+		}
+	case []interface{}:
+		for _, h := range v {
+			if s, ok := h.(string); ok {
+				c.addHost(s)
+			}
+		}
+	}
+	c.client = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= luaHttpMaxRedirect {
+				return fmt.Errorf("stopped after %d redirects", luaHttpMaxRedirect)
+			}
+			return c.checkURL(req.URL)
+		},
+	}
+	return c
+}
+
+func (c *luaHttpClient) addHost(host string) {
+	c.hosts = appendAllowHost(c.hosts, host)
+}
+
+func (c *luaHttpClient) checkURL(u *url.URL) error {
+	return checkAllowHost(c.hosts, u)
+}
+
+func (c *luaHttpClient) loader(l *lua.LState) int {
+	mod := l.SetFuncs(l.NewTable(), map[string]lua.LGFunction{
+		"get": func(l *lua.LState) int {
+			return c.doRequest(l, "GET", l.CheckString(1), "", l.OptTable(2, nil))
+		},
+		"post": func(l *lua.LState) int {
+			return c.doRequest(l, "POST", l.CheckString(1), l.OptString(2, ""), l.OptTable(3, nil))
+		},
+		"request": func(l *lua.LState) int {
+			opts := l.CheckTable(1)
+			method := strings.ToUpper(lua.LVAsString(opts.RawGetString("method")))
+			if len(method) <= 0 {
+				method = "GET"
+			}
+			return c.doRequest(l, method, lua.LVAsString(opts.RawGetString("url")), lua.LVAsString(opts.RawGetString("body")), opts)
+		},
+	})
+	l.Push(mod)
+	return 1
+}
+
+func (c *luaHttpClient) doRequest(l *lua.LState, method string, rawURL string, body string, opts *lua.LTable) int {
+	ctx := l.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	resp, err := c.send(ctx, method, rawURL, body, opts)
+	if err != nil {
+		l.Push(lua.LNil)
+		l.Push(lua.LString(err.Error()))
+		return 2
+	}
+	l.Push(resp)
+	return 1
+}
+
+func (c *luaHttpClient) send(ctx context.Context, method string, rawURL string, body string, opts *lua.LTable) (*lua.LTable, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkURL(u); err != nil {
+		return nil, err
+	}
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil {
+		if headers, ok := opts.RawGetString("headers").(*lua.LTable); ok {
+			headers.ForEach(func(k, v lua.LValue) {
+				req.Header.Set(k.String(), v.String())
+			})
+		}
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxBody+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.maxBody {
+		return nil, ErrBodyTooLarge
+	}
+	ret := &lua.LTable{}
+	ret.RawSetString("status", lua.LNumber(resp.StatusCode))
+	ret.RawSetString("body", lua.LString(data))
+	headers := &lua.LTable{}
+	for k := range resp.Header {
+		headers.RawSetString(strings.ToLower(k), lua.LString(resp.Header.Get(k)))
+	}
+	ret.RawSetString("headers", headers)
+	return ret, nil
+}
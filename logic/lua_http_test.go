@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestLuaHttp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Write([]byte(strings.Repeat("a", 100))) // nolint: errcheck
+		case "/redirect":
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.Header().Set("X-Method", r.Method)
+			w.Write([]byte(r.Header.Get("X-Token"))) // nolint: errcheck
+		}
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	w := &Webhook{
+		lfunc: &luaFunc{},
+		env: map[string]interface{}{
+			"http_allow_hosts": "api.example.com, " + u.Host,
+			"http_timeout":     "100ms",
+			"http_max_body":    10,
+		},
+	}
+	w.http = newLuaHttpClient(w.env)
+	l := lua.NewState()
+	defer l.Close()
+	l.PreloadModule("http", w.http.loader)
+	tests := map[string]string{
+		`local http=require "http";local r=http.get("` + srv.URL + `/",{headers={["X-Token"]="abc"}});return r.body`:         "abc",
+		`local http=require "http";local r=http.post("` + srv.URL + `/","123");return r.headers["x-method"]`:                 "POST",
+		`local http=require "http";local r=http.request({method="put",url="` + srv.URL + `/"});return r.headers["x-method"]`: "PUT",
+		`local http=require "http";local r=http.request({url="` + srv.URL + `/"});return r.status`:                           "200",
+		`local http=require "http";local r,err=http.get("` + srv.URL + `/large");return err`:                                 ErrBodyTooLarge.Error(),
+		`local http=require "http";local r,err=http.get("http://127.0.0.2/");return err`:                                     ErrHostNotAllowed.Error(),
+		`local http=require "http";local r,err=http.get("file:///etc/passwd");return err`:                                    ErrInvalidScheme.Error(),
+		`local http=require "http";local r,err=http.get("` + srv.URL + `/redirect");return err`:                              ErrHostNotAllowed.Error(),
+		`local http=require "http";local r,err=http.get("` + srv.URL + `/slow");return err`:                                  "Timeout",
+		`local http=require "http";local r,err=http.get("%%");return err`:                                                    "invalid URL",
+	}
+	for code, expected := range tests {
+		if err := l.DoString(code); err != nil {
+			t.Fatal(err)
+		}
+		if lv := l.Get(-1); !strings.Contains(lv.String(), expected) {
+			t.Error("Check lua http failed:", code, lv.String())
+		}
+		l.Pop(1)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	l.SetContext(ctx)
+	start := time.Now()
+	l.DoString(`local http=require "http";return http.get("` + srv.URL + `/slow")`) // nolint: errcheck
+	if d := time.Since(start); d >= 100*time.Millisecond {
+		t.Error("Check lua http context deadline failed:", d)
+	}
+}
+
+func TestLuaHttpClientOptions(t *testing.T) {
+	c := newLuaHttpClient(map[string]interface{}{
+		"http_allow_hosts": []interface{}{"*.example.com", 123},
+		"http_timeout":     3,
+		"http_max_body":    int64(1024),
+	})
+	if c.client.Timeout != 3*time.Second || c.maxBody != 1024 {
+		t.Error("Check lua http options failed")
+	}
+	if u, _ := url.Parse("https://api.example.com/abc"); c.checkURL(u) != nil {
+		t.Error("Check lua http wildcard host failed")
+	}
+	if u, _ := url.Parse("https://example.org/abc"); c.checkURL(u) != ErrHostNotAllowed {
+		t.Error("Check lua http not allowed host failed")
+	}
+	c = newLuaHttpClient(map[string]interface{}{})
+	if c.client.Timeout != luaHttpTimeout || c.maxBody != luaHttpMaxBody || len(c.hosts) != 0 {
+		t.Error("Check lua http default options failed")
+	}
+}
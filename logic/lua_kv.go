@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/chanify/chanify/model"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	luaKVMaxKey   = 255
+	luaKVMaxValue = 4096
+)
+
+// variable define
+var (
+	ErrKVNotAvailable = errors.New("kv storage not available")
+	ErrKVInvalidKey   = errors.New("invalid kv key")
+	ErrKVValueTooLong = errors.New("kv value too long")
+)
+
+type luaKV struct {
+	db model.DB
+	ns string
+}
+
+// newLuaKV create key-value storage for lua plugin, keys are namespaced by ns
+func newLuaKV(db model.DB, ns string) *luaKV {
+	return &luaKV{db: db, ns: ns}
+}
+
+func (kv *luaKV) loader(l *lua.LState) int {
+	mod := l.SetFuncs(l.NewTable(), map[string]lua.LGFunction{
+		"get": func(l *lua.LState) int {
+			value, err := kv.Get(l.CheckString(1))
+			if err != nil {
+				return luaKVError(l, err)
+			}
+			if value == nil {
+				l.Push(lua.LNil)
+			} else {
+				l.Push(lua.LString(value))
+			}
+			return 1
+		},
+		"set": func(l *lua.LState) int {
+			var value string
+			switch v := l.CheckAny(2).(type) {
+			case lua.LString:
+				value = string(v)
+			case lua.LNumber:
+				value = v.String()
+			case lua.LBool:
+				value = v.String()
+			default:
+				l.ArgError(2, "string or number expected")
+				return 0
+			}
+			if err := kv.Set(l.CheckString(1), value, float64(l.OptNumber(3, 0))); err != nil {
+				return luaKVError(l, err)
+			}
+			l.Push(lua.LTrue)
+			return 1
+		},
+		"incr": func(l *lua.LState) int {
+			value, err := kv.Incr(l.CheckString(1), int64(l.OptNumber(2, 1)), float64(l.OptNumber(3, 0)))
+			if err != nil {
+				return luaKVError(l, err)
+			}
+			l.Push(lua.LNumber(value))
+			return 1
+		},
+		"del": func(l *lua.LState) int {
+			if err := kv.Del(l.CheckString(1)); err != nil {
+				return luaKVError(l, err)
+			}
+			l.Push(lua.LTrue)
+			return 1
+		},
+	})
+	l.Push(mod)
+	return 1
+}
+
+// Get value with key, return nil if not found or expired
+func (kv *luaKV) Get(key string) ([]byte, error) {
+	if err := kv.check(key); err != nil {
+		return nil, err
+	}
+	value, err := kv.db.GetPluginValue(kv.ns, key)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	return value, nil
+}
+
+// Set value with key, ttl is in seconds and 0 means never expired
+func (kv *luaKV) Set(key string, value string, ttl float64) error {
+	if err := kv.check(key); err != nil {
+		return err
+	}
+	if len(value) > luaKVMaxValue {
+		return ErrKVValueTooLong
+	}
+	return kv.db.SetPluginValue(kv.ns, key, []byte(value), luaKVExpires(ttl))
+}
+
+// Incr value with key by delta, ttl only applies when the key is created
+func (kv *luaKV) Incr(key string, delta int64, ttl float64) (int64, error) {
+	if err := kv.check(key); err != nil {
+		return 0, err
+	}
+	return kv.db.IncrPluginValue(kv.ns, key, delta, luaKVExpires(ttl))
+}
+
+// Del value with key
+func (kv *luaKV) Del(key string) error {
+	if err := kv.check(key); err != nil {
+		return err
+	}
+	return kv.db.DelPluginValue(kv.ns, key)
+}
+
+func (kv *luaKV) check(key string) error {
+	if kv.db == nil {
+		return ErrKVNotAvailable
+	}
+	if len(key) <= 0 || len(key) > luaKVMaxKey {
+		return ErrKVInvalidKey
+	}
+	return nil
+}
+
+func luaKVExpires(ttl float64) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Unix() + int64(math.Ceil(ttl))
+}
+
+func luaKVError(l *lua.LState, err error) int {
+	if err == model.ErrNotImplemented {
+		err = ErrKVNotAvailable
+	}
+	l.Push(lua.LNil)
+	l.Push(lua.LString(err.Error()))
+	return 2
+}
@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chanify/chanify/model"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestLuaKV(t *testing.T) {
+	db, err := model.InitDB("sqlite://?mode=memory")
+	if err != nil {
+		t.Fatal("Open db failed:", err)
+	}
+	defer db.Close()
+	w := &Webhook{kv: newLuaKV(db, "webhook.test")}
+	l, cancel := w.NewState(context.Background())
+	defer cancel()
+	defer l.Close()
+	codes := []string{
+		`local kv = require "kv"; assert(kv.get("status") == nil)`,
+		`local kv = require "kv"; assert(kv.set("status", "failed")); assert(kv.get("status") == "failed")`,
+		`local kv = require "kv"; assert(kv.set("count", 12, 60)); assert(kv.get("count") == "12")`,
+		`local kv = require "kv"; assert(kv.incr("count") == 13); assert(kv.incr("count", 5) == 18)`,
+		`local kv = require "kv"; assert(kv.incr("flap", 1, 300) == 1)`,
+		`local kv = require "kv"; assert(kv.del("status")); assert(kv.get("status") == nil)`,
+		`local kv = require "kv"; local v, err = kv.get(""); assert(v == nil and err == "invalid kv key")`,
+		`local kv = require "kv"; local v, err = kv.set("big", string.rep("x", 5000)); assert(v == nil and err == "kv value too long")`,
+		`local kv = require "kv"; assert(not pcall(kv.set, "t", {}))`,
+	}
+	for _, code := range codes {
+		if err := l.DoString(code); err != nil {
+			t.Error("Check lua kv failed:", code, err)
+		}
+	}
+	other := newLuaKV(db, "webhook.other")
+	if v, err := other.Get("count"); err != nil || v != nil {
+		t.Error("Check lua kv namespace failed:", err)
+	}
+	if v, err := w.kv.Get("count"); err != nil || string(v) != "18" {
+		t.Error("Check lua kv persistent failed:", err)
+	}
+}
+
+func TestLuaKVNotAvailable(t *testing.T) {
+	db, _ := model.InitDB("nosql://?secret=123")
+	defer db.Close()
+	for _, kv := range []*luaKV{newLuaKV(nil, "test"), newLuaKV(db, "test")} {
+		l := lua.NewState()
+		l.PreloadModule("kv", kv.loader)
+		codes := []string{
+			`local kv = require "kv"; local v, err = kv.get("a"); assert(v == nil and err == "kv storage not available")`,
+			`local kv = require "kv"; local v, err = kv.set("a", "b"); assert(v == nil and err == "kv storage not available")`,
+			`local kv = require "kv"; local v, err = kv.incr("a"); assert(v == nil and err == "kv storage not available")`,
+			`local kv = require "kv"; local v, err = kv.del("a"); assert(v == nil and err == "kv storage not available")`,
+		}
+		for _, code := range codes {
+			if err := l.DoString(code); err != nil {
+				t.Error("Check lua kv not available failed:", code, err)
+			}
+		}
+		l.Close()
+	}
+}
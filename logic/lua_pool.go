@@ -0,0 +1,185 @@
+package logic
+
+import (
+	"context"
+	"sync"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const luaPoolSize = 8
+
+type luaPooledState struct {
+	l       *lua.LState
+	gen     uint64
+	strMeta lua.LValue
+	tables  map[*lua.LTable]*luaTableSnapshot
+	envs    map[*lua.LFunction]*lua.LTable
+}
+
+type luaTableSnapshot struct {
+	fields map[lua.LValue]lua.LValue
+	meta   lua.LValue
+}
+
+type luaStatePool struct {
+	mutex  sync.Mutex
+	size   int
+	gen    uint64
+	states []*luaPooledState
+}
+
+func newLuaStatePool(size int) *luaStatePool {
+	return &luaStatePool{size: size}
+}
+
+// Get pooled state or nil if pool is empty
+func (p *luaStatePool) Get() *luaPooledState {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	n := len(p.states)
+	if n <= 0 {
+		return nil
+	}
+	s := p.states[n-1]
+	p.states = p.states[:n-1]
+	return s
+}
+
+// Put state back into pool, close it if pool is full or invalidated
+func (p *luaStatePool) Put(s *luaPooledState) {
+	p.mutex.Lock()
+	if s.gen == p.gen && len(p.states) < p.size {
+		p.states = append(p.states, s)
+		s = nil
+	}
+	p.mutex.Unlock()
+	if s != nil {
+		s.l.Close()
+	}
+}
+
+// Generation of pool, states created with older generation will be dropped
+func (p *luaStatePool) Generation() uint64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.gen
+}
+
+// Invalidate close all idle states
+func (p *luaStatePool) Invalidate() {
+	p.mutex.Lock()
+	states := p.states
+	p.states = nil
+	p.gen++
+	p.mutex.Unlock()
+	for _, s := range states {
+		s.l.Close()
+	}
+}
+
+// newLuaPooledState snapshot every table reachable from globals, registry and string metatable,
+// with their fields and metatables, and environments of reachable functions
+func newLuaPooledState(l *lua.LState, gen uint64) *luaPooledState {
+	s := &luaPooledState{
+		l:       l,
+		gen:     gen,
+		strMeta: l.GetMetatable(lua.LString("")),
+		tables:  map[*lua.LTable]*luaTableSnapshot{},
+		envs:    map[*lua.LFunction]*lua.LTable{},
+	}
+	s.snapshot(l.G.Global)
+	s.snapshot(l.Get(lua.RegistryIndex))
+	s.snapshot(s.strMeta)
+	return s
+}
+
+func (s *luaPooledState) snapshot(v lua.LValue) {
+	switch val := v.(type) {
+	case *lua.LTable:
+		if _, ok := s.tables[val]; ok {
+			return
+		}
+		ts := &luaTableSnapshot{fields: map[lua.LValue]lua.LValue{}, meta: val.Metatable}
+		s.tables[val] = ts
+		val.ForEach(func(k, v lua.LValue) {
+			ts.fields[k] = v
+		})
+		for k, v := range ts.fields {
+			s.snapshot(k)
+			s.snapshot(v)
+		}
+		s.snapshot(val.Metatable)
+	case *lua.LFunction:
+		if _, ok := s.envs[val]; ok {
+			return
+		}
+		s.envs[val] = val.Env
+		s.snapshot(val.Env)
+	}
+}
+
+// reset tables and function environments to the initial snapshot, false if the state can not be restored
+func (s *luaPooledState) reset() bool {
+	l := s.l
+	l.SetTop(0)
+	if l.GetMetatable(lua.LString("")) != s.strMeta {
+		return false
+	}
+	for tbl, ts := range s.tables {
+		luaTableRestore(tbl, ts.fields)
+		tbl.Metatable = ts.meta
+	}
+	for fn, env := range s.envs {
+		fn.Env = env
+	}
+	return true
+}
+
+func luaTableRestore(tbl *lua.LTable, fields map[lua.LValue]lua.LValue) {
+	keys := []lua.LValue{}
+	tbl.ForEach(func(k, v lua.LValue) {
+		if old, ok := fields[k]; !ok || old != v {
+			keys = append(keys, k)
+		}
+	})
+	for _, k := range keys {
+		tbl.RawSet(k, lua.LNil)
+	}
+	for k, v := range fields {
+		if tbl.RawGet(k) != v {
+			tbl.RawSet(k, v)
+		}
+	}
+}
+
+// Call lua function with a pooled state, the state is dropped if fn returns error
+func (w *Webhook) Call(ctx context.Context, fn func(l *lua.LState) error) error {
+	if w.pool == nil {
+		l, cancel := w.NewState(ctx)
+		defer cancel()
+		defer l.Close()
+		return fn(l)
+	}
+	sb := w.getSandbox()
+	s := w.pool.Get()
+	if s == nil {
+		gen := w.pool.Generation()
+		s = newLuaPooledState(sb.open(w.loaders()), gen)
+	}
+	ctx, cancel := context.WithTimeout(ctx, sb.timeout)
+	defer cancel()
+	s.l.SetContext(ctx)
+	err := fn(s.l)
+	s.l.RemoveContext()
+	if err != nil {
+		s.l.Close()
+		return err
+	}
+	if !s.reset() {
+		s.l.Close()
+		return nil
+	}
+	w.pool.Put(s)
+	return nil
+}
@@ -0,0 +1,231 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const luaPoolTestScript = `
+local json = require "json"
+local obj = json.decode(ctx)
+counter = (counter or 0) + 1
+string.custom = true
+return 200, json.encode({ name = obj.name, counter = counter })
+`
+
+func newLuaPoolTestWebhook(t testing.TB, size int) *Webhook {
+	file := filepath.Join(t.TempDir(), "pool.lua")
+	os.WriteFile(file, []byte(luaPoolTestScript), 0644) // nolint: errcheck
+	lfunc := &luaFunc{}
+	if err := lfunc.Reload(file); err != nil {
+		t.Fatal("Compile lua failed:", err)
+	}
+	w := &Webhook{name: "pool", lfunc: lfunc, sandbox: newLuaSandbox(nil)}
+	if size > 0 {
+		w.pool = newLuaStatePool(size)
+	}
+	return w
+}
+
+func callLuaPoolTest(w *Webhook) (*lua.LState, string, error) {
+	var state *lua.LState
+	var ret string
+	err := w.Call(context.Background(), func(l *lua.LState) error {
+		state = l
+		l.SetGlobal("ctx", lua.LString(`{"name":"chanify"}`))
+		if err := w.DoCall(l); err != nil {
+			return err
+		}
+		ret = l.Get(-1).String()
+		return nil
+	})
+	return state, ret, err
+}
+
+func TestWebhookCallPool(t *testing.T) {
+	w := newLuaPoolTestWebhook(t, 2)
+	l1, ret, err := callLuaPoolTest(w)
+	if err != nil || ret != `{"counter":1,"name":"chanify"}` {
+		t.Fatal("Call pooled webhook failed:", ret, err)
+	}
+	l2, ret, err := callLuaPoolTest(w)
+	if err != nil || ret != `{"counter":1,"name":"chanify"}` {
+		t.Fatal("Check pooled webhook reset failed:", ret, err)
+	}
+	if l1 != l2 {
+		t.Error("Check pooled webhook reuse failed")
+	}
+	if l2.GetGlobal("ctx") != lua.LNil || l2.GetField(l2.GetGlobal("string"), "custom") != lua.LNil {
+		t.Error("Check pooled webhook globals failed")
+	}
+	if len(w.pool.states) != 1 {
+		t.Error("Check pooled webhook size failed")
+	}
+	w.pool.Invalidate()
+	if len(w.pool.states) != 0 || w.pool.Generation() != 1 {
+		t.Error("Check pooled webhook invalidate failed")
+	}
+	l3, _, err := callLuaPoolTest(w)
+	if err != nil || l3 == l2 {
+		t.Error("Check pooled webhook new state failed:", err)
+	}
+}
+
+func TestWebhookCallPoolFailed(t *testing.T) {
+	w := newLuaPoolTestWebhook(t, 2)
+	errTest := errors.New("test")
+	var state *lua.LState
+	if err := w.Call(context.Background(), func(l *lua.LState) error {
+		state = l
+		return errTest
+	}); err != errTest {
+		t.Fatal("Check pooled webhook error failed:", err)
+	}
+	if len(w.pool.states) != 0 || !state.IsClosed() {
+		t.Error("Check pooled webhook drop failed")
+	}
+	w.sandbox.timeout = 50 * time.Millisecond
+	if err := w.Call(context.Background(), func(l *lua.LState) error {
+		return l.DoString("while true do end")
+	}); err == nil {
+		t.Error("Check pooled webhook timeout failed")
+	}
+}
+
+func TestWebhookCallPoolMetatables(t *testing.T) {
+	w := newLuaPoolTestWebhook(t, 2)
+	var l1, l2 *lua.LState
+	if err := w.Call(context.Background(), func(l *lua.LState) error {
+		l1 = l
+		return l.DoString(`
+setmetatable(_G, { __index = function(t, k) return "leak" end })
+setmetatable(string, { __index = function(t, k) return "leak" end })
+getmetatable("").__index = { upper = function() return "leak" end }
+package.preload.leak = function() return "leak" end
+os.leak = { nested = true }
+`)
+	}); err != nil {
+		t.Fatal("Call pooled webhook failed:", err)
+	}
+	var ret string
+	if err := w.Call(context.Background(), func(l *lua.LState) error {
+		l2 = l
+		if err := l.DoString(`return tostring(missing) .. tostring(string.missing) .. ("a"):upper() .. tostring(package.preload.leak) .. tostring(os.leak)`); err != nil {
+			return err
+		}
+		ret = l.Get(-1).String()
+		return nil
+	}); err != nil {
+		t.Fatal("Call pooled webhook again failed:", err)
+	}
+	if l1 != l2 {
+		t.Error("Check pooled webhook reuse failed")
+	}
+	if ret != "nilnilAnilnil" {
+		t.Error("Check pooled webhook metatables reset failed:", ret)
+	}
+	s := w.pool.Get()
+	defer s.l.Close()
+	s.strMeta = lua.LNil
+	if s.reset() {
+		t.Error("Check pooled webhook string metatable changed failed")
+	}
+}
+
+func TestLuaStatePool(t *testing.T) {
+	p := newLuaStatePool(1)
+	if p.Get() != nil {
+		t.Fatal("Check empty pool failed")
+	}
+	s1 := newLuaPooledState(lua.NewState(), p.Generation())
+	s2 := newLuaPooledState(lua.NewState(), p.Generation())
+	p.Put(s1)
+	p.Put(s2)
+	if !s2.l.IsClosed() || p.Get() != s1 {
+		t.Error("Check pool size failed")
+	}
+	p.Invalidate()
+	p.Put(s1)
+	if !s1.l.IsClosed() || p.Get() != nil {
+		t.Error("Check pool generation failed")
+	}
+}
+
+func TestWebhookCallNoPool(t *testing.T) {
+	w := newLuaPoolTestWebhook(t, 0)
+	l1, ret, err := callLuaPoolTest(w)
+	if err != nil || ret != `{"counter":1,"name":"chanify"}` {
+		t.Fatal("Call webhook failed:", ret, err)
+	}
+	if !l1.IsClosed() {
+		t.Error("Check webhook close state failed")
+	}
+}
+
+func TestReloadWebhookInvalidatePool(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "pool.lua")
+	os.WriteFile(file, []byte(luaPoolTestScript), 0644) // nolint: errcheck
+	p := loadWebhookPlugin(dir, []map[string]interface{}{{"name": "pool", "file": file}}, nil)
+	defer p.Close()
+	w := p.webHooks["pool"]
+	if w == nil || w.pool == nil || w.pool.size != luaPoolSize {
+		t.Fatal("Check webhook pool failed")
+	}
+	callLuaPoolTest(w) // nolint: errcheck
+	if len(w.pool.states) != 1 {
+		t.Fatal("Check webhook pool put failed")
+	}
+	os.Chtimes(file, time.Now().Add(time.Minute), time.Now().Add(time.Minute)) // nolint: errcheck
+	abs, _ := filepath.Abs(file)
+	p.ReloadWebhook(abs)
+	if len(w.pool.states) != 0 {
+		t.Error("Check webhook pool invalidate failed")
+	}
+	p2 := loadWebhookPlugin(dir, []map[string]interface{}{{"name": "pool", "file": file, "pool_size": 0}}, nil)
+	defer p2.Close()
+	if p2.webHooks["pool"].pool != nil {
+		t.Error("Check disable webhook pool failed")
+	}
+}
+
+func BenchmarkWebhookNewState(b *testing.B) {
+	w := newLuaPoolTestWebhook(b, 0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := callLuaPoolTest(w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWebhookPool(b *testing.B) {
+	w := newLuaPoolTestWebhook(b, luaPoolSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := callLuaPoolTest(w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWebhookPoolParallel(b *testing.B) {
+	w := newLuaPoolTestWebhook(b, luaPoolSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, _, err := callLuaPoolTest(w); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	luaTimeout         = 10 * time.Second
+	luaCallStackSize   = 200
+	luaRegistrySize    = 1024
+	luaRegistryMaxSize = 1024 * 64
+	luaMaxStringSize   = 1024 * 1024
+)
+
+var luaDefaultModules = []string{"table", "string", "math", "coroutine", "os", "hex", "json", "crypto", "alert", "http", "kv"}
+
+// luaWebhookModules are preloaded per webhook in Webhook.NewState
+var luaWebhookModules = map[string]bool{"http": true, "kv": true}
+
+// luaSafeStdLibs is the whitelist of standard libraries
+var luaSafeStdLibs = map[string]lua.LGFunction{
+	lua.TabLibName:       lua.OpenTable,
+	lua.StringLibName:    lua.OpenString,
+	lua.MathLibName:      lua.OpenMath,
+	lua.CoroutineLibName: lua.OpenCoroutine,
+	lua.OsLibName:        luaOpenSafeOs,
+}
+
+var luaUnsafeBaseFuncs = []string{"dofile", "loadfile", "load", "loadstring"}
+
+var luaSafeOsFuncs = map[string]bool{"clock": true, "date": true, "difftime": true, "time": true}
+
+type luaSandbox struct {
+	timeout         time.Duration
+	callStackSize   int
+	registryMaxSize int
+	maxStringSize   int
+	modules         []string
+}
+
+// newLuaSandbox read timeout, call_stack_size, registry_max_size, max_string_size and modules from webhook options
+func newLuaSandbox(opts map[string]interface{}) *luaSandbox {
+	sb := &luaSandbox{
+		timeout:         luaTimeout,
+		callStackSize:   luaCallStackSize,
+		registryMaxSize: luaRegistryMaxSize,
+		maxStringSize:   luaMaxStringSize,
+		modules:         luaDefaultModules,
+	}
+	if val, ok := readOptString(opts, "timeout"); ok {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			sb.timeout = d
+		}
+	}
+	if val, ok := opts["call_stack_size"].(int); ok && val > 0 {
+		sb.callStackSize = val
+	}
+	if val, ok := opts["registry_max_size"].(int); ok && val > 0 {
+		sb.registryMaxSize = val
+	}
+	if val, ok := opts["max_string_size"].(int); ok && val > 0 {
+		sb.maxStringSize = val
+	}
+	if mods, ok := opts["modules"].([]interface{}); ok {
+		sb.modules = []string{}
+		for _, m := range mods {
+			if name, ok := m.(string); ok {
+				name = strings.ToLower(strings.TrimSpace(name))
+				if _, ok := luaSafeStdLibs[name]; ok {
+					sb.modules = append(sb.modules, name)
+				} else if _, ok := luaMods[name]; ok || luaWebhookModules[name] {
+					sb.modules = append(sb.modules, name)
+				} else {
+					log.Println("Unsupported lua module:", name)
+				}
+			}
+		}
+	}
+	return sb
+}
+
+func (sb *luaSandbox) newState(ctx context.Context, loaders map[string]lua.LGFunction) (*lua.LState, context.CancelFunc) {
+	l := sb.open(loaders)
+	ctx, cancel := context.WithTimeout(ctx, sb.timeout)
+	l.SetContext(ctx)
+	return l, cancel
+}
+
+func (sb *luaSandbox) open(loaders map[string]lua.LGFunction) *lua.LState {
+	registrySize := luaRegistrySize
+	if registrySize > sb.registryMaxSize {
+		registrySize = sb.registryMaxSize
+	}
+	l := lua.NewState(lua.Options{
+		SkipOpenLibs:        true,
+		CallStackSize:       sb.callStackSize,
+		RegistrySize:        registrySize,
+		RegistryMaxSize:     sb.registryMaxSize,
+		MinimizeStackMemory: true,
+	})
+	luaOpenLib(l, lua.LoadLibName, luaOpenSafePackage)
+	luaOpenLib(l, lua.BaseLibName, luaOpenSafeBase)
+	mods := []string{}
+	for _, name := range sb.modules {
+		if name == lua.StringLibName {
+			luaOpenLib(l, name, sb.openSafeString)
+		} else if open, ok := luaSafeStdLibs[name]; ok {
+			luaOpenLib(l, name, open)
+		} else if luaWebhookModules[name] {
+			if loader, ok := loaders[name]; ok {
+				l.PreloadModule(name, loader)
+			}
+		} else {
+			mods = append(mods, name)
+		}
+	}
+	if len(mods) > 0 {
+		initLua(l, mods...)
+	}
+	return l
+}
+
+func luaOpenLib(l *lua.LState, name string, open lua.LGFunction) {
+	l.Push(l.NewFunction(open))
+	l.Push(lua.LString(name))
+	l.Call(1, 0)
+}
+
+func luaOpenSafePackage(l *lua.LState) int {
+	lua.OpenPackage(l)
+	if mod, ok := l.Get(-1).(*lua.LTable); ok {
+		mod.RawSetString("loadlib", lua.LNil)
+		mod.RawSetString("seeall", lua.LNil)
+		mod.RawSetString("path", lua.LString(""))
+		if loaders, ok := mod.RawGetString("loaders").(*lua.LTable); ok {
+			// keep package.preload loader only
+			for loaders.Len() > 1 {
+				loaders.Remove(loaders.Len())
+			}
+		}
+	}
+	return 1
+}
+
+func luaOpenSafeBase(l *lua.LState) int {
+	n := lua.OpenBase(l)
+	g := l.G.Global
+	for _, name := range luaUnsafeBaseFuncs {
+		g.RawSetString(name, lua.LNil)
+	}
+	return n
+}
+
+// openSafeString open string library with size limit of string.rep
+func (sb *luaSandbox) openSafeString(l *lua.LState) int {
+	n := lua.OpenString(l)
+	if mod, ok := l.Get(-1).(*lua.LTable); ok {
+		mod.RawSetString("rep", l.NewFunction(sb.stringRep))
+	}
+	return n
+}
+
+func (sb *luaSandbox) stringRep(l *lua.LState) int {
+	str := l.CheckString(1)
+	n := l.CheckInt(2)
+	if n <= 0 || len(str) <= 0 {
+		l.Push(lua.LString(""))
+		return 1
+	}
+	if n > sb.maxStringSize/len(str) {
+		l.RaiseError("string size exceeds %d bytes", sb.maxStringSize)
+		return 0
+	}
+	l.Push(lua.LString(strings.Repeat(str, n)))
+	return 1
+}
+
+func luaOpenSafeOs(l *lua.LState) int {
+	lua.OpenOs(l)
+	if mod, ok := l.Get(-1).(*lua.LTable); ok {
+		keys := []string{}
+		mod.ForEach(func(k, v lua.LValue) {
+			if !luaSafeOsFuncs[k.String()] {
+				keys = append(keys, k.String())
+			}
+		})
+		for _, k := range keys {
+			mod.RawSetString(k, lua.LNil)
+		}
+	}
+	return 1
+}
@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestLuaSandbox(t *testing.T) {
+	sb := newLuaSandbox(map[string]interface{}{"timeout": "50ms"})
+	l, cancel := sb.newState(context.Background(), map[string]lua.LGFunction{"http": newLuaHttpClient(nil).loader})
+	defer cancel()
+	defer l.Close()
+	safe := []string{
+		`assert(os.execute == nil and os.exit == nil and os.remove == nil and io == nil)`,
+		`assert(load == nil and loadstring == nil and dofile == nil and loadfile == nil)`,
+		`assert(debug == nil and package.loadlib == nil)`,
+		`assert(type(os.time()) == "number" and string.len("abc") == 3 and math.max(1, 2) == 2)`,
+		`local json = require "json"; assert(json.encode({1}) == "[1]")`,
+		`local http = require "http"; assert(http.get ~= nil)`,
+		`assert(not pcall(require, "os_test_module"))`,
+	}
+	for _, code := range safe {
+		if err := l.DoString(code); err != nil {
+			t.Error("Check lua sandbox failed:", code, err)
+		}
+	}
+	start := time.Now()
+	if err := l.DoString(`while true do end`); err == nil || time.Since(start) > time.Second {
+		t.Error("Check lua sandbox timeout failed")
+	}
+}
+
+func TestLuaSandboxLimits(t *testing.T) {
+	sb := newLuaSandbox(map[string]interface{}{
+		"call_stack_size":   50,
+		"registry_max_size": 512,
+		"max_string_size":   100,
+		"modules":           []interface{}{"string", "json", "io", 123},
+	})
+	if len(sb.modules) != 2 {
+		t.Fatal("Check lua sandbox modules failed")
+	}
+	l, cancel := sb.newState(context.Background(), nil)
+	defer cancel()
+	defer l.Close()
+	if err := l.DoString(`assert(table == nil and math == nil and os == nil and io == nil)`); err != nil {
+		t.Error("Check lua sandbox module whitelist failed:", err)
+	}
+	if err := l.DoString(`assert(not pcall(require, "hex"))`); err != nil {
+		t.Error("Check lua sandbox custom modules failed:", err)
+	}
+	if err := l.DoString(`assert(string.rep("ab", 50) == ("ab"):rep(50) and string.rep("a", 0) == "")`); err != nil {
+		t.Error("Check lua sandbox string rep failed:", err)
+	}
+	if err := l.DoString(`return ("ab"):rep(51)`); err == nil || !strings.Contains(err.Error(), "string size") {
+		t.Error("Check lua sandbox string size failed:", err)
+	}
+	if err := l.DoString(`local function f(n) return f(n + 1) + 1 end; f(1)`); err == nil || !strings.Contains(err.Error(), "stack overflow") {
+		t.Error("Check lua sandbox call stack failed:", err)
+	}
+	file := filepath.Join(t.TempDir(), "registry.lua")
+	os.WriteFile(file, []byte(`local function f(...) return f(1, ...) end; f()`), 0644) // nolint: errcheck
+	lfunc := &luaFunc{}
+	if err := lfunc.Reload(file); err != nil {
+		t.Fatal("Check lua compile failed:", err)
+	}
+	w := &Webhook{lfunc: lfunc, sandbox: sb}
+	l2, cancel2 := w.NewState(context.Background())
+	defer cancel2()
+	defer l2.Close()
+	if err := w.DoCall(l2); err == nil {
+		t.Error("Check lua sandbox registry failed")
+	}
+}
+
+func TestWebhookNewState(t *testing.T) {
+	w := &Webhook{}
+	l, cancel := w.NewState(context.Background())
+	defer cancel()
+	defer l.Close()
+	if l.Context() == nil {
+		t.Error("Check webhook lua context failed")
+	}
+	if err := l.DoString(`assert(require("hex").encode("a") == "61")`); err != nil {
+		t.Error("Check webhook lua modules failed:", err)
+	}
+	if _, ok := l.GetGlobal("io").(*lua.LTable); ok {
+		t.Error("Check webhook lua io failed")
+	}
+}
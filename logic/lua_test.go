@@ -156,3 +156,27 @@ func TestLuaCryptoHMac(t *testing.T) {
 		t.Error("Do crypto invalid hmac failed")
 	}
 }
+
+func TestLuaAlertLevel(t *testing.T) {
+	l := lua.NewState()
+	defer l.Close()
+	initLua(l)
+	tests := map[string]string{
+		`"Critical"`:      "10,time-sensitive",
+		`"page"`:          "10,time-sensitive",
+		`"warning"`:       "10,active",
+		`nil`:             "10,active",
+		`"info"`:          "5,passive",
+		`"fatal", true`:   "5,passive",
+		`"warning", true`: "5,passive",
+	}
+	for args, expected := range tests {
+		if err := l.DoString(`local a=require "alert";local p,lv=a.level(` + args + `);return p..","..lv`); err != nil {
+			t.Fatal("Run alert level failed:", args, err)
+		}
+		if lv := l.Get(-1); lv.String() != expected {
+			t.Error("Check alert level failed:", args, lv.String())
+		}
+		l.Pop(1)
+	}
+}
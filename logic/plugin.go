@@ -1,6 +1,8 @@
 package logic
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -8,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/chanify/chanify/model"
 	"github.com/fsnotify/fsnotify"
 	lua "github.com/yuin/gopher-lua"
 )
@@ -19,21 +22,30 @@ type luaFunc struct {
 
 // Webhook item
 type Webhook struct {
-	name  string
-	env   map[string]interface{}
-	lfunc *luaFunc
+	name    string
+	env     map[string]interface{}
+	lfunc   *luaFunc
+	http    *luaHttpClient
+	kv      *luaKV
+	sandbox *luaSandbox
+	pool    *luaStatePool
+	handler WebhookHandler
+	verify  *webhookVerify
 }
 
 type pluginManager struct {
+	db       model.DB
 	watcher  *fsnotify.Watcher
 	luaMutex sync.Mutex
 	luaFiles map[string]*luaFunc
 	webHooks map[string]*Webhook
+	filters  []*messageFilter
 }
 
-func loadWebhookPlugin(path string, wbOpts []map[string]interface{}) *pluginManager {
+func loadWebhookPlugin(path string, wbOpts []map[string]interface{}, db model.DB) *pluginManager {
 	log.Println("Load plugins:", path)
 	plugin := &pluginManager{
+		db:       db,
 		luaFiles: make(map[string]*luaFunc),
 		webHooks: make(map[string]*Webhook),
 	}
@@ -44,29 +56,65 @@ func loadWebhookPlugin(path string, wbOpts []map[string]interface{}) *pluginMana
 	}
 	for _, opts := range wbOpts {
 		if name, ok := readOptString(opts, "name"); ok && len(name) > 0 {
-			if wh, ok := plugin.webHooks[name]; ok && wh.lfunc.lfunc != nil {
+			if wh, ok := plugin.webHooks[name]; ok && wh.isReady() {
 				log.Println("Webhook plugin conflict:", name)
 				continue
 			}
-			if file, ok := readOptString(opts, "file"); ok && len(file) > 0 {
-				if lfunc := plugin.loadLuaFile(file, path); lfunc != nil {
-					webhook := &Webhook{
-						name:  name,
-						env:   make(map[string]interface{}),
-						lfunc: lfunc,
-					}
-					for k, v := range readOptTable(opts, "env") {
-						webhook.env[strings.ToLower(k)] = v
-					}
-					plugin.webHooks[name] = webhook
-					log.Println("Load webhook plugin:", name)
+			webhook := newWebhook(name, opts)
+			if vopts := readOptTable(opts, "verify"); len(vopts) > 0 {
+				verify, err := newWebhookVerify(vopts, webhook.env)
+				if err != nil {
+					log.Println("Webhook verify invalid:", name, err)
+					continue
 				}
+				webhook.verify = verify
 			}
+			if hname, ok := readOptString(opts, "handler"); ok && len(hname) > 0 {
+				handler, ok := getWebhookHandler(hname)
+				if !ok {
+					log.Println("Webhook handler not found:", hname)
+					continue
+				}
+				webhook.handler = handler
+			} else if file, ok := readOptString(opts, "file"); !ok || len(file) <= 0 || !plugin.loadLua(webhook, file, path, opts, "webhook.") {
+				continue
+			}
+			plugin.webHooks[name] = webhook
+			log.Println("Load webhook plugin:", name)
 		}
 	}
 	return plugin
 }
 
+func newWebhook(name string, opts map[string]interface{}) *Webhook {
+	webhook := &Webhook{
+		name: name,
+		env:  make(map[string]interface{}),
+	}
+	for k, v := range readOptTable(opts, "env") {
+		webhook.env[strings.ToLower(k)] = v
+	}
+	return webhook
+}
+
+// loadLua prepare lua runtime of webhook, kv keys are namespaced with ns prefix
+func (p *pluginManager) loadLua(webhook *Webhook, file string, path string, opts map[string]interface{}, ns string) bool {
+	lfunc := p.loadLuaFile(file, path)
+	if lfunc == nil {
+		return false
+	}
+	webhook.lfunc = lfunc
+	webhook.http = newLuaHttpClient(webhook.env)
+	webhook.kv = newLuaKV(p.db, ns+webhook.name)
+	webhook.sandbox = newLuaSandbox(opts)
+	if size, ok := opts["pool_size"].(int); !ok {
+		webhook.pool = newLuaStatePool(luaPoolSize)
+	} else if size > 0 {
+		webhook.pool = newLuaStatePool(size)
+	}
+	return true
+}
+
 func (p *pluginManager) Close() {
 	if p.watcher != nil {
 		p.watcher.Close()
@@ -77,11 +125,8 @@ func (p *pluginManager) Close() {
 func (p *pluginManager) GetWebhook(name string) (*Webhook, error) {
 	p.luaMutex.Lock()
 	defer p.luaMutex.Unlock()
-	if webhook, ok := p.webHooks[strings.ToLower(name)]; ok {
-		lfunc := webhook.lfunc
-		if lfunc != nil && lfunc.lfunc != nil {
-			return webhook, nil
-		}
+	if webhook, ok := p.webHooks[strings.ToLower(name)]; ok && webhook.isReady() {
+		return webhook, nil
 	}
 	return nil, ErrNotFound
 }
@@ -137,6 +182,16 @@ func (p *pluginManager) ReloadWebhook(file string) {
 			log.Println("Reload lua failed:", err)
 		} else {
 			log.Println("Reload lua file:", file)
+			for _, webhook := range p.webHooks {
+				if webhook.lfunc == lfunc && webhook.pool != nil {
+					webhook.pool.Invalidate()
+				}
+			}
+			for _, filter := range p.filters {
+				if filter.webhook != nil && filter.webhook.lfunc == lfunc && filter.webhook.pool != nil {
+					filter.webhook.pool.Invalidate()
+				}
+			}
 		}
 	}
 }
@@ -157,7 +212,43 @@ func (lf *luaFunc) Reload(file string) error {
 	return nil
 }
 
-func (w *Webhook) DoCall(l *lua.LState) error {
+func (w *Webhook) isReady() bool {
+	if w.handler != nil {
+		return true
+	}
+	return w.lfunc != nil && w.lfunc.lfunc != nil
+}
+
+// NewState create sandboxed lua state with execution timeout, call cancel after use
+func (w *Webhook) NewState(ctx context.Context) (*lua.LState, context.CancelFunc) {
+	return w.getSandbox().newState(ctx, w.loaders())
+}
+
+func (w *Webhook) getSandbox() *luaSandbox {
+	if w.sandbox == nil {
+		return newLuaSandbox(nil)
+	}
+	return w.sandbox
+}
+
+func (w *Webhook) loaders() map[string]lua.LGFunction {
+	loaders := map[string]lua.LGFunction{}
+	if w.http != nil {
+		loaders["http"] = w.http.loader
+	}
+	if w.kv != nil {
+		loaders["kv"] = w.kv.loader
+	}
+	return loaders
+}
+
+func (w *Webhook) DoCall(l *lua.LState) (err error) {
+	defer func() {
+		// gopher-lua may panic outside PCall when the registry overflows
+		if r := recover(); r != nil {
+			err = fmt.Errorf("lua panic: %v", r)
+		}
+	}()
 	if w.lfunc == nil {
 		return ErrNotFound
 	}
@@ -165,10 +256,9 @@ func (w *Webhook) DoCall(l *lua.LState) error {
 	if lfunc == nil {
 		return ErrNotFound
 	}
-	initLua(l)
 	if ctx, ok := l.GetGlobal("ctx").(*lua.LUserData); ok {
 		if mtbl, ok := ctx.Metatable.(*lua.LTable); ok {
-			if ftbl, ok := mtbl.RawGetString("__index").(*lua.LTable); ok {
+			if ftbl, ok := mtbl.RawGetString("__index").(*lua.LTable); ok && ftbl.RawGetString("env") == lua.LNil {
 				ftbl.RawSetString("env", l.NewFunction(func(ll *lua.LState) int {
 					key := strings.ToLower(ll.CheckString(2))
 					if val, ok := w.env[key]; ok {
@@ -29,8 +29,11 @@ func TestLoadLuaFile(t *testing.T) {
 			"file": "webhook/github.lua",
 		},
 	}
-	l := loadWebhookPlugin(dir, opts)
+	l := loadWebhookPlugin(dir, opts, nil)
 	defer l.Close()
+	if wh, ok := l.webHooks["github"]; !ok || wh.kv == nil || wh.kv.ns != "webhook.github" {
+		t.Error("Check webhook kv failed")
+	}
 	if l.loadLuaFile("not_exist", dir) != nil {
 		t.Error("Check not exist lua file failed")
 	}
@@ -42,7 +45,7 @@ func TestLoadLuaFile(t *testing.T) {
 
 func TestLuaWatch(t *testing.T) {
 	opts := []map[string]interface{}{}
-	l := loadWebhookPlugin("", opts)
+	l := loadWebhookPlugin("", opts, nil)
 	defer l.Close()
 	l.watcher.Errors <- errors.New("123")
 }
@@ -0,0 +1,146 @@
+package logic
+
+import (
+	"encoding/hex"
+	"log"
+	"strings"
+
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+)
+
+// interruption levels from lowest to highest
+var policyLevels = map[string]int{
+	"passive":        int(pb.InterruptionLevel_IlPassive),
+	"active":         int(pb.InterruptionLevel_IlActive),
+	"time-sensitive": int(pb.InterruptionLevel_IlTimeSensitive),
+	"critical":       int(pb.InterruptionLevel_IlTimeSensitive) + 1,
+}
+
+// MessagePolicy limit notification options set by senders
+type MessagePolicy struct {
+	MaxPriority          int             // clamp priority, 0 for no limit
+	MaxInterruptionLevel string          // passive, active, time-sensitive or critical, empty for no limit
+	PassiveChannels      map[string]bool // user channels forced to passive
+	IgnoreSound          bool            // drop sound set by sender
+}
+
+func loadPolicies(opts map[string]interface{}) (*MessagePolicy, map[string]*MessagePolicy) {
+	tokens := map[string]*MessagePolicy{}
+	if len(opts) <= 0 {
+		return nil, tokens
+	}
+	node := parsePolicy(opts)
+	if node != nil {
+		log.Println("Message policy:", node.MaxPriority, node.MaxInterruptionLevel, node.IgnoreSound)
+	}
+	for _, item := range readOptList(opts, "tokens") {
+		hash, _ := readOptString(item, "hash")
+		hash = strings.ToLower(strings.TrimSpace(hash))
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) <= 0 {
+			log.Println("Invalid token hash for policy:", hash)
+			continue
+		}
+		if p := parsePolicy(item); p != nil {
+			tokens[hash] = p
+			log.Println("Message policy for token:", hash)
+		}
+	}
+	return node, tokens
+}
+
+func parsePolicy(opts map[string]interface{}) *MessagePolicy {
+	p := &MessagePolicy{PassiveChannels: map[string]bool{}}
+	if v, ok := opts["max-priority"].(int); ok && v > 0 {
+		p.MaxPriority = v
+	}
+	if level, _ := readOptString(opts, "max-interruption-level"); len(level) > 0 {
+		if _, ok := policyLevels[level]; ok {
+			p.MaxInterruptionLevel = level
+		} else {
+			log.Println("Invalid max interruption level for policy:", level)
+		}
+	}
+	for _, name := range readOptStrings(opts, "passive-channels") {
+		if len(name) > 0 {
+			p.PassiveChannels[name] = true
+		}
+	}
+	p.IgnoreSound, _ = opts["ignore-sound"].(bool)
+	if p.MaxPriority <= 0 && len(p.MaxInterruptionLevel) <= 0 && len(p.PassiveChannels) <= 0 && !p.IgnoreSound {
+		return nil
+	}
+	return p
+}
+
+// GetMessagePolicy return policy of node merged with policy of token, nil if no policy
+func (l *Logic) GetMessagePolicy(token *model.Token) *MessagePolicy {
+	tp := l.tokenPolicies[hex.EncodeToString(token.HashValue())]
+	if l.policy == nil {
+		return tp
+	}
+	return l.policy.merge(tp)
+}
+
+// merge return the stricter of both policies
+func (p *MessagePolicy) merge(o *MessagePolicy) *MessagePolicy {
+	if o == nil {
+		return p
+	}
+	res := &MessagePolicy{
+		MaxPriority:          p.MaxPriority,
+		MaxInterruptionLevel: p.MaxInterruptionLevel,
+		PassiveChannels:      map[string]bool{},
+		IgnoreSound:          p.IgnoreSound || o.IgnoreSound,
+	}
+	if o.MaxPriority > 0 && (res.MaxPriority <= 0 || o.MaxPriority < res.MaxPriority) {
+		res.MaxPriority = o.MaxPriority
+	}
+	if len(o.MaxInterruptionLevel) > 0 && (len(res.MaxInterruptionLevel) <= 0 || policyLevels[o.MaxInterruptionLevel] < policyLevels[res.MaxInterruptionLevel]) {
+		res.MaxInterruptionLevel = o.MaxInterruptionLevel
+	}
+	for _, chs := range []map[string]bool{p.PassiveChannels, o.PassiveChannels} {
+		for ch := range chs {
+			res.PassiveChannels[ch] = true
+		}
+	}
+	return res
+}
+
+// ApplySender drop options of sender before user channel defaults are applied
+func (p *MessagePolicy) ApplySender(msg *model.Message) {
+	if p != nil && p.IgnoreSound {
+		msg.DropSound()
+	}
+}
+
+// Apply clamp priority and interruption level of message
+func (p *MessagePolicy) Apply(msg *model.Message) {
+	if p == nil {
+		return
+	}
+	if p.MaxPriority > 0 {
+		priority := int(msg.Priority)
+		if priority <= 0 {
+			priority = 10 // default priority of apns
+		}
+		if priority > p.MaxPriority {
+			msg.Priority = int32(p.MaxPriority)
+		}
+	}
+	level := p.MaxInterruptionLevel
+	if p.PassiveChannels[msg.ChannelName()] {
+		level = "passive"
+	}
+	if len(level) <= 0 {
+		return
+	}
+	max := policyLevels[level]
+	if msg.IsCritical() && max < policyLevels["critical"] {
+		msg.Sound.Type = pb.SoundType_NormalSound
+		msg.Sound.Volume = 0
+	}
+	if int(msg.InterruptionLevel) > max {
+		msg.SetInterruptionLevel(level)
+	}
+}
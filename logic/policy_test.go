@@ -0,0 +1,99 @@
+package logic
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/chanify/chanify/model"
+	"github.com/chanify/chanify/pb"
+)
+
+func TestLoadPolicies(t *testing.T) {
+	if p, tokens := loadPolicies(nil); p != nil || len(tokens) != 0 {
+		t.Fatal("Check empty policies failed")
+	}
+	p, tokens := loadPolicies(map[string]interface{}{
+		"max-priority":           5,
+		"max-interruption-level": "active",
+		"passive-channels":       []interface{}{"logs", ""},
+		"tokens": []interface{}{
+			map[interface{}]interface{}{"hash": "ABCDEF", "ignore-sound": true},
+			map[string]interface{}{"hash": "xyz", "ignore-sound": true},
+			map[string]interface{}{"hash": "123456"},
+			map[string]interface{}{"ignore-sound": true},
+		},
+	})
+	if p == nil || p.MaxPriority != 5 || p.MaxInterruptionLevel != "active" || len(p.PassiveChannels) != 1 || !p.PassiveChannels["logs"] {
+		t.Fatal("Load node policy failed:", p)
+	}
+	if len(tokens) != 1 || tokens["abcdef"] == nil || !tokens["abcdef"].IgnoreSound {
+		t.Fatal("Load token policies failed:", tokens)
+	}
+	if p, _ := loadPolicies(map[string]interface{}{"max-interruption-level": "loud"}); p != nil {
+		t.Error("Check invalid interruption level policy failed:", p)
+	}
+}
+
+func TestMessagePolicy(t *testing.T) {
+	tk, _ := model.ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRw..c2lnbg")
+	hash := hex.EncodeToString(tk.HashValue())
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", Policy: map[string]interface{}{
+		"max-interruption-level": "time-sensitive",
+		"tokens": []interface{}{
+			map[string]interface{}{"hash": hash, "max-priority": 5, "max-interruption-level": "passive", "ignore-sound": true, "passive-channels": []string{"ci"}},
+		},
+	}})
+	defer l.Close()
+	if p := l.GetMessagePolicy(&model.Token{}); p == nil || p.MaxInterruptionLevel != "time-sensitive" || p.IgnoreSound {
+		t.Fatal("Get node policy failed:", p)
+	}
+	p := l.GetMessagePolicy(tk)
+	if p == nil || p.MaxPriority != 5 || p.MaxInterruptionLevel != "passive" || !p.IgnoreSound || !p.PassiveChannels["ci"] {
+		t.Fatal("Get merged policy failed:", p)
+	}
+	l2, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory"})
+	defer l2.Close()
+	if p := l2.GetMessagePolicy(tk); p != nil {
+		t.Fatal("Check no policy failed:", p)
+	}
+}
+
+func TestApplyMessagePolicy(t *testing.T) {
+	var p *MessagePolicy
+	msg := model.NewMessage(&model.Token{}).SoundName("bell").SetInterruptionLevel("time-sensitive")
+	p.ApplySender(msg)
+	p.Apply(msg)
+	if msg.Sound == nil || msg.InterruptionLevel != pb.InterruptionLevel_IlTimeSensitive {
+		t.Fatal("Check nil policy failed")
+	}
+	p = &MessagePolicy{IgnoreSound: true}
+	if p.ApplySender(msg); msg.Sound != nil {
+		t.Error("Check ignore sound failed")
+	}
+	p = &MessagePolicy{MaxPriority: 5, MaxInterruptionLevel: "active"}
+	msg = model.NewMessage(&model.Token{}).SetInterruptionLevel("time-sensitive")
+	if p.Apply(msg); msg.Priority != 5 || msg.InterruptionLevel != pb.InterruptionLevel_IlActive {
+		t.Error("Check clamp policy failed:", msg.Priority, msg.InterruptionLevel)
+	}
+	msg = model.NewMessage(&model.Token{}).SetPriority(1).SetInterruptionLevel("passive")
+	if p.Apply(msg); msg.Priority != 1 || msg.InterruptionLevel != pb.InterruptionLevel_IlPassive {
+		t.Error("Check lower level policy failed:", msg.Priority, msg.InterruptionLevel)
+	}
+	msg = model.NewMessage(&model.Token{}).SetCritical(true, 0.5)
+	if p.Apply(msg); msg.IsCritical() || msg.Sound.Volume != 0 {
+		t.Error("Check critical policy failed:", msg.Sound)
+	}
+	msg = model.NewMessage(&model.Token{}).SetCritical(true, 0.5)
+	if (&MessagePolicy{MaxInterruptionLevel: "critical"}).Apply(msg); !msg.IsCritical() {
+		t.Error("Check allow critical policy failed:", msg.Sound)
+	}
+	p = &MessagePolicy{PassiveChannels: map[string]bool{"logs": true}}
+	msg = model.NewMessage(&model.Token{}).SetChannelName("logs")
+	if p.Apply(msg); msg.InterruptionLevel != pb.InterruptionLevel_IlPassive {
+		t.Error("Check passive channel policy failed:", msg.InterruptionLevel)
+	}
+	msg = model.NewMessage(&model.Token{}).SetChannelName("alerts").SetInterruptionLevel("time-sensitive")
+	if p.Apply(msg); msg.InterruptionLevel != pb.InterruptionLevel_IlTimeSensitive {
+		t.Error("Check other channel policy failed:", msg.InterruptionLevel)
+	}
+}
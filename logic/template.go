@@ -0,0 +1,121 @@
+package logic
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"text/template"
+)
+
+// Template for message
+type Template struct {
+	name    string
+	title   *template.Template
+	text    *template.Template
+	sound   *template.Template
+	format  string
+	actions []*template.Template
+}
+
+// TemplateResult is rendered message fields
+type TemplateResult struct {
+	Title   string
+	Text    string
+	Sound   string
+	Format  string
+	Actions []string
+}
+
+func loadTemplates(tplOpts []map[string]interface{}) map[string]*Template {
+	tpls := map[string]*Template{}
+	for _, opts := range tplOpts {
+		name, _ := readOptString(opts, "name")
+		name = strings.ToLower(name)
+		if len(name) <= 0 {
+			continue
+		}
+		if _, ok := tpls[name]; ok {
+			log.Println("Template conflict:", name)
+			continue
+		}
+		tpl, err := newTemplate(name, opts)
+		if err != nil {
+			log.Println("Load template failed:", name, err)
+			continue
+		}
+		tpls[name] = tpl
+		log.Println("Load template:", name)
+	}
+	return tpls
+}
+
+func newTemplate(name string, opts map[string]interface{}) (*Template, error) {
+	t := &Template{name: name}
+	t.format, _ = readOptString(opts, "format")
+	var err error
+	if t.title, err = parseTemplateOpt(name+".title", opts, "title"); err != nil {
+		return nil, err
+	}
+	if t.text, err = parseTemplateOpt(name+".text", opts, "text"); err != nil {
+		return nil, err
+	}
+	if t.sound, err = parseTemplateOpt(name+".sound", opts, "sound"); err != nil {
+		return nil, err
+	}
+	if acts, ok := opts["actions"].([]interface{}); ok {
+		for _, act := range acts {
+			if s, ok := act.(string); ok {
+				tpl, err := template.New(name + ".action").Option("missingkey=error").Parse(s)
+				if err != nil {
+					return nil, err
+				}
+				t.actions = append(t.actions, tpl)
+			}
+		}
+	}
+	return t, nil
+}
+
+func parseTemplateOpt(name string, opts map[string]interface{}, key string) (*template.Template, error) {
+	if value, ok := readOptString(opts, key); ok && len(value) > 0 {
+		return template.New(name).Option("missingkey=error").Parse(value)
+	}
+	return nil, nil
+}
+
+// Render template with variables
+func (t *Template) Render(vars map[string]interface{}) (*TemplateResult, error) {
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	res := &TemplateResult{Format: t.format}
+	var err error
+	if res.Title, err = executeTemplate(t.title, vars); err != nil {
+		return nil, err
+	}
+	if res.Text, err = executeTemplate(t.text, vars); err != nil {
+		return nil, err
+	}
+	if res.Sound, err = executeTemplate(t.sound, vars); err != nil {
+		return nil, err
+	}
+	for _, tpl := range t.actions {
+		act, err := executeTemplate(tpl, vars)
+		if err != nil {
+			return nil, err
+		}
+		res.Actions = append(res.Actions, act)
+	}
+	return res, nil
+}
+
+func executeTemplate(tpl *template.Template, vars map[string]interface{}) (string, error) {
+	if tpl == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,37 @@
+package logic
+
+import "testing"
+
+func TestTemplate(t *testing.T) {
+	tpls := loadTemplates([]map[string]interface{}{
+		{"name": ""},
+		{"name": "Deploy", "title": "{{.app}} deployed", "text": "version {{.version}}", "sound": "{{if .ok}}bell{{end}}", "format": "markdown", "actions": []interface{}{"Open|https://ci/{{.app}}", 123}},
+		{"name": "deploy", "text": "conflict"},
+		{"name": "bad", "text": "{{.abc"},
+		{"name": "badact", "actions": []interface{}{"{{"}},
+	})
+	if len(tpls) != 1 {
+		t.Fatal("Load templates failed:", len(tpls))
+	}
+	res, err := tpls["deploy"].Render(map[string]interface{}{"app": "web", "version": "1.0", "ok": true})
+	if err != nil {
+		t.Fatal("Render template failed:", err)
+	}
+	if res.Title != "web deployed" || res.Text != "version 1.0" || res.Sound != "bell" || res.Format != "markdown" || len(res.Actions) != 1 || res.Actions[0] != "Open|https://ci/web" {
+		t.Error("Check rendered template failed:", res)
+	}
+	if _, err := tpls["deploy"].Render(nil); err == nil {
+		t.Error("Check missing template variable failed")
+	}
+}
+
+func TestGetTemplate(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory", Templates: []map[string]interface{}{{"name": "abc", "text": "123"}}})
+	defer l.Close()
+	if _, err := l.GetTemplate("ABC"); err != nil {
+		t.Error("Get template failed:", err)
+	}
+	if _, err := l.GetTemplate("xyz"); err != ErrNotFound {
+		t.Error("Check template not found failed")
+	}
+}
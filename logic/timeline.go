@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"time"
+
+	"github.com/chanify/chanify/model"
+)
+
+// timeline history limits
+const (
+	TimelineHistoryExpires = 30 * 24 * time.Hour
+	TimelineMaxPoints      = 1000
+)
+
+// TimelinePoint is history point of timeline code
+type TimelinePoint struct {
+	Timestamp int64                `json:"timestamp"`
+	Items     []*model.MsgTimeItem `json:"items"`
+}
+
+// SaveTimeline record point of timeline message into history, drop points older than expires or out of ttl
+func (l *Logic) SaveTimeline(uid string, msg *model.Message) error {
+	p := msg.TimelinePoint()
+	if p == nil {
+		return nil
+	}
+	now := time.Now()
+	if ttl, ok := msg.TTL(); ok {
+		if ttl <= 0 {
+			return nil
+		}
+		p.Expires = now.Add(ttl).UnixNano() / 1e6
+	}
+	expires := now.Add(-TimelineHistoryExpires).UnixNano() / 1e6
+	return l.db.AddTimelinePoint(uid, p, expires, now.UnixNano()/1e6)
+}
+
+// GetTimelineCodes return timeline codes in history of user
+func (l *Logic) GetTimelineCodes(uid string) ([]string, error) {
+	return l.db.GetTimelineCodes(uid, time.Now().UnixNano()/1e6)
+}
+
+// GetTimeline return latest points of timeline code between since and until (ms), until 0 means now
+func (l *Logic) GetTimeline(uid string, code string, since int64, until int64, limit int) ([]*TimelinePoint, error) {
+	now := time.Now().UnixNano() / 1e6
+	if until <= 0 {
+		until = now
+	}
+	if limit <= 0 || limit > TimelineMaxPoints {
+		limit = TimelineMaxPoints
+	}
+	pts, err := l.db.GetTimelinePoints(uid, code, since, until, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := []*TimelinePoint{}
+	for _, p := range pts {
+		items, err := p.Items()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &TimelinePoint{Timestamp: p.Timestamp, Items: items})
+	}
+	return res, nil
+}
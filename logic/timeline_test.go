@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chanify/chanify/model"
+)
+
+func TestTimeline(t *testing.T) {
+	l, _ := NewLogic(&Options{DBUrl: "sqlite://?mode=memory"})
+	defer l.Close()
+	if err := l.SaveTimeline("abc", model.NewMessage(&model.Token{}).TextContent("hello", "", "", "")); err != nil {
+		t.Fatal("Check save text timeline failed:", err)
+	}
+	old := time.Now().Add(-TimelineHistoryExpires - time.Hour)
+	now := time.Now()
+	l.SaveTimeline("abc", model.NewMessage(&model.Token{}).TimelineContent("cpu", "", &old, []*model.MsgTimeItem{{Name: "load", Value: 1}})) // nolint: errcheck
+	if err := l.SaveTimeline("abc", model.NewMessage(&model.Token{}).TimelineContent("cpu", "", &now, []*model.MsgTimeItem{{Name: "load", Value: 2.5, Unit: "%"}})); err != nil {
+		t.Fatal("Save timeline failed:", err)
+	}
+	if codes, err := l.GetTimelineCodes("abc"); err != nil || len(codes) != 1 || codes[0] != "cpu" {
+		t.Fatal("Get timeline codes failed:", codes, err)
+	}
+	pts, err := l.GetTimeline("abc", "cpu", 0, 0, 0)
+	if err != nil || len(pts) != 1 || len(pts[0].Items) != 1 || pts[0].Items[0].Value != 2.5 || pts[0].Items[0].Unit != "%" {
+		t.Fatal("Get timeline failed:", pts, err)
+	}
+	if err := l.SaveTimeline("abc", model.NewMessage(&model.Token{}).TimelineContent("disk", "", &now, []*model.MsgTimeItem{{Name: "used", Value: 90}}).SetTTL(0)); err != nil {
+		t.Fatal("Save timeline deliver now failed:", err)
+	}
+	l.SaveTimeline("abc", model.NewMessage(&model.Token{}).TimelineContent("mem", "", &now, []*model.MsgTimeItem{{Name: "used", Value: 80}}).SetTTL(time.Hour)) // nolint: errcheck
+	if codes, err := l.GetTimelineCodes("abc"); err != nil || len(codes) != 2 || codes[0] != "cpu" || codes[1] != "mem" {
+		t.Fatal("Check timeline codes with ttl failed:", codes, err)
+	}
+	l.db.AddTimelinePoint("abc", &model.TimelinePoint{Code: "bad", Timestamp: 1, Data: []byte{0xff}}, 0, 0) // nolint: errcheck
+	if _, err := l.GetTimeline("abc", "bad", 0, 0, 10); err == nil {
+		t.Fatal("Check invalid timeline point failed")
+	}
+	l2, _ := NewLogic(&Options{DBUrl: "nosql://?secret=123"})
+	defer l2.Close()
+	if _, err := l2.GetTimeline("abc", "cpu", 0, 0, 10); err != model.ErrNotImplemented {
+		t.Fatal("Check get timeline not implemented failed:", err)
+	}
+}
@@ -0,0 +1,30 @@
+package logic
+
+import (
+	"strings"
+	"time"
+)
+
+// message ttl limits
+const (
+	TTLDefault = 24 * time.Hour
+	TTLMax     = 30 * 24 * time.Hour
+)
+
+// ParseTTL parse ttl from duration (e.g. 1h) or seconds, 0 means deliver now or drop, false if not set or invalid
+func ParseTTL(ttl string) (time.Duration, bool) {
+	ttl = strings.TrimSpace(ttl)
+	if d, err := time.ParseDuration(ttl); ttl == "0" || (err == nil && d == 0) {
+		return 0, true
+	}
+	d := parseDuration(ttl, time.Second, TTLMax)
+	return d, d > 0
+}
+
+// apnsExpiration return expiration of notification, apns tries to deliver only once if ttl is 0
+func apnsExpiration(ttl time.Duration, now time.Time) time.Time {
+	if ttl <= 0 {
+		return time.Unix(0, 0)
+	}
+	return now.Add(ttl)
+}
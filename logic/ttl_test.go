@@ -0,0 +1,36 @@
+package logic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTTL(t *testing.T) {
+	tests := map[string]time.Duration{
+		"0":    0,
+		"0s":   0,
+		"90":   90 * time.Second,
+		"5m":   5 * time.Minute,
+		"999h": TTLMax,
+	}
+	for s, want := range tests {
+		if d, ok := ParseTTL(s); !ok || d != want {
+			t.Error("Parse ttl failed:", s, d, ok)
+		}
+	}
+	for _, s := range []string{"", " ", "abc", "-5m", "100d"} {
+		if _, ok := ParseTTL(s); ok {
+			t.Error("Check invalid ttl failed:", s)
+		}
+	}
+}
+
+func TestAPNSExpiration(t *testing.T) {
+	now := time.Now()
+	if exp := apnsExpiration(0, now); exp.IsZero() || exp.Unix() != 0 {
+		t.Error("Check deliver now expiration failed:", exp)
+	}
+	if exp := apnsExpiration(time.Hour, now); !exp.Equal(now.Add(time.Hour)) {
+		t.Error("Check expiration failed:", exp)
+	}
+}
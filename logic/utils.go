@@ -2,7 +2,11 @@ package logic
 
 import (
 	"bufio"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	lua "github.com/yuin/gopher-lua"
 	parse "github.com/yuin/gopher-lua/parse"
@@ -29,6 +33,32 @@ func saveFile(path string, data []byte) error {
 	return nil
 }
 
+// parseDuration parse duration (e.g. 5m) or seconds and clamp into [min, max], 0 if invalid
+func parseDuration(value string, min time.Duration, max time.Duration) time.Duration {
+	value = strings.TrimSpace(value)
+	if len(value) <= 0 {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0
+		}
+		d = time.Duration(n) * time.Second
+	}
+	if d <= 0 {
+		return 0
+	}
+	if d < min {
+		return min
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
 func readOptString(opts map[string]interface{}, key string) (string, bool) {
 	if value, ok := opts[key]; ok {
 		if val, ok := value.(string); ok {
@@ -58,6 +88,71 @@ func readOptTable(opts map[string]interface{}, key string) map[string]interface{
 	return m
 }
 
+func readOptList(opts map[string]interface{}, key string) []map[string]interface{} {
+	lst := []map[string]interface{}{}
+	switch val := opts[key].(type) {
+	case []map[string]interface{}:
+		lst = append(lst, val...)
+	case []interface{}:
+		for i := range val {
+			if m := readOptTable(map[string]interface{}{key: val[i]}, key); len(m) > 0 {
+				lst = append(lst, m)
+			}
+		}
+	}
+	return lst
+}
+
+func readOptStrings(opts map[string]interface{}, key string) []string {
+	lst := []string{}
+	switch val := opts[key].(type) {
+	case []string:
+		lst = append(lst, val...)
+	case []interface{}:
+		for _, v := range val {
+			if s, ok := v.(string); ok {
+				lst = append(lst, s)
+			}
+		}
+	}
+	return lst
+}
+
+func appendAllowHost(hosts []string, host string) []string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if len(host) > 0 {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// checkAllowHost check http(s) url against allowed hosts (host, host:port or *.domain), nothing is allowed if hosts is empty
+func checkAllowHost(hosts []string, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrInvalidScheme
+	}
+	host := strings.ToLower(u.Host)
+	hostname := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		if h == host || h == hostname {
+			return nil
+		}
+		if strings.HasPrefix(h, "*.") && strings.HasSuffix(hostname, h[1:]) {
+			return nil
+		}
+	}
+	return ErrHostNotAllowed
+}
+
+func containsString(items []string, item string) bool {
+	for _, v := range items {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
 func compileLua(filePath string) (*lua.FunctionProto, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -0,0 +1,89 @@
+package logic
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// WebhookContext is the request context for native webhook handler
+type WebhookContext interface {
+	Env(key string) interface{}
+	Token() string
+	URL() *url.URL
+	Body() []byte
+	Query(key string) (string, bool)
+	Header(key string) string
+	Send(msg *WebhookMessage) (string, error)
+}
+
+// WebhookSendError is returned by WebhookContext.Send with status code and response of sender
+type WebhookSendError struct {
+	Code     int
+	Response string
+}
+
+func (e *WebhookSendError) Error() string {
+	return e.Response
+}
+
+// WebhookHandler is native webhook handler, return status code and response body
+type WebhookHandler interface {
+	ServeWebhook(ctx WebhookContext) (int, string, error)
+}
+
+// WebhookHandlerFunc is an adapter to use function as webhook handler
+type WebhookHandlerFunc func(ctx WebhookContext) (int, string, error)
+
+// ServeWebhook calls f(ctx)
+func (f WebhookHandlerFunc) ServeWebhook(ctx WebhookContext) (int, string, error) {
+	return f(ctx)
+}
+
+// WebhookMessage is message sent by native webhook handler
+type WebhookMessage struct {
+	Token             string
+	Title             string
+	Text              string
+	Format            string
+	Link              string
+	Sound             string
+	CopyText          string
+	AutoCopy          string
+	Template          string
+	Vars              map[string]interface{}
+	Actions           []string
+	Priority          int
+	InterruptionLevel string
+	Critical          bool    // critical alert, requires entitled client
+	Volume            float64 // volume of critical alert, 0.0 to 1.0
+}
+
+var (
+	webhookHandlerMutex sync.RWMutex
+	webhookHandlers     = map[string]WebhookHandler{}
+)
+
+// RegisterWebhookHandler register native webhook handler with name, use `handler: <name>` in webhook config
+func RegisterWebhookHandler(name string, handler WebhookHandler) {
+	webhookHandlerMutex.Lock()
+	defer webhookHandlerMutex.Unlock()
+	webhookHandlers[strings.ToLower(name)] = handler
+}
+
+func getWebhookHandler(name string) (WebhookHandler, bool) {
+	webhookHandlerMutex.RLock()
+	defer webhookHandlerMutex.RUnlock()
+	handler, ok := webhookHandlers[strings.ToLower(name)]
+	return handler, ok
+}
+
+// Handler return native handler, nil for lua webhook
+func (w *Webhook) Handler() WebhookHandler {
+	return w.handler
+}
+
+// Env return webhook env value with key
+func (w *Webhook) Env(key string) interface{} {
+	return w.env[strings.ToLower(key)]
+}
@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidPayload is returned when webhook payload can not be decoded
+var ErrInvalidPayload = errors.New("invalid webhook payload")
+
+type alertmanagerAlert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type alertmanagerPayload struct {
+	Status       string              `json:"status"`
+	GroupLabels  map[string]string   `json:"groupLabels"`
+	CommonLabels map[string]string   `json:"commonLabels"`
+	Alerts       []alertmanagerAlert `json:"alerts"`
+}
+
+func init() {
+	RegisterWebhookHandler("alertmanager", WebhookHandlerFunc(serveAlertmanager))
+}
+
+// serveAlertmanager is native version of plugin/webhook/alertmanager.lua
+func serveAlertmanager(ctx WebhookContext) (int, string, error) {
+	var data alertmanagerPayload
+	if err := json.Unmarshal(ctx.Body(), &data); err != nil || data.Alerts == nil {
+		return 0, "", ErrInvalidPayload
+	}
+	if len(data.Status) <= 0 {
+		data.Status = "firing"
+	}
+	name := data.GroupLabels["alertname"]
+	if len(name) <= 0 {
+		name = data.CommonLabels["alertname"]
+		if len(name) <= 0 {
+			name = "Alert"
+		}
+	}
+	severity := data.CommonLabels["severity"]
+	firing := 0
+	lines := []string{}
+	for _, alert := range data.Alerts {
+		if alert.Status == "firing" {
+			firing++
+		}
+		if len(severity) <= 0 {
+			severity = alert.Labels["severity"]
+		}
+		line := alert.Annotations["summary"]
+		if len(line) <= 0 {
+			line = alert.Annotations["description"]
+			if len(line) <= 0 {
+				line = alert.Labels["alertname"]
+			}
+		}
+		if instance, ok := alert.Labels["instance"]; ok {
+			line = fmt.Sprintf("%s (%s)", line, instance)
+		}
+		status := alert.Status
+		if len(status) <= 0 {
+			status = data.Status
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s", strings.ToUpper(status), line))
+	}
+	resolved := data.Status == "resolved"
+	title := fmt.Sprintf("[FIRING:%d] %s", firing, name)
+	if resolved {
+		title = "[RESOLVED] " + name
+	}
+	priority, level := severityLevel(severity, resolved)
+	sound, _ := ctx.Query("sound")
+	ret, err := ctx.Send(&WebhookMessage{
+		Title:             title,
+		Text:              strings.Join(lines, "\n"),
+		Sound:             sound,
+		Priority:          priority,
+		InterruptionLevel: level,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return http.StatusOK, ret, nil
+}
+
+// severityLevel map alert severity to notification priority and interruption level
+func severityLevel(severity string, resolved bool) (int, string) {
+	if resolved {
+		return 5, "passive"
+	}
+	switch strings.ToLower(severity) {
+	case "critical", "fatal", "error", "high", "page":
+		return 10, "time-sensitive"
+	case "info", "low", "debug", "none":
+		return 5, "passive"
+	}
+	return 10, "active"
+}
@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testWebhookContext struct {
+	body    string
+	query   url.Values
+	headers map[string]string
+	env     map[string]interface{}
+	sent    *WebhookMessage
+	err     error
+}
+
+func (c *testWebhookContext) Env(key string) interface{} { return c.env[key] }
+func (c *testWebhookContext) Token() string              { return "token" }
+func (c *testWebhookContext) URL() *url.URL              { return &url.URL{RawQuery: c.query.Encode()} }
+func (c *testWebhookContext) Body() []byte               { return []byte(c.body) }
+func (c *testWebhookContext) Header(key string) string   { return c.headers[key] }
+func (c *testWebhookContext) Query(key string) (string, bool) {
+	v, ok := c.query[key]
+	if !ok {
+		return "", false
+	}
+	return v[0], true
+}
+func (c *testWebhookContext) Send(msg *WebhookMessage) (string, error) {
+	c.sent = msg
+	if c.err != nil {
+		return "", c.err
+	}
+	return `{"request-uid":"test"}`, nil
+}
+
+func TestRegisterWebhookHandler(t *testing.T) {
+	RegisterWebhookHandler("Test-Handler", WebhookHandlerFunc(func(ctx WebhookContext) (int, string, error) {
+		return http.StatusAccepted, ctx.Token(), nil
+	}))
+	defer func() {
+		webhookHandlerMutex.Lock()
+		delete(webhookHandlers, "test-handler")
+		webhookHandlerMutex.Unlock()
+	}()
+	h, ok := getWebhookHandler("test-handler")
+	if !ok {
+		t.Fatal("Get webhook handler failed")
+	}
+	if code, ret, err := h.ServeWebhook(&testWebhookContext{}); err != nil || code != http.StatusAccepted || ret != "token" {
+		t.Error("Serve webhook handler failed")
+	}
+	if _, ok := getWebhookHandler("alertmanager"); !ok {
+		t.Error("Check builtin webhook handler failed")
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.lua")
+	os.WriteFile(file, []byte("return 200"), 0644) // nolint: errcheck
+	opts := []map[string]interface{}{
+		{"name": "native", "handler": "TEST-HANDLER", "env": map[string]interface{}{"Secret": "123"}},
+		{"name": "native", "file": file},
+		{"name": "missing", "handler": "not-found"},
+		{"name": "script", "file": file},
+		{"name": "none"},
+	}
+	p := loadWebhookPlugin(dir, opts, nil)
+	defer p.Close()
+	wh, err := p.GetWebhook("native")
+	if err != nil || wh.Handler() == nil || wh.lfunc != nil || wh.Env("SECRET") != "123" {
+		t.Fatal("Check native webhook failed")
+	}
+	if code, _, _ := wh.Handler().ServeWebhook(&testWebhookContext{}); code != http.StatusAccepted {
+		t.Error("Check native webhook handler failed")
+	}
+	if _, err := p.GetWebhook("missing"); err != ErrNotFound {
+		t.Error("Check missing webhook handler failed")
+	}
+	if _, err := p.GetWebhook("none"); err != ErrNotFound {
+		t.Error("Check empty webhook failed")
+	}
+	if wh, err := p.GetWebhook("script"); err != nil || wh.Handler() != nil {
+		t.Error("Check lua webhook failed")
+	}
+}
+
+func TestServeAlertmanager(t *testing.T) {
+	ctx := &testWebhookContext{
+		query: url.Values{"sound": []string{"1"}},
+		body: `{"status":"firing","groupLabels":{"alertname":"HighCPU"},"commonLabels":{"severity":"critical"},"alerts":[
+			{"status":"firing","labels":{"instance":"web-1:9100"},"annotations":{"summary":"CPU usage above 90%"}},
+			{"status":"firing","labels":{"alertname":"HighCPU","instance":"web-2:9100"},"annotations":{}}]}`,
+	}
+	code, ret, err := serveAlertmanager(ctx)
+	if err != nil || code != http.StatusOK || !strings.Contains(ret, "request-uid") {
+		t.Fatal("Serve alertmanager failed:", err)
+	}
+	msg := ctx.sent
+	if msg.Title != "[FIRING:2] HighCPU" || msg.Text != "[FIRING] CPU usage above 90% (web-1:9100)\n[FIRING] HighCPU (web-2:9100)" || msg.Sound != "1" {
+		t.Error("Check alertmanager message failed:", msg.Title, msg.Text)
+	}
+	if msg.Priority != 10 || msg.InterruptionLevel != "time-sensitive" {
+		t.Error("Check alertmanager severity failed")
+	}
+
+	ctx = &testWebhookContext{body: `{"status":"resolved","commonLabels":{"alertname":"DiskFull","severity":"warning"},"alerts":[{"labels":{},"annotations":{"description":"Disk usage back to normal"}}]}`}
+	if _, _, err := serveAlertmanager(ctx); err != nil {
+		t.Fatal("Serve resolved alertmanager failed:", err)
+	}
+	if ctx.sent.Title != "[RESOLVED] DiskFull" || ctx.sent.Text != "[RESOLVED] Disk usage back to normal" || ctx.sent.Priority != 5 || ctx.sent.InterruptionLevel != "passive" {
+		t.Error("Check resolved alertmanager message failed:", ctx.sent.Title, ctx.sent.Text)
+	}
+
+	if _, _, err := serveAlertmanager(&testWebhookContext{body: `{}`}); err != ErrInvalidPayload {
+		t.Error("Check alertmanager payload failed:", err)
+	}
+	errSend := errors.New("send failed")
+	if _, _, err := serveAlertmanager(&testWebhookContext{body: `{"alerts":[]}`, err: errSend}); err != errSend {
+		t.Error("Check alertmanager send failed:", err)
+	}
+}
+
+func TestSeverityLevel(t *testing.T) {
+	tests := map[string]string{"Critical": "time-sensitive", "warning": "active", "": "active", "info": "passive"}
+	for severity, level := range tests {
+		if _, l := severityLevel(severity, false); l != level {
+			t.Error("Check severity level failed:", severity, l)
+		}
+	}
+	if p, l := severityLevel("critical", true); p != 5 || l != "passive" {
+		t.Error("Check resolved severity level failed")
+	}
+}
@@ -0,0 +1,113 @@
+package logic
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// ErrWebhookUnauthorized is returned when webhook request signature verification failed
+var ErrWebhookUnauthorized = errors.New("webhook unauthorized")
+
+type webhookVerify struct {
+	kind     string
+	header   string
+	prefix   string
+	encoding string
+	secret   []byte
+}
+
+// newWebhookVerify create request verifier from `verify` option, secret is read from webhook env
+func newWebhookVerify(opts map[string]interface{}, env map[string]interface{}) (*webhookVerify, error) {
+	v := &webhookVerify{}
+	v.kind, _ = readOptString(opts, "type")
+	v.kind = strings.ToLower(v.kind)
+	v.header, _ = readOptString(opts, "header")
+	v.prefix, _ = readOptString(opts, "prefix")
+	v.encoding, _ = readOptString(opts, "encoding")
+	v.encoding = strings.ToLower(v.encoding)
+	switch v.kind {
+	case "hmac-sha1", "hmac-sha256", "hmac-sha512":
+		if len(v.header) <= 0 {
+			return nil, fmt.Errorf("verify header required for %s", v.kind)
+		}
+		if len(v.encoding) <= 0 {
+			v.encoding = "hex"
+		}
+		if v.encoding != "hex" && v.encoding != "base64" {
+			return nil, fmt.Errorf("unsupported verify encoding: %s", v.encoding)
+		}
+	case "basic":
+		v.header = "Authorization"
+		v.prefix = "Basic "
+	case "bearer":
+		if len(v.header) <= 0 {
+			v.header = "Authorization"
+			v.prefix = "Bearer "
+		}
+	default:
+		return nil, fmt.Errorf("unsupported verify type: %s", v.kind)
+	}
+	key, _ := readOptString(opts, "secret_env")
+	secret, _ := env[strings.ToLower(key)].(string)
+	if len(secret) <= 0 {
+		return nil, fmt.Errorf("verify secret not found in env: %s", key)
+	}
+	if v.kind == "basic" {
+		secret = base64.StdEncoding.EncodeToString([]byte(secret))
+	}
+	v.secret = []byte(secret)
+	return v, nil
+}
+
+// Verify request with header and body
+func (v *webhookVerify) Verify(header func(key string) string, body []byte) bool {
+	value := header(v.header)
+	if len(value) <= len(v.prefix) || !strings.EqualFold(value[:len(v.prefix)], v.prefix) {
+		return false
+	}
+	value = value[len(v.prefix):]
+	switch v.kind {
+	case "basic", "bearer":
+		return subtle.ConstantTimeCompare([]byte(value), v.secret) == 1
+	}
+	var sign []byte
+	var err error
+	if v.encoding == "base64" {
+		sign, err = base64.StdEncoding.DecodeString(value)
+	} else {
+		sign, err = hex.DecodeString(value)
+	}
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(v.hash(), v.secret)
+	mac.Write(body) // nolint: errcheck
+	return hmac.Equal(mac.Sum(nil), sign)
+}
+
+func (v *webhookVerify) hash() func() hash.Hash {
+	switch v.kind {
+	case "hmac-sha1":
+		return sha1.New
+	case "hmac-sha512":
+		return sha512.New
+	}
+	return sha256.New
+}
+
+// Verify webhook request before running script or handler, nil if no verify configured
+func (w *Webhook) Verify(header func(key string) string, body []byte) error {
+	if w.verify != nil && !w.verify.Verify(header, body) {
+		return ErrWebhookUnauthorized
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package logic
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestWebhookVerifyHmac(t *testing.T) {
+	env := map[string]interface{}{"secret_token": "abc"}
+	body := []byte(`{"hello":"world"}`)
+	mac := hmac.New(sha256.New, []byte("abc"))
+	mac.Write(body) // nolint: errcheck
+	sign := mac.Sum(nil)
+	v, err := newWebhookVerify(map[string]interface{}{"type": "hmac-sha256", "header": "X-Hub-Signature-256", "prefix": "sha256=", "secret_env": "SECRET_TOKEN"}, env)
+	if err != nil {
+		t.Fatal("Create hmac verify failed:", err)
+	}
+	tests := map[string]bool{
+		"sha256=" + hex.EncodeToString(sign): true,
+		"SHA256=" + hex.EncodeToString(sign): true,
+		hex.EncodeToString(sign):             false,
+		"sha256=" + hex.EncodeToString(body): false,
+		"sha256=xyz":                         false,
+		"sha256=":                            false,
+		"":                                   false,
+	}
+	for value, ret := range tests {
+		h := http.Header{}
+		h.Set("X-Hub-Signature-256", value)
+		if v.Verify(h.Get, body) != ret {
+			t.Error("Check hmac verify failed:", value)
+		}
+	}
+	v, _ = newWebhookVerify(map[string]interface{}{"type": "hmac-sha256", "header": "X-Sign", "encoding": "base64", "secret_env": "secret_token"}, env)
+	h := http.Header{}
+	h.Set("X-Sign", base64.StdEncoding.EncodeToString(sign))
+	if !v.Verify(h.Get, body) {
+		t.Error("Check hmac base64 verify failed")
+	}
+	h.Set("X-Sign", "!")
+	if v.Verify(h.Get, body) {
+		t.Error("Check hmac invalid base64 verify failed")
+	}
+	mac = hmac.New(sha1.New, []byte("abc"))
+	mac.Write(body) // nolint: errcheck
+	h.Set("X-Sign", hex.EncodeToString(mac.Sum(nil)))
+	v, _ = newWebhookVerify(map[string]interface{}{"type": "hmac-sha1", "header": "X-Sign", "secret_env": "secret_token"}, env)
+	if !v.Verify(h.Get, body) {
+		t.Error("Check hmac sha1 verify failed")
+	}
+	v, _ = newWebhookVerify(map[string]interface{}{"type": "hmac-sha512", "header": "X-Sign", "secret_env": "secret_token"}, env)
+	if v.Verify(h.Get, body) {
+		t.Error("Check hmac sha512 verify failed")
+	}
+}
+
+func TestWebhookVerifyAuth(t *testing.T) {
+	env := map[string]interface{}{"user": "admin:pass", "token": "abc"}
+	v, err := newWebhookVerify(map[string]interface{}{"type": "basic", "secret_env": "USER"}, env)
+	if err != nil {
+		t.Fatal("Create basic verify failed:", err)
+	}
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.SetBasicAuth("admin", "pass")
+	if !v.Verify(req.Header.Get, nil) {
+		t.Error("Check basic verify failed")
+	}
+	req.SetBasicAuth("admin", "abc")
+	if v.Verify(req.Header.Get, nil) {
+		t.Error("Check basic verify invalid password failed")
+	}
+	v, _ = newWebhookVerify(map[string]interface{}{"type": "bearer", "secret_env": "token"}, env)
+	req.Header.Set("Authorization", "Bearer abc")
+	if !v.Verify(req.Header.Get, nil) {
+		t.Error("Check bearer verify failed")
+	}
+	req.Header.Set("Authorization", "Bearer abcd")
+	if v.Verify(req.Header.Get, nil) {
+		t.Error("Check bearer verify invalid token failed")
+	}
+	v, _ = newWebhookVerify(map[string]interface{}{"type": "bearer", "header": "X-Gitlab-Token", "secret_env": "token"}, env)
+	req.Header.Set("X-Gitlab-Token", "abc")
+	if !v.Verify(req.Header.Get, nil) {
+		t.Error("Check bearer header verify failed")
+	}
+}
+
+func TestWebhookVerifyInvalid(t *testing.T) {
+	env := map[string]interface{}{"token": "abc", "number": 123}
+	tests := []map[string]interface{}{
+		{"type": "unknown", "secret_env": "token"},
+		{"type": "hmac-sha256", "secret_env": "token"},
+		{"type": "hmac-sha256", "header": "X-Sign", "encoding": "raw", "secret_env": "token"},
+		{"type": "bearer"},
+		{"type": "bearer", "secret_env": "none"},
+		{"type": "bearer", "secret_env": "number"},
+	}
+	for _, opts := range tests {
+		if _, err := newWebhookVerify(opts, env); err == nil {
+			t.Error("Check invalid verify failed:", opts)
+		}
+	}
+}
+
+func TestWebhookVerifyPlugin(t *testing.T) {
+	opts := []map[string]interface{}{
+		{"name": "ok", "handler": "alertmanager", "env": map[string]interface{}{"Token": "abc"}, "verify": map[interface{}]interface{}{"type": "bearer", "secret_env": "TOKEN"}},
+		{"name": "bad", "handler": "alertmanager", "verify": map[string]interface{}{"type": "bearer", "secret_env": "TOKEN"}},
+		{"name": "none", "handler": "alertmanager"},
+	}
+	l := loadWebhookPlugin("", opts, nil)
+	defer l.Close()
+	if _, err := l.GetWebhook("bad"); err == nil {
+		t.Error("Check webhook with invalid verify failed")
+	}
+	wh, err := l.GetWebhook("ok")
+	if err != nil {
+		t.Fatal("Check webhook with verify failed:", err)
+	}
+	h := http.Header{}
+	if wh.Verify(h.Get, nil) != ErrWebhookUnauthorized {
+		t.Error("Check webhook verify unauthorized failed")
+	}
+	h.Set("Authorization", "Bearer abc")
+	if wh.Verify(h.Get, nil) != nil {
+		t.Error("Check webhook verify authorized failed")
+	}
+	if wh, _ := l.GetWebhook("none"); wh.Verify(h.Get, nil) != nil {
+		t.Error("Check webhook without verify failed")
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"database/sql"
+)
+
+// Channel is user defined channel with default notification options
+type Channel struct {
+	Name              string `json:"name"`
+	Icon              string `json:"icon,omitempty"`
+	Sound             string `json:"sound,omitempty"`
+	Priority          int    `json:"priority,omitempty"`
+	InterruptionLevel string `json:"interruption-level,omitempty"`
+	TTL               *int   `json:"ttl,omitempty"` // seconds, 0 to deliver now or drop
+}
+
+func scanChannels(rows *sql.Rows) ([]*Channel, error) {
+	chs := []*Channel{}
+	for rows.Next() {
+		ch := &Channel{}
+		var ttl sql.NullInt64
+		if err := rows.Scan(&ch.Name, &ch.Icon, &ch.Sound, &ch.Priority, &ch.InterruptionLevel, &ttl); err != nil {
+			return nil, err
+		}
+		chs = append(chs, ch.setTTL(ttl))
+	}
+	return chs, rows.Err()
+}
+
+func (ch *Channel) setTTL(ttl sql.NullInt64) *Channel {
+	ch.TTL = nil
+	if ttl.Valid {
+		v := int(ttl.Int64)
+		ch.TTL = &v
+	}
+	return ch
+}
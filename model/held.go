@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// HeldMessage is message held by do-not-disturb policy until deliver time
+type HeldMessage struct {
+	ID       int64
+	UID      string
+	Mode     int
+	Deliver  int64
+	Timeline bool
+	Data     []byte
+	Options  []byte
+}
+
+// heldOptions is sending options of held message which are not in pb message
+type heldOptions struct {
+	Batch   time.Duration `json:"batch,omitempty"`
+	Expires int64         `json:"expires,omitempty"` // unix time when ttl ends
+}
+
+// NewHeldMessage hold message for user until deliver timestamp, batch and ttl options are kept
+func NewHeldMessage(uid string, mode int, deliver int64, m *Message) *HeldMessage {
+	h := &HeldMessage{
+		UID:      uid,
+		Mode:     mode,
+		Deliver:  deliver,
+		Timeline: m.isTimeline,
+		Data:     m.Marshal(),
+	}
+	opts := &heldOptions{Batch: m.batch}
+	if m.ttl != nil {
+		opts.Expires = time.Now().Add(*m.ttl).Unix()
+	}
+	if opts.Batch > 0 || opts.Expires > 0 {
+		h.Options, _ = json.Marshal(opts)
+	}
+	return h
+}
+
+// Message return the held message, ttl is the time left until it ends
+func (h *HeldMessage) Message() (*Message, error) {
+	m := &Message{isTimeline: h.Timeline}
+	if err := proto.Unmarshal(h.Data, &m.Message); err != nil {
+		return nil, err
+	}
+	if len(h.Options) > 0 {
+		var opts heldOptions
+		if err := json.Unmarshal(h.Options, &opts); err != nil {
+			return nil, err
+		}
+		m.SetBatch(opts.Batch)
+		if opts.Expires > 0 {
+			ttl := time.Until(time.Unix(opts.Expires, 0)).Round(time.Second)
+			if ttl < 0 {
+				ttl = 0
+			}
+			m.SetTTL(ttl)
+		}
+	}
+	return m, nil
+}
+
+func scanHeldMessages(rows *sql.Rows) ([]*HeldMessage, error) {
+	msgs := []*HeldMessage{}
+	for rows.Next() {
+		m := &HeldMessage{}
+		if err := rows.Scan(&m.ID, &m.UID, &m.Mode, &m.Deliver, &m.Timeline, &m.Data, &m.Options); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].ID < msgs[j].ID })
+	return msgs, rows.Err()
+}
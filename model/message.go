@@ -3,6 +3,8 @@ package model
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"math"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"time"
@@ -11,10 +13,33 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// system actions handled by client
+const (
+	SysActionCopy     = "copy"
+	SysActionAck      = "ack"
+	SysActionCallback = "callback"
+
+	sysActionPrefix = "chanify://action/"
+)
+
+// flags of message content
+const (
+	ContentFlagAutoCopy = 1 << iota
+	ContentFlagMarkdown
+)
+
 // MsgTimeItem define data for timeline
 type MsgTimeItem struct {
-	Name  string
-	Value interface{}
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	Unit  string      `json:"unit,omitempty"`
+}
+
+// AckOptions track acknowledgement of notification
+type AckOptions struct {
+	Callback string
+	Interval time.Duration
+	Repeat   int
 }
 
 // Message for notification
@@ -22,6 +47,11 @@ type Message struct {
 	pb.Message
 	isTimeline bool
 	ilValue    string
+	soundSet   bool
+	batch      time.Duration
+	ack        *AckOptions
+	ttl        *time.Duration
+	textFile   *[2]string
 }
 
 // NewMessage with sender token
@@ -55,7 +85,7 @@ func (m *Message) LinkContent(link string) *Message {
 func (m *Message) TimelineContent(code string, title string, ts *time.Time, items []*MsgTimeItem) *Message {
 	tis := []*pb.TimeItem{}
 	for _, item := range items {
-		ti := &pb.TimeItem{Name: item.Name}
+		ti := &pb.TimeItem{Name: item.Name, Unit: item.Unit}
 		switch v := item.Value.(type) {
 		case int:
 			ti.ValueType = pb.ValueType_ValueTypeInteger
@@ -66,6 +96,12 @@ func (m *Message) TimelineContent(code string, title string, ts *time.Time, item
 		case float64:
 			ti.ValueType = pb.ValueType_ValueTypeDouble
 			ti.DoubleValue = v
+		case string:
+			ti.ValueType = pb.ValueType_ValueTypeString
+			ti.StringValue = v
+		case bool:
+			ti.ValueType = pb.ValueType_ValueTypeBool
+			ti.BoolValue = v
 		default:
 			continue
 		}
@@ -102,7 +138,28 @@ func (m *Message) TextContent(text string, title string, copytext string, autoco
 		ctx.Copytext = copytext
 	}
 	if len(autocopy) > 0 {
-		ctx.Flags = 1
+		ctx.Flags = ContentFlagAutoCopy
+	}
+	m.Content, _ = proto.Marshal(ctx)
+	return m
+}
+
+// MarkdownContent set markdown text notification, text is plain fallback for notification body
+func (m *Message) MarkdownContent(markdown string, text string, title string, copytext string, autocopy string) *Message {
+	ctx := &pb.MsgContent{
+		Type:     pb.MsgType_Text,
+		Text:     text,
+		Markdown: markdown,
+		Flags:    ContentFlagMarkdown,
+	}
+	if len(title) > 0 {
+		ctx.Title = title
+	}
+	if len(copytext) > 0 {
+		ctx.Copytext = copytext
+	}
+	if len(autocopy) > 0 {
+		ctx.Flags |= ContentFlagAutoCopy
 	}
 	m.Content, _ = proto.Marshal(ctx)
 	return m
@@ -158,12 +215,32 @@ func (m *Message) ImageContent(path string, t *Thumbnail, size int) *Message {
 	return m
 }
 
+// VideoContent set video notification
+func (m *Message) VideoContent(path string, t *Thumbnail, duration uint64, size int) *Message {
+	ctx := &pb.MsgContent{
+		Type:     pb.MsgType_Video,
+		File:     filepath.ToSlash(path),
+		Size:     uint64(size),
+		Duration: duration,
+	}
+	if t != nil {
+		ctx.Thumbnail = &pb.Thumbnail{
+			Width:  int32(t.width),
+			Height: int32(t.height),
+			Data:   t.preview,
+		}
+	}
+	m.Content, _ = proto.Marshal(ctx)
+	return m
+}
+
 // AudioContent set audio notification
 func (m *Message) AudioContent(path string, fname string, title string, duration uint64, size int) *Message {
 	ctx := &pb.MsgContent{
 		Type:     pb.MsgType_Audio,
 		File:     filepath.ToSlash(path),
 		Size:     uint64(size),
+		Duration: duration,
 		Filename: fname,
 		Title:    title,
 	}
@@ -190,6 +267,35 @@ func (m *Message) TextFileContent(path string, filename string, title string, de
 	return m
 }
 
+// SetTextFile keep full title and text of long text message until its text file is saved
+func (m *Message) SetTextFile(title string, text string) *Message {
+	m.textFile = &[2]string{title, text}
+	return m
+}
+
+// TextFile return full title and text set by SetTextFile, false if not set or saved
+func (m *Message) TextFile() (string, string, bool) {
+	if m.textFile == nil {
+		return "", "", false
+	}
+	return m.textFile[0], m.textFile[1], true
+}
+
+// SavedTextFile set text file content saved with path, and clear full text set by SetTextFile
+func (m *Message) SavedTextFile(path string, filename string, title string, desc string, size int) *Message {
+	var ctx pb.MsgContent
+	proto.Unmarshal(m.Content, &ctx) // nolint: errcheck
+	ctx.Type = pb.MsgType_File
+	ctx.File = filepath.ToSlash(path)
+	ctx.Filename = filename
+	ctx.Size = uint64(size)
+	ctx.Title = title
+	ctx.Text = desc
+	m.Content, _ = proto.Marshal(&ctx)
+	m.textFile = nil
+	return m
+}
+
 // IsTimeline return is timeline notification
 func (m *Message) IsTimeline() bool {
 	return m.isTimeline
@@ -203,12 +309,41 @@ func (m *Message) SetTimeline(timeline bool) *Message {
 
 // SoundName set notification sound
 func (m *Message) SoundName(sound string) *Message {
-	if len(sound) > 0 && sound != "0" {
-		m.Sound = &pb.Sound{Name: sound}
+	if len(sound) > 0 {
+		m.soundSet = true
+		if sound != "0" {
+			m.Sound = &pb.Sound{Name: sound}
+		}
 	}
 	return m
 }
 
+// DropSound clear sound of message, user channel default sound is used
+func (m *Message) DropSound() *Message {
+	m.Sound = nil
+	m.soundSet = false
+	return m
+}
+
+// SetCritical mark sound as critical alert with volume (0.0-1.0, 0 for default), default sound is used if sound not set
+func (m *Message) SetCritical(critical bool, volume float64) *Message {
+	if critical {
+		if m.Sound == nil {
+			m.Sound = &pb.Sound{Name: "1"}
+		}
+		m.Sound.Type = pb.SoundType_CriticalSound
+		if volume > 0 {
+			m.Sound.Volume = float32(math.Min(volume, 1))
+		}
+	}
+	return m
+}
+
+// IsCritical return is critical alert notification
+func (m *Message) IsCritical() bool {
+	return m.Sound != nil && m.Sound.Type == pb.SoundType_CriticalSound
+}
+
 // SetPriority set notification priority
 func (m *Message) SetPriority(priority int) *Message {
 	if priority > 0 && priority < 0x7fffffff {
@@ -233,6 +368,91 @@ func (m *Message) SetInterruptionLevel(interruptionLevel string) *Message {
 	return m
 }
 
+// SetBatch merge messages sent within window into one notification, 0 to disable
+func (m *Message) SetBatch(window time.Duration) *Message {
+	if window > 0 {
+		m.batch = window
+	}
+	return m
+}
+
+// BatchWindow return batch window set by SetBatch
+func (m *Message) BatchWindow() time.Duration {
+	return m.batch
+}
+
+// SetAck track acknowledgement of message, repeat notification until acked if interval is set
+func (m *Message) SetAck(opts *AckOptions) *Message {
+	m.ack = opts
+	return m
+}
+
+// Ack return acknowledgement options set by SetAck
+func (m *Message) Ack() *AckOptions {
+	return m.ack
+}
+
+// SetTTL set time to live of notification, 0 to deliver now or drop
+func (m *Message) SetTTL(ttl time.Duration) *Message {
+	if ttl >= 0 {
+		m.ttl = &ttl
+	}
+	return m
+}
+
+// TTL return time to live set by SetTTL, false if not set
+func (m *Message) TTL() (time.Duration, bool) {
+	if m.ttl == nil {
+		return 0, false
+	}
+	return *m.ttl, true
+}
+
+// InterruptionLevelName return interruption level name set by SetInterruptionLevel
+func (m *Message) InterruptionLevelName() string {
+	return m.ilValue
+}
+
+// ChannelName return user channel name, empty for system channels
+func (m *Message) ChannelName() string {
+	var ch pb.Channel
+	if len(m.Channel) <= 0 || proto.Unmarshal(m.Channel, &ch) != nil || ch.Type != pb.ChanType_User {
+		return ""
+	}
+	return ch.Name
+}
+
+// ApplyChannel set icon of user channel and fill notification options not set by sender
+func (m *Message) ApplyChannel(ch *Channel) *Message {
+	if len(ch.Icon) > 0 {
+		m.Channel, _ = proto.Marshal(&pb.Channel{Type: pb.ChanType_User, Name: ch.Name, Icon: ch.Icon})
+	}
+	if m.Sound == nil && !m.soundSet {
+		m.SoundName(ch.Sound)
+	}
+	if m.Priority <= 0 {
+		m.SetPriority(ch.Priority)
+	}
+	if len(m.ilValue) <= 0 {
+		m.SetInterruptionLevel(ch.InterruptionLevel)
+	}
+	if m.ttl == nil && ch.TTL != nil {
+		m.SetTTL(time.Duration(*ch.TTL) * time.Second)
+	}
+	return m
+}
+
+// SetChannelName reroute message to user channel, empty name for default channel
+func (m *Message) SetChannelName(name string) *Message {
+	if len(name) <= 0 {
+		m.Channel = nil
+	} else {
+		m.Channel, _ = proto.Marshal(&pb.Channel{Type: pb.ChanType_User, Name: name})
+	}
+	m.fixChannel()
+	return m
+}
+
 // EncryptContent return encrypted content with key
 func (m *Message) EncryptContent(key []byte) {
 	if m.Content != nil {
@@ -294,8 +514,32 @@ func parseActions(actions []string) []*pb.ActionItem {
 				Name: ss[0],
 				Link: ss[1],
 			}
+			if IsSysAction(ss[1]) {
+				item.Type = pb.ActType_ActSys
+			}
 			acts = append(acts, item)
 		}
 	}
 	return acts
 }
+
+// IsSysAction return link is handled by client without opening browser
+func IsSysAction(link string) bool {
+	if strings.HasPrefix(link, sysActionPrefix) {
+		name := strings.SplitN(link[len(sysActionPrefix):], "?", 2)[0]
+		switch name {
+		case SysActionCopy, SysActionAck, SysActionCallback:
+			return true
+		}
+	}
+	return false
+}
+
+// SysAction format system action item, e.g. "Copy|chanify://action/copy?text=abc"
+func SysAction(name string, action string, args url.Values) string {
+	link := sysActionPrefix + action
+	if len(args) > 0 {
+		link += "?" + args.Encode()
+	}
+	return name + "|" + link
+}
@@ -2,7 +2,9 @@ package model
 
 import (
 	"bytes"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/chanify/chanify/pb"
 	"google.golang.org/protobuf/proto"
@@ -41,6 +43,22 @@ func TestImageContent(t *testing.T) {
 	}
 }
 
+func TestVideoContent(t *testing.T) {
+	tk, _ := ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRzIUx5tXg-Vym58og7aZw05IkoDvse8..c2lnbg")
+	m := NewMessage(tk)
+	m.VideoContent("/files/videos/123", NewThumbnail(10, 20).SetPreview([]byte("abc")), 1500, 10)
+	var ctx pb.MsgContent
+	if err := proto.Unmarshal(m.Content, &ctx); err != nil {
+		t.Fatal("Unmarshal video content failed")
+	}
+	if ctx.Type != pb.MsgType_Video || ctx.Duration != 1500 || ctx.Size != 10 {
+		t.Fatal("Check video content failed")
+	}
+	if ctx.Thumbnail == nil || ctx.Thumbnail.Width != 10 || string(ctx.Thumbnail.Data) != "abc" {
+		t.Fatal("Check video thumbnail failed")
+	}
+}
+
 func TestActionContent(t *testing.T) {
 	tk, _ := ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRzIUx5tXg-Vym58og7aZw05IkoDvse8..c2lnbg")
 	m := NewMessage(tk)
@@ -57,17 +75,50 @@ func TestActionContent(t *testing.T) {
 	}
 }
 
+func TestMarkdownContent(t *testing.T) {
+	tk, _ := ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRzIUx5tXg-Vym58og7aZw05IkoDvse8..c2lnbg")
+	m := NewMessage(tk)
+	m.MarkdownContent("**123**", "123", "abc", "copy", "1")
+	var ctx pb.MsgContent
+	if err := proto.Unmarshal(m.Content, &ctx); err != nil {
+		t.Fatal("Unmarshal markdown content failed")
+	}
+	if ctx.Markdown != "**123**" || ctx.Text != "123" || ctx.Title != "abc" || ctx.Copytext != "copy" || ctx.Flags != ContentFlagAutoCopy|ContentFlagMarkdown {
+		t.Fatal("Check markdown content failed")
+	}
+}
+
 func TestTimeContent(t *testing.T) {
 	tk, _ := ParseToken("EiJBQk9PNlRTSVhLU0VWSUpLWExEUVNVWFFSWFVBT1hHR1lZIgRjaGFuKgVNRlJHRzIUx5tXg-Vym58og7aZw05IkoDvse8..c2lnbg")
 	m := NewMessage(tk)
-	m.TimelineContent("test", "", nil, []*MsgTimeItem{{Name: "123", Value: "123"}})
+	m.TimelineContent("test", "", nil, []*MsgTimeItem{{Name: "status", Value: "degraded"}, {Name: "up", Value: true}, {Name: "temp", Value: 21.5, Unit: "°C"}, {Name: "bad", Value: []int{}}})
 	var ctx pb.MsgContent
 	if err := proto.Unmarshal(m.Content, &ctx); err != nil {
 		t.Fatal("Unmarshal timeline content failed")
 	}
-	if len(ctx.TimeContent.TimeItems) != 0 {
+	items := ctx.TimeContent.TimeItems
+	if len(items) != 3 {
 		t.Fatal("Check time content failed")
 	}
+	if items[0].ValueType != pb.ValueType_ValueTypeString || items[0].StringValue != "degraded" || items[1].ValueType != pb.ValueType_ValueTypeBool || !items[1].BoolValue {
+		t.Error("Check time content string and bool failed:", items[0], items[1])
+	}
+	if items[2].ValueType != pb.ValueType_ValueTypeDouble || items[2].DoubleValue != 21.5 || items[2].Unit != "°C" {
+		t.Error("Check time content unit failed:", items[2])
+	}
+	p := m.TimelinePoint()
+	if p == nil || p.Code != "test" || p.Timestamp <= 0 {
+		t.Fatal("Check timeline point failed:", p)
+	}
+	if its, err := p.Items(); err != nil || len(its) != 3 || its[0].Value != "degraded" || its[1].Value != true || its[2].Unit != "°C" {
+		t.Fatal("Check timeline point items failed:", its, err)
+	}
+	if _, err := (&TimelinePoint{Data: []byte{0xff}}).Items(); err == nil {
+		t.Fatal("Check invalid timeline point failed")
+	}
+	if NewMessage(tk).TextContent("hello", "", "", "").TimelinePoint() != nil {
+		t.Fatal("Check text timeline point failed")
+	}
 }
 
 func TestMessageChannel(t *testing.T) {
@@ -90,3 +141,119 @@ func TestMessageChannel(t *testing.T) {
 		t.Fatal("Check default timeline channel failed")
 	}
 }
+
+func TestMessageChannelName(t *testing.T) {
+	m := NewMessage(&Token{})
+	if m.ChannelName() != "" {
+		t.Fatal("Check empty channel name failed")
+	}
+	m.SetChannelName("alerts")
+	if m.ChannelName() != "alerts" {
+		t.Fatal("Check channel name failed")
+	}
+	m.SetChannelName("")
+	if !bytes.Equal(m.Channel, defaultChannel) || m.ChannelName() != "" {
+		t.Fatal("Check reset channel name failed")
+	}
+	m.Channel = []byte{0xff}
+	if m.ChannelName() != "" {
+		t.Fatal("Check invalid channel name failed")
+	}
+	if m.SetInterruptionLevel("passive").InterruptionLevelName() != "passive" {
+		t.Fatal("Check interruption level name failed")
+	}
+}
+
+func TestMessageApplyChannel(t *testing.T) {
+	ch := &Channel{Name: "alerts", Icon: "https://example.com/icon.png", Sound: "bell", Priority: 5, InterruptionLevel: "time-sensitive"}
+	m := NewMessage(&Token{}).SetChannelName("alerts").ApplyChannel(ch)
+	var c pb.Channel
+	proto.Unmarshal(m.Channel, &c) // nolint: errcheck
+	if c.Name != "alerts" || c.Icon != ch.Icon || m.Sound.GetName() != "bell" || m.Priority != 5 || m.InterruptionLevel != pb.InterruptionLevel_IlTimeSensitive {
+		t.Fatal("Apply channel failed:", c.Icon, m.Sound, m.Priority, m.InterruptionLevel)
+	}
+	m = NewMessage(&Token{}).SetChannelName("alerts").SoundName("1").SetPriority(10).SetInterruptionLevel("passive")
+	m.ApplyChannel(&Channel{Name: "alerts", Sound: "bell", Priority: 5, InterruptionLevel: "active"})
+	if m.ChannelName() != "alerts" || m.Sound.GetName() != "1" || m.Priority != 10 || m.InterruptionLevel != pb.InterruptionLevel_IlPassive {
+		t.Fatal("Check apply channel override failed:", m.Sound, m.Priority, m.InterruptionLevel)
+	}
+	if m = NewMessage(&Token{}).SoundName("0").ApplyChannel(ch); m.Sound != nil {
+		t.Fatal("Check apply channel silent sound failed:", m.Sound)
+	}
+	if m = NewMessage(&Token{}).SoundName("0").DropSound().ApplyChannel(ch); m.Sound.GetName() != "bell" {
+		t.Fatal("Check apply channel dropped sound failed:", m.Sound)
+	}
+	ttl := 300
+	if d, ok := NewMessage(&Token{}).ApplyChannel(&Channel{Name: "alerts", TTL: &ttl}).TTL(); !ok || d != 5*time.Minute {
+		t.Fatal("Check apply channel ttl failed:", d, ok)
+	}
+	if d, ok := NewMessage(&Token{}).SetTTL(0).ApplyChannel(&Channel{Name: "alerts", TTL: &ttl}).TTL(); !ok || d != 0 {
+		t.Fatal("Check apply channel ttl override failed:", d, ok)
+	}
+}
+
+func TestMessageTTL(t *testing.T) {
+	if _, ok := NewMessage(&Token{}).TTL(); ok {
+		t.Fatal("Check default ttl failed")
+	}
+	if _, ok := NewMessage(&Token{}).SetTTL(-1).TTL(); ok {
+		t.Fatal("Check invalid ttl failed")
+	}
+	if d, ok := NewMessage(&Token{}).SetTTL(time.Hour).TTL(); !ok || d != time.Hour {
+		t.Fatal("Check ttl failed:", d, ok)
+	}
+}
+
+func TestMessageCritical(t *testing.T) {
+	m := NewMessage(&Token{}).SetCritical(false, 0.5)
+	if m.IsCritical() || m.Sound != nil {
+		t.Fatal("Check non critical message failed")
+	}
+	m = NewMessage(&Token{}).SetCritical(true, 0)
+	if !m.IsCritical() || m.Sound.GetName() != "1" || m.Sound.GetVolume() != 0 {
+		t.Fatal("Check default critical sound failed:", m.Sound)
+	}
+	m = NewMessage(&Token{}).SoundName("bell").SetCritical(true, 2)
+	if !m.IsCritical() || m.Sound.GetName() != "bell" || m.Sound.GetVolume() != 1 {
+		t.Fatal("Check critical volume failed:", m.Sound)
+	}
+}
+
+func TestSysActionContent(t *testing.T) {
+	m := NewMessage(&Token{}).ActionContent("text", "title", []string{
+		SysAction("Copy", SysActionCopy, url.Values{"text": {"abc"}}),
+		SysAction("Ack", SysActionAck, nil),
+		"Script|chanify://action/run-script/abc",
+		"Open|http://127.0.0.1",
+	})
+	var ctx pb.MsgContent
+	proto.Unmarshal(m.Content, &ctx) // nolint: errcheck
+	if len(ctx.Actions) != 4 {
+		t.Fatal("Check sys actions failed")
+	}
+	if ctx.Actions[0].Type != pb.ActType_ActSys || ctx.Actions[0].Link != "chanify://action/copy?text=abc" || ctx.Actions[1].Type != pb.ActType_ActSys {
+		t.Error("Check sys action type failed:", ctx.Actions[0], ctx.Actions[1])
+	}
+	if ctx.Actions[2].Type != pb.ActType_ActURL || ctx.Actions[3].Type != pb.ActType_ActURL {
+		t.Error("Check url action type failed:", ctx.Actions[2], ctx.Actions[3])
+	}
+}
+
+func TestMessageTextFile(t *testing.T) {
+	m := NewMessage(&Token{}).TextFileContent("", "", "", "", 0, []string{"Open|http://127.0.0.1"})
+	if _, _, ok := m.TextFile(); ok {
+		t.Fatal("Check empty text file failed")
+	}
+	if title, text, ok := m.SetTextFile("title", "text").TextFile(); !ok || title != "title" || text != "text" {
+		t.Fatal("Check text file failed:", title, text)
+	}
+	m.SavedTextFile("files/abc", "title.txt", "title", "text", 10)
+	if _, _, ok := m.TextFile(); ok {
+		t.Error("Check saved text file failed")
+	}
+	var ctx pb.MsgContent
+	proto.Unmarshal(m.Content, &ctx) // nolint: errcheck
+	if ctx.Type != pb.MsgType_File || ctx.File != "files/abc" || ctx.Filename != "title.txt" || ctx.Size != 10 || len(ctx.Actions) != 1 {
+		t.Error("Check saved text file content failed")
+	}
+}
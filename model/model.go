@@ -12,12 +12,28 @@ import (
 type DB interface {
 	GetOption(key string, value interface{}) error
 	SetOption(key string, value interface{}) error
+	GetPluginValue(ns string, key string) ([]byte, error)
+	SetPluginValue(ns string, key string, value []byte, expires int64) error
+	IncrPluginValue(ns string, key string, delta int64, expires int64) (int64, error)
+	DelPluginValue(ns string, key string) error
+	GetUserDND(uid string) ([]byte, error)
+	SetUserDND(uid string, policy []byte) error
+	HoldMessage(m *HeldMessage) error
+	PopHeldMessages(deliver int64, limit int) ([]*HeldMessage, error)
+	GetChannels(uid string) ([]*Channel, error)
+	GetChannel(uid string, name string) (*Channel, error)
+	UpsertChannel(uid string, ch *Channel) error
+	DeleteChannel(uid string, name string) error
+	AddTimelinePoint(uid string, p *TimelinePoint, expires int64, now int64) error
+	GetTimelinePoints(uid string, code string, since int64, until int64, now int64, limit int) ([]*TimelinePoint, error)
+	GetTimelineCodes(uid string, now int64) ([]string, error)
 	GetUser(uid string) (*User, error)
 	UpsertUser(u *User) error
 	BindDevice(uid string, uuid string, key []byte, devType int) error
 	UnbindDevice(uid string, uuid string) error
 	UpdatePushToken(uid string, uuid string, token []byte, sandbox bool) error
 	GetDeviceKey(uuid string) ([]byte, error)
+	GetUserDeviceKey(uid string, uuid string) ([]byte, error)
 	GetDevices(uid string) ([]*Device, error)
 	Close()
 }
@@ -47,6 +47,147 @@ func (s *mysql) SetOption(key string, value interface{}) error {
 	return err
 }
 
+func (s *mysql) GetPluginValue(ns string, key string) ([]byte, error) {
+	var value []byte
+	row := s.db.QueryRow("SELECT `value` FROM `plugin_kv` WHERE `ns`=? AND `key`=? AND (`expires`=0 OR `expires`>?) LIMIT 1;", ns, key, time.Now().Unix())
+	err := row.Scan(&value)
+	return value, err
+}
+
+func (s *mysql) SetPluginValue(ns string, key string, value []byte, expires int64) error {
+	if _, err := s.db.Exec("DELETE FROM `plugin_kv` WHERE `ns`=? AND `expires`>0 AND `expires`<=?;", ns, time.Now().Unix()); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("INSERT INTO `plugin_kv`(`ns`,`key`,`value`,`expires`) VALUES(?,?,?,?) ON DUPLICATE KEY UPDATE `value`=VALUES(`value`),`expires`=VALUES(`expires`);", ns, key, value, expires)
+	return err
+}
+
+func (s *mysql) IncrPluginValue(ns string, key string, delta int64, expires int64) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().Unix()
+	// `value` is assigned before `expires`, so both checks see the old expiration
+	if _, err := tx.Exec("INSERT INTO `plugin_kv`(`ns`,`key`,`value`,`expires`) VALUES(?,?,?,?) ON DUPLICATE KEY UPDATE `value`=IF(`expires`>0 AND `expires`<=?,VALUES(`value`),CAST(`value` AS SIGNED)+VALUES(`value`)),`expires`=IF(`expires`>0 AND `expires`<=?,VALUES(`expires`),`expires`);", ns, key, delta, expires, now, now); err != nil {
+		tx.Rollback() // nolint: errcheck
+		return 0, err
+	}
+	var value int64
+	row := tx.QueryRow("SELECT CAST(`value` AS SIGNED) FROM `plugin_kv` WHERE `ns`=? AND `key`=? LIMIT 1;", ns, key)
+	if err := row.Scan(&value); err != nil {
+		tx.Rollback() // nolint: errcheck
+		return 0, err
+	}
+	return value, tx.Commit()
+}
+
+func (s *mysql) DelPluginValue(ns string, key string) error {
+	_, err := s.db.Exec("DELETE FROM `plugin_kv` WHERE `ns`=? AND `key`=?;", ns, key)
+	return err
+}
+
+func (s *mysql) GetUserDND(uid string) ([]byte, error) {
+	var policy []byte
+	row := s.db.QueryRow("SELECT `policy` FROM `user_dnd` WHERE `uid`=? LIMIT 1;", uid)
+	err := row.Scan(&policy)
+	return policy, err
+}
+
+func (s *mysql) SetUserDND(uid string, policy []byte) error {
+	if len(policy) <= 0 {
+		_, err := s.db.Exec("DELETE FROM `user_dnd` WHERE `uid`=?;", uid)
+		return err
+	}
+	_, err := s.db.Exec("INSERT INTO `user_dnd`(`uid`,`policy`) VALUES(?,?) ON DUPLICATE KEY UPDATE `policy`=VALUES(`policy`);", uid, policy)
+	return err
+}
+
+func (s *mysql) HoldMessage(m *HeldMessage) error {
+	_, err := s.db.Exec("INSERT INTO `held_messages`(`uid`,`mode`,`deliver`,`timeline`,`data`,`options`) VALUES(?,?,?,?,?,?);", m.UID, m.Mode, m.Deliver, m.Timeline, m.Data, m.Options)
+	return err
+}
+
+func (s *mysql) PopHeldMessages(deliver int64, limit int) ([]*HeldMessage, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query("SELECT `id`,`uid`,`mode`,`deliver`,`timeline`,`data`,`options` FROM `held_messages` WHERE `deliver`<=? ORDER BY `id` LIMIT ? FOR UPDATE;", deliver, limit)
+	if err != nil {
+		tx.Rollback() // nolint: errcheck
+		return nil, err
+	}
+	msgs, err := scanHeldMessages(rows)
+	rows.Close()
+	if err != nil {
+		tx.Rollback() // nolint: errcheck
+		return nil, err
+	}
+	for _, m := range msgs {
+		if _, err := tx.Exec("DELETE FROM `held_messages` WHERE `id`=?;", m.ID); err != nil {
+			tx.Rollback() // nolint: errcheck
+			return nil, err
+		}
+	}
+	return msgs, tx.Commit()
+}
+
+func (s *mysql) GetChannels(uid string) ([]*Channel, error) {
+	rows, err := s.db.Query("SELECT `name`,`icon`,`sound`,`priority`,`ilevel`,`ttl` FROM `channels` WHERE `uid`=? ORDER BY `name`;", uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanChannels(rows)
+}
+
+func (s *mysql) GetChannel(uid string, name string) (*Channel, error) {
+	ch := &Channel{Name: name}
+	var ttl sql.NullInt64
+	row := s.db.QueryRow("SELECT `icon`,`sound`,`priority`,`ilevel`,`ttl` FROM `channels` WHERE `uid`=? AND `name`=? LIMIT 1;", uid, name)
+	if err := row.Scan(&ch.Icon, &ch.Sound, &ch.Priority, &ch.InterruptionLevel, &ttl); err != nil {
+		return nil, err
+	}
+	return ch.setTTL(ttl), nil
+}
+
+func (s *mysql) UpsertChannel(uid string, ch *Channel) error {
+	_, err := s.db.Exec("INSERT INTO `channels`(`uid`,`name`,`icon`,`sound`,`priority`,`ilevel`,`ttl`) VALUES(?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE `icon`=VALUES(`icon`),`sound`=VALUES(`sound`),`priority`=VALUES(`priority`),`ilevel`=VALUES(`ilevel`),`ttl`=VALUES(`ttl`),`lastupdate`=CURRENT_TIMESTAMP;", uid, ch.Name, ch.Icon, ch.Sound, ch.Priority, ch.InterruptionLevel, ch.TTL)
+	return err
+}
+
+func (s *mysql) DeleteChannel(uid string, name string) error {
+	_, err := s.db.Exec("DELETE FROM `channels` WHERE `uid`=? AND `name`=?;", uid, name)
+	return err
+}
+
+func (s *mysql) AddTimelinePoint(uid string, p *TimelinePoint, expires int64, now int64) error {
+	if _, err := s.db.Exec("DELETE FROM `timelines` WHERE `uid`=? AND `code`=? AND (`timestamp`<? OR (`expires`>0 AND `expires`<=?));", uid, p.Code, expires, now); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("INSERT INTO `timelines`(`uid`,`code`,`timestamp`,`data`,`expires`) VALUES(?,?,?,?,?);", uid, p.Code, p.Timestamp, p.Data, p.Expires)
+	return err
+}
+
+func (s *mysql) GetTimelinePoints(uid string, code string, since int64, until int64, now int64, limit int) ([]*TimelinePoint, error) {
+	rows, err := s.db.Query("SELECT `timestamp`,`data` FROM `timelines` WHERE `uid`=? AND `code`=? AND `timestamp`>=? AND `timestamp`<=? AND (`expires`=0 OR `expires`>?) ORDER BY `timestamp` DESC LIMIT ?;", uid, code, since, until, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTimelinePoints(code, rows)
+}
+
+func (s *mysql) GetTimelineCodes(uid string, now int64) ([]string, error) {
+	rows, err := s.db.Query("SELECT DISTINCT `code` FROM `timelines` WHERE `uid`=? AND (`expires`=0 OR `expires`>?) ORDER BY `code`;", uid, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTimelineCodes(rows)
+}
+
 func (s *mysql) GetUser(uid string) (*User, error) {
 	u := &User{UID: uid}
 	row := s.db.QueryRow("SELECT `pubkey`, `seckey`, `flags` FROM `users` WHERE `uid`=? LIMIT 1;", uid)
@@ -84,6 +225,13 @@ func (s *mysql) GetDeviceKey(uuid string) ([]byte, error) {
 	return key, err
 }
 
+func (s *mysql) GetUserDeviceKey(uid string, uuid string) ([]byte, error) {
+	var key []byte
+	row := s.db.QueryRow("SELECT `key` FROM `devices` WHERE `uuid`=? AND `uid`=? LIMIT 1;", uuid, uid)
+	err := row.Scan(&key)
+	return key, err
+}
+
 func (s *mysql) GetDevices(uid string) ([]*Device, error) {
 	devs := []*Device{}
 	rows, err := s.db.Query("SELECT `token`,`sandbox`,`type` FROM `devices` WHERE `uid`=? ORDER BY `lastupdate` DESC LIMIT 4;", uid)
@@ -112,6 +260,11 @@ func (s *mysql) fixDB() error {
 		"CREATE TABLE IF NOT EXISTS `options`(`key` VARCHAR(255), `value` VARBINARY(255), PRIMARY KEY (`key`));",
 		"CREATE TABLE IF NOT EXISTS `users`(`uid` VARCHAR(255), `pubkey` VARBINARY(255) UNIQUE, `seckey` VARBINARY(255), `flags` INTEGER DEFAULT 0, `lastupdate` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, `createtime` TIMESTAMP DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY(`uid`));",
 		"CREATE TABLE IF NOT EXISTS `devices`(`uuid` VARCHAR(255), `uid` VARCHAR(255), `key` VARBINARY(255), `type` INTEGER DEFAULT 0, `token` VARBINARY(255), `sandbox` INTEGER DEFAULT 0, `lastupdate` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, `createtime` TIMESTAMP DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY(`uuid`), INDEX(`uid`));",
+		"CREATE TABLE IF NOT EXISTS `plugin_kv`(`ns` VARCHAR(255), `key` VARCHAR(255), `value` VARBINARY(4096), `expires` BIGINT DEFAULT 0, PRIMARY KEY(`ns`,`key`));",
+		"CREATE TABLE IF NOT EXISTS `user_dnd`(`uid` VARCHAR(255), `policy` VARBINARY(4096), PRIMARY KEY(`uid`));",
+		"CREATE TABLE IF NOT EXISTS `held_messages`(`id` BIGINT AUTO_INCREMENT, `uid` VARCHAR(255), `mode` INTEGER DEFAULT 0, `deliver` BIGINT, `timeline` INTEGER DEFAULT 0, `data` VARBINARY(4096), `options` VARBINARY(1024), PRIMARY KEY(`id`), INDEX(`deliver`));",
+		"CREATE TABLE IF NOT EXISTS `channels`(`uid` VARCHAR(255), `name` VARCHAR(255), `icon` VARCHAR(1024) DEFAULT '', `sound` VARCHAR(255) DEFAULT '', `priority` INTEGER DEFAULT 0, `ilevel` VARCHAR(32) DEFAULT '', `ttl` INTEGER DEFAULT NULL, `lastupdate` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, PRIMARY KEY(`uid`,`name`));",
+		"CREATE TABLE IF NOT EXISTS `timelines`(`id` BIGINT AUTO_INCREMENT, `uid` VARCHAR(255), `code` VARCHAR(255), `timestamp` BIGINT, `data` VARBINARY(4096), `expires` BIGINT DEFAULT 0, PRIMARY KEY(`id`), INDEX(`uid`,`code`,`timestamp`));",
 	}
 	for _, str := range sqls {
 		if _, err := s.db.Exec(str); err != nil {
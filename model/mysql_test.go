@@ -66,6 +66,11 @@ func TestMySQLDevice(t *testing.T) {
 		t.Fatal("Get device key failed:", err)
 	}
 
+	mock.ExpectQuery("SELECT `key` FROM `devices`").WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("123456"))
+	if k, err := db.GetUserDeviceKey("1", "123"); err != nil || string(k) != "123456" {
+		t.Fatal("Get user device key failed:", err)
+	}
+
 	mock.ExpectQuery("SELECT `token`,`sandbox`,`type` FROM `devices`").WillReturnRows(sqlmock.NewRows([]string{"token", "sandbox", "type"}).AddRow("123", true, 2))
 	if _, err := db.GetDevices("1"); err != nil {
 		t.Fatal("Get devices failed:", err)
@@ -77,6 +82,61 @@ func TestMySQLDevice(t *testing.T) {
 	}
 }
 
+func TestMySQLPluginValue(t *testing.T) {
+	dbmock, mock, _ := sqlmock.New()
+	db := &mysql{db: dbmock}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT `value` FROM `plugin_kv`").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("ok"))
+	if v, err := db.GetPluginValue("github", "status"); err != nil || string(v) != "ok" {
+		t.Fatal("Get plugin value failed:", err)
+	}
+
+	mock.ExpectExec("DELETE FROM `plugin_kv`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.SetPluginValue("github", "status", []byte("ok"), 0); err != nil {
+		t.Fatal("Set plugin value failed:", err)
+	}
+
+	mock.ExpectExec("DELETE FROM `plugin_kv`").WillReturnError(sql.ErrConnDone)
+	if err := db.SetPluginValue("github", "status", []byte("ok"), 0); err != sql.ErrConnDone {
+		t.Fatal("Check set plugin value failed:", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT CAST(.+) FROM `plugin_kv`").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(3))
+	mock.ExpectCommit()
+	if v, err := db.IncrPluginValue("github", "count", 1, 0); err != nil || v != 3 {
+		t.Fatal("Incr plugin value failed:", v, err)
+	}
+
+	mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+	if _, err := db.IncrPluginValue("github", "count", 1, 0); err != sql.ErrConnDone {
+		t.Fatal("Check incr plugin value begin failed:", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `plugin_kv`").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	if _, err := db.IncrPluginValue("github", "count", 1, 0); err != sql.ErrConnDone {
+		t.Fatal("Check incr plugin value insert failed:", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT CAST(.+) FROM `plugin_kv`").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	if _, err := db.IncrPluginValue("github", "count", 1, 0); err != sql.ErrConnDone {
+		t.Fatal("Check incr plugin value select failed:", err)
+	}
+
+	mock.ExpectExec("DELETE FROM `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.DelPluginValue("github", "count"); err != nil {
+		t.Fatal("Del plugin value failed:", err)
+	}
+}
+
 func TestMySQLFixDB(t *testing.T) {
 	dbmock, mock, _ := sqlmock.New()
 	db := &mysql{db: dbmock}
@@ -85,6 +145,11 @@ func TestMySQLFixDB(t *testing.T) {
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `options`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `users`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `devices`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT COUNT(.+) FROM INFORMATION_SCHEMA.COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 	mock.ExpectCommit()
@@ -95,6 +160,11 @@ func TestMySQLFixDB(t *testing.T) {
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `options`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `users`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `devices`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT COUNT(.+) FROM INFORMATION_SCHEMA.COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 	mock.ExpectExec("ALTER TABLE `devices` ADD COLUMN `type` ").WillReturnResult(sqlmock.NewResult(1, 1))
@@ -117,6 +187,11 @@ func TestMySQLFixDBFailed(t *testing.T) {
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `options`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `users`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `devices`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
 	if err := db.fixDB(); err != sql.ErrConnDone {
 		t.Fatal("Check fix db begin failed:", err)
@@ -125,6 +200,11 @@ func TestMySQLFixDBFailed(t *testing.T) {
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `options`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `users`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `devices`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT COUNT(.+) FROM INFORMATION_SCHEMA.COLUMNS").WillReturnError(sql.ErrConnDone)
 	if err := db.fixDB(); err != sql.ErrConnDone {
@@ -134,6 +214,11 @@ func TestMySQLFixDBFailed(t *testing.T) {
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `options`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `users`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `devices`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT COUNT(.+) FROM INFORMATION_SCHEMA.COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 	mock.ExpectExec("ALTER TABLE `devices` ADD COLUMN `type` ").WillReturnError(sql.ErrConnDone)
@@ -144,6 +229,11 @@ func TestMySQLFixDBFailed(t *testing.T) {
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `options`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `users`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `devices`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT COUNT(.+) FROM INFORMATION_SCHEMA.COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 	mock.ExpectCommit().WillReturnError(sql.ErrConnDone)
@@ -180,6 +270,11 @@ func TestMySQLOpenFailed(t *testing.T) {
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `options`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `users`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `devices`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `plugin_kv`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT COUNT(.+) FROM INFORMATION_SCHEMA.COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 	mock.ExpectCommit()
@@ -187,3 +282,146 @@ func TestMySQLOpenFailed(t *testing.T) {
 		t.Error("Open mysql driver failed:", err)
 	}
 }
+
+func TestMySQLUserDND(t *testing.T) {
+	dbmock, mock, _ := sqlmock.New()
+	db := &mysql{db: dbmock}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT `policy` FROM `user_dnd`").WillReturnRows(sqlmock.NewRows([]string{"policy"}).AddRow("{}"))
+	if v, err := db.GetUserDND("abc"); err != nil || string(v) != "{}" {
+		t.Fatal("Get user dnd failed:", err)
+	}
+	mock.ExpectExec("INSERT INTO `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.SetUserDND("abc", []byte("{}")); err != nil {
+		t.Fatal("Set user dnd failed:", err)
+	}
+	mock.ExpectExec("DELETE FROM `user_dnd`").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.SetUserDND("abc", nil); err != nil {
+		t.Fatal("Clear user dnd failed:", err)
+	}
+}
+
+func TestMySQLHeldMessages(t *testing.T) {
+	dbmock, mock, _ := sqlmock.New()
+	db := &mysql{db: dbmock}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.HoldMessage(&HeldMessage{UID: "abc"}); err != nil {
+		t.Fatal("Hold message failed:", err)
+	}
+
+	cols := []string{"id", "uid", "mode", "deliver", "timeline", "data", "options"}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM `held_messages`").WillReturnRows(sqlmock.NewRows(cols).AddRow(2, "abc", 1, 100, false, []byte{}, nil).AddRow(1, "abc", 2, 100, true, []byte{}, nil))
+	mock.ExpectExec("DELETE FROM `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM `held_messages`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if msgs, err := db.PopHeldMessages(100, 10); err != nil || len(msgs) != 2 || msgs[0].ID != 1 || !msgs[0].Timeline {
+		t.Fatal("Pop held messages failed:", err, msgs)
+	}
+
+	mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+	if _, err := db.PopHeldMessages(100, 10); err != sql.ErrConnDone {
+		t.Fatal("Check pop held messages begin failed:", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM `held_messages`").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	if _, err := db.PopHeldMessages(100, 10); err != sql.ErrConnDone {
+		t.Fatal("Check pop held messages select failed:", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM `held_messages`").WillReturnRows(sqlmock.NewRows(cols).AddRow("x", "abc", 1, 100, false, []byte{}, nil))
+	mock.ExpectRollback()
+	if _, err := db.PopHeldMessages(100, 10); err == nil {
+		t.Fatal("Check pop held messages scan failed")
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM `held_messages`").WillReturnRows(sqlmock.NewRows(cols).AddRow(1, "abc", 1, 100, false, []byte{}, nil))
+	mock.ExpectExec("DELETE FROM `held_messages`").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	if _, err := db.PopHeldMessages(100, 10); err != sql.ErrConnDone {
+		t.Fatal("Check pop held messages delete failed:", err)
+	}
+}
+
+func TestMySQLChannels(t *testing.T) {
+	dbmock, mock, _ := sqlmock.New()
+	db := &mysql{db: dbmock}
+	defer db.Close()
+
+	cols := []string{"name", "icon", "sound", "priority", "ilevel", "ttl"}
+	mock.ExpectQuery("SELECT (.+) FROM `channels`").WillReturnRows(sqlmock.NewRows(cols).AddRow("alerts", "", "bell", 10, "", 300).AddRow("logs", "", "", 5, "passive", nil))
+	if chs, err := db.GetChannels("abc"); err != nil || len(chs) != 2 || chs[1].InterruptionLevel != "passive" || *chs[0].TTL != 300 || chs[1].TTL != nil {
+		t.Fatal("Get channels failed:", chs, err)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `channels`").WillReturnError(sql.ErrConnDone)
+	if _, err := db.GetChannels("abc"); err != sql.ErrConnDone {
+		t.Fatal("Check get channels failed:", err)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `channels`").WillReturnRows(sqlmock.NewRows(cols).AddRow("logs", "", "", "x", "", nil))
+	if _, err := db.GetChannels("abc"); err == nil {
+		t.Fatal("Check scan channels failed")
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `channels`").WillReturnRows(sqlmock.NewRows(cols[1:]).AddRow("", "bell", 10, "", 0))
+	if ch, err := db.GetChannel("abc", "alerts"); err != nil || ch.Name != "alerts" || ch.Sound != "bell" || *ch.TTL != 0 {
+		t.Fatal("Get channel failed:", ch, err)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `channels`").WillReturnError(sql.ErrNoRows)
+	if _, err := db.GetChannel("abc", "none"); err != sql.ErrNoRows {
+		t.Fatal("Check get channel failed:", err)
+	}
+	mock.ExpectExec("INSERT INTO `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.UpsertChannel("abc", &Channel{Name: "logs"}); err != nil {
+		t.Fatal("Upsert channel failed:", err)
+	}
+	mock.ExpectExec("DELETE FROM `channels`").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.DeleteChannel("abc", "logs"); err != nil {
+		t.Fatal("Delete channel failed:", err)
+	}
+}
+
+func TestMySQLTimelines(t *testing.T) {
+	dbmock, mock, _ := sqlmock.New()
+	db := &mysql{db: dbmock}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `timelines`").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.AddTimelinePoint("abc", &TimelinePoint{Code: "cpu", Timestamp: 100}, 0, 0); err != nil {
+		t.Fatal("Add timeline point failed:", err)
+	}
+	mock.ExpectExec("DELETE FROM `timelines`").WillReturnError(sql.ErrConnDone)
+	if err := db.AddTimelinePoint("abc", &TimelinePoint{Code: "cpu", Timestamp: 100}, 0, 0); err != sql.ErrConnDone {
+		t.Fatal("Check add timeline point failed:", err)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `timelines`").WillReturnRows(sqlmock.NewRows([]string{"timestamp", "data"}).AddRow(200, []byte{2}).AddRow(100, []byte{1}))
+	if pts, err := db.GetTimelinePoints("abc", "cpu", 0, 1000, 0, 10); err != nil || len(pts) != 2 || pts[0].Timestamp != 100 || pts[1].Code != "cpu" {
+		t.Fatal("Get timeline points failed:", pts, err)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `timelines`").WillReturnError(sql.ErrConnDone)
+	if _, err := db.GetTimelinePoints("abc", "cpu", 0, 1000, 0, 10); err != sql.ErrConnDone {
+		t.Fatal("Check get timeline points failed:", err)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM `timelines`").WillReturnRows(sqlmock.NewRows([]string{"timestamp", "data"}).AddRow("x", nil))
+	if _, err := db.GetTimelinePoints("abc", "cpu", 0, 1000, 0, 10); err == nil {
+		t.Fatal("Check scan timeline points failed")
+	}
+	mock.ExpectQuery("SELECT DISTINCT `code` FROM `timelines`").WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("cpu").AddRow("mem"))
+	if codes, err := db.GetTimelineCodes("abc", 0); err != nil || len(codes) != 2 {
+		t.Fatal("Get timeline codes failed:", codes, err)
+	}
+	mock.ExpectQuery("SELECT DISTINCT `code` FROM `timelines`").WillReturnError(sql.ErrConnDone)
+	if _, err := db.GetTimelineCodes("abc", 0); err != sql.ErrConnDone {
+		t.Fatal("Check get timeline codes failed:", err)
+	}
+	mock.ExpectQuery("SELECT DISTINCT `code` FROM `timelines`").WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow(nil))
+	if _, err := db.GetTimelineCodes("abc", 0); err == nil {
+		t.Fatal("Check scan timeline codes failed")
+	}
+}
@@ -42,6 +42,66 @@ func (s *nosql) SetOption(key string, value interface{}) error {
 	return ErrNotImplemented
 }
 
+func (s *nosql) GetPluginValue(ns string, key string) ([]byte, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *nosql) SetPluginValue(ns string, key string, value []byte, expires int64) error {
+	return ErrNotImplemented
+}
+
+func (s *nosql) IncrPluginValue(ns string, key string, delta int64, expires int64) (int64, error) {
+	return 0, ErrNotImplemented
+}
+
+func (s *nosql) DelPluginValue(ns string, key string) error {
+	return ErrNotImplemented
+}
+
+func (s *nosql) GetUserDND(uid string) ([]byte, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *nosql) SetUserDND(uid string, policy []byte) error {
+	return ErrNotImplemented
+}
+
+func (s *nosql) HoldMessage(m *HeldMessage) error {
+	return ErrNotImplemented
+}
+
+func (s *nosql) PopHeldMessages(deliver int64, limit int) ([]*HeldMessage, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *nosql) GetChannels(uid string) ([]*Channel, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *nosql) GetChannel(uid string, name string) (*Channel, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *nosql) UpsertChannel(uid string, ch *Channel) error {
+	return ErrNotImplemented
+}
+
+func (s *nosql) DeleteChannel(uid string, name string) error {
+	return ErrNotImplemented
+}
+
+func (s *nosql) AddTimelinePoint(uid string, p *TimelinePoint, expires int64, now int64) error {
+	return ErrNotImplemented
+}
+
+func (s *nosql) GetTimelinePoints(uid string, code string, since int64, until int64, now int64, limit int) ([]*TimelinePoint, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *nosql) GetTimelineCodes(uid string, now int64) ([]string, error) {
+	return nil, ErrNotImplemented
+}
+
 func (s *nosql) GetUser(uid string) (*User, error) {
 	data, err := crypto.Base32Encode.DecodeString(uid)
 	if err != nil {
@@ -76,6 +136,10 @@ func (s *nosql) GetDeviceKey(uuid string) ([]byte, error) {
 	return nil, ErrNotImplemented
 }
 
+func (s *nosql) GetUserDeviceKey(uid string, uuid string) ([]byte, error) {
+	return nil, ErrNotImplemented
+}
+
 func (s *nosql) GetDevices(uid string) ([]*Device, error) {
 	return nil, ErrNotImplemented
 }
@@ -46,10 +46,57 @@ func TestNoSQL(t *testing.T) {
 	if _, err := db.GetDeviceKey(""); err != ErrNotImplemented {
 		t.Fatal("Check GetDevices failed:", err)
 	}
+	if _, err := db.GetUserDeviceKey("", ""); err != ErrNotImplemented {
+		t.Fatal("Check GetUserDeviceKey failed:", err)
+	}
 	if _, err := db.GetDevices(""); err != ErrNotImplemented {
 		t.Fatal("Check GetDevices failed:", err)
 	}
-
+	if _, err := db.GetPluginValue("", ""); err != ErrNotImplemented {
+		t.Fatal("Check GetPluginValue failed:", err)
+	}
+	if err := db.SetPluginValue("", "", nil, 0); err != ErrNotImplemented {
+		t.Fatal("Check SetPluginValue failed:", err)
+	}
+	if _, err := db.IncrPluginValue("", "", 1, 0); err != ErrNotImplemented {
+		t.Fatal("Check IncrPluginValue failed:", err)
+	}
+	if err := db.DelPluginValue("", ""); err != ErrNotImplemented {
+		t.Fatal("Check DelPluginValue failed:", err)
+	}
+	if _, err := db.GetUserDND(""); err != ErrNotImplemented {
+		t.Fatal("Check GetUserDND failed:", err)
+	}
+	if err := db.SetUserDND("", nil); err != ErrNotImplemented {
+		t.Fatal("Check SetUserDND failed:", err)
+	}
+	if err := db.HoldMessage(&HeldMessage{}); err != ErrNotImplemented {
+		t.Fatal("Check HoldMessage failed:", err)
+	}
+	if _, err := db.PopHeldMessages(0, 1); err != ErrNotImplemented {
+		t.Fatal("Check PopHeldMessages failed:", err)
+	}
+	if _, err := db.GetChannels(""); err != ErrNotImplemented {
+		t.Fatal("Check GetChannels failed:", err)
+	}
+	if _, err := db.GetChannel("", ""); err != ErrNotImplemented {
+		t.Fatal("Check GetChannel failed:", err)
+	}
+	if err := db.UpsertChannel("", &Channel{}); err != ErrNotImplemented {
+		t.Fatal("Check UpsertChannel failed:", err)
+	}
+	if err := db.DeleteChannel("", ""); err != ErrNotImplemented {
+		t.Fatal("Check DeleteChannel failed:", err)
+	}
+	if err := db.AddTimelinePoint("", &TimelinePoint{}, 0, 0); err != ErrNotImplemented {
+		t.Fatal("Check AddTimelinePoint failed:", err)
+	}
+	if _, err := db.GetTimelinePoints("", "", 0, 0, 0, 1); err != ErrNotImplemented {
+		t.Fatal("Check GetTimelinePoints failed:", err)
+	}
+	if _, err := db.GetTimelineCodes("", 0); err != ErrNotImplemented {
+		t.Fatal("Check GetTimelineCodes failed:", err)
+	}
 }
 
 func TestNoSQLFailed(t *testing.T) {
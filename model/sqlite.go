@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"log"
 	"strings"
+	"time"
 
 	_ "modernc.org/sqlite" // sqlite driver
 )
@@ -47,6 +48,119 @@ func (s *sqlite) GetOption(key string, value interface{}) error {
 	return row.Scan(value)
 }
 
+func (s *sqlite) GetPluginValue(ns string, key string) ([]byte, error) {
+	var value []byte
+	row := s.db.QueryRow("SELECT `value` FROM `plugin_kv` WHERE `ns`=? AND `key`=? AND (`expires`=0 OR `expires`>?) LIMIT 1;", ns, key, time.Now().Unix())
+	err := row.Scan(&value)
+	return value, err
+}
+
+func (s *sqlite) SetPluginValue(ns string, key string, value []byte, expires int64) error {
+	if _, err := s.db.Exec("DELETE FROM `plugin_kv` WHERE `ns`=? AND `expires`>0 AND `expires`<=?;", ns, time.Now().Unix()); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("INSERT INTO `plugin_kv`(`ns`,`key`,`value`,`expires`) VALUES(?,?,?,?) ON CONFLICT(`ns`,`key`) DO UPDATE SET `value`=excluded.`value`,`expires`=excluded.`expires`;", ns, key, value, expires)
+	return err
+}
+
+func (s *sqlite) IncrPluginValue(ns string, key string, delta int64, expires int64) (int64, error) {
+	var value int64
+	now := time.Now().Unix()
+	row := s.db.QueryRow("INSERT INTO `plugin_kv`(`ns`,`key`,`value`,`expires`) VALUES(?,?,?,?) ON CONFLICT(`ns`,`key`) DO UPDATE SET `value`=CASE WHEN `plugin_kv`.`expires`>0 AND `plugin_kv`.`expires`<=? THEN excluded.`value` ELSE CAST(CAST(`plugin_kv`.`value` AS TEXT) AS INTEGER)+excluded.`value` END,`expires`=CASE WHEN `plugin_kv`.`expires`>0 AND `plugin_kv`.`expires`<=? THEN excluded.`expires` ELSE `plugin_kv`.`expires` END RETURNING CAST(`value` AS INTEGER);", ns, key, delta, expires, now, now)
+	err := row.Scan(&value)
+	return value, err
+}
+
+func (s *sqlite) DelPluginValue(ns string, key string) error {
+	_, err := s.db.Exec("DELETE FROM `plugin_kv` WHERE `ns`=? AND `key`=?;", ns, key)
+	return err
+}
+
+func (s *sqlite) GetUserDND(uid string) ([]byte, error) {
+	var policy []byte
+	row := s.db.QueryRow("SELECT `policy` FROM `user_dnd` WHERE `uid`=? LIMIT 1;", uid)
+	err := row.Scan(&policy)
+	return policy, err
+}
+
+func (s *sqlite) SetUserDND(uid string, policy []byte) error {
+	if len(policy) <= 0 {
+		_, err := s.db.Exec("DELETE FROM `user_dnd` WHERE `uid`=?;", uid)
+		return err
+	}
+	_, err := s.db.Exec("INSERT INTO `user_dnd`(`uid`,`policy`) VALUES(?,?) ON CONFLICT(`uid`) DO UPDATE SET `policy`=excluded.`policy`;", uid, policy)
+	return err
+}
+
+func (s *sqlite) HoldMessage(m *HeldMessage) error {
+	_, err := s.db.Exec("INSERT INTO `held_messages`(`uid`,`mode`,`deliver`,`timeline`,`data`,`options`) VALUES(?,?,?,?,?,?);", m.UID, m.Mode, m.Deliver, m.Timeline, m.Data, m.Options)
+	return err
+}
+
+func (s *sqlite) PopHeldMessages(deliver int64, limit int) ([]*HeldMessage, error) {
+	rows, err := s.db.Query("DELETE FROM `held_messages` WHERE `id` IN (SELECT `id` FROM `held_messages` WHERE `deliver`<=? ORDER BY `id` LIMIT ?) RETURNING `id`,`uid`,`mode`,`deliver`,`timeline`,`data`,`options`;", deliver, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanHeldMessages(rows)
+}
+
+func (s *sqlite) GetChannels(uid string) ([]*Channel, error) {
+	rows, err := s.db.Query("SELECT `name`,`icon`,`sound`,`priority`,`ilevel`,`ttl` FROM `channels` WHERE `uid`=? ORDER BY `name`;", uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanChannels(rows)
+}
+
+func (s *sqlite) GetChannel(uid string, name string) (*Channel, error) {
+	ch := &Channel{Name: name}
+	var ttl sql.NullInt64
+	row := s.db.QueryRow("SELECT `icon`,`sound`,`priority`,`ilevel`,`ttl` FROM `channels` WHERE `uid`=? AND `name`=? LIMIT 1;", uid, name)
+	if err := row.Scan(&ch.Icon, &ch.Sound, &ch.Priority, &ch.InterruptionLevel, &ttl); err != nil {
+		return nil, err
+	}
+	return ch.setTTL(ttl), nil
+}
+
+func (s *sqlite) UpsertChannel(uid string, ch *Channel) error {
+	_, err := s.db.Exec("INSERT INTO `channels`(`uid`,`name`,`icon`,`sound`,`priority`,`ilevel`,`ttl`) VALUES(?,?,?,?,?,?,?) ON CONFLICT(`uid`,`name`) DO UPDATE SET `icon`=excluded.`icon`,`sound`=excluded.`sound`,`priority`=excluded.`priority`,`ilevel`=excluded.`ilevel`,`ttl`=excluded.`ttl`,`lastupdate`=CURRENT_TIMESTAMP;", uid, ch.Name, ch.Icon, ch.Sound, ch.Priority, ch.InterruptionLevel, ch.TTL)
+	return err
+}
+
+func (s *sqlite) DeleteChannel(uid string, name string) error {
+	_, err := s.db.Exec("DELETE FROM `channels` WHERE `uid`=? AND `name`=?;", uid, name)
+	return err
+}
+
+func (s *sqlite) AddTimelinePoint(uid string, p *TimelinePoint, expires int64, now int64) error {
+	if _, err := s.db.Exec("DELETE FROM `timelines` WHERE `uid`=? AND `code`=? AND (`timestamp`<? OR (`expires`>0 AND `expires`<=?));", uid, p.Code, expires, now); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("INSERT INTO `timelines`(`uid`,`code`,`timestamp`,`data`,`expires`) VALUES(?,?,?,?,?);", uid, p.Code, p.Timestamp, p.Data, p.Expires)
+	return err
+}
+
+func (s *sqlite) GetTimelinePoints(uid string, code string, since int64, until int64, now int64, limit int) ([]*TimelinePoint, error) {
+	rows, err := s.db.Query("SELECT `timestamp`,`data` FROM `timelines` WHERE `uid`=? AND `code`=? AND `timestamp`>=? AND `timestamp`<=? AND (`expires`=0 OR `expires`>?) ORDER BY `timestamp` DESC LIMIT ?;", uid, code, since, until, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTimelinePoints(code, rows)
+}
+
+func (s *sqlite) GetTimelineCodes(uid string, now int64) ([]string, error) {
+	rows, err := s.db.Query("SELECT DISTINCT `code` FROM `timelines` WHERE `uid`=? AND (`expires`=0 OR `expires`>?) ORDER BY `code`;", uid, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTimelineCodes(rows)
+}
+
 func (s *sqlite) GetUser(uid string) (*User, error) {
 	u := &User{UID: uid}
 	row := s.db.QueryRow("SELECT `pubkey`, `seckey`, `flags` FROM `users` WHERE `uid`=? LIMIT 1;", uid)
@@ -83,6 +197,13 @@ func (s *sqlite) GetDeviceKey(uuid string) ([]byte, error) {
 	return key, err
 }
 
+func (s *sqlite) GetUserDeviceKey(uid string, uuid string) ([]byte, error) {
+	var key []byte
+	row := s.db.QueryRow("SELECT `key` FROM `devices` WHERE `uuid`=? AND `uid`=? LIMIT 1;", uuid, uid)
+	err := row.Scan(&key)
+	return key, err
+}
+
 func (s *sqlite) GetDevices(uid string) ([]*Device, error) {
 	devs := []*Device{}
 	rows, err := s.db.Query("SELECT `token`,`sandbox`,`type` FROM `devices` WHERE `uid`=? ORDER BY `lastupdate` DESC LIMIT 4;", uid)
@@ -106,6 +227,13 @@ func (s *sqlite) fixDB() error {
 		"CREATE TABLE IF NOT EXISTS `users`(`uid` TEXT PRIMARY KEY, `pubkey` BLOB UNIQUE, `seckey` BLOB, `flags` INTEGER DEFAULT 0, `lastupdate` TIMESTAMP DEFAULT CURRENT_TIMESTAMP, `createtime` TIMESTAMP DEFAULT CURRENT_TIMESTAMP);",
 		"CREATE TABLE IF NOT EXISTS `devices`(`uuid` TEXT PRIMARY KEY, `uid` TEXT, `key` BLOB, `type` INTEGER DEFAULT 0, `token` BLOB, `sandbox` INTEGER DEFAULT 0, `lastupdate` TIMESTAMP DEFAULT CURRENT_TIMESTAMP, `createtime` TIMESTAMP DEFAULT CURRENT_TIMESTAMP);",
 		"CREATE INDEX IF NOT EXISTS `idx_devices_uid` ON `devices`(`uid`);",
+		"CREATE TABLE IF NOT EXISTS `plugin_kv`(`ns` TEXT, `key` TEXT, `value` BLOB, `expires` INTEGER DEFAULT 0, PRIMARY KEY(`ns`,`key`));",
+		"CREATE TABLE IF NOT EXISTS `user_dnd`(`uid` TEXT PRIMARY KEY, `policy` BLOB);",
+		"CREATE TABLE IF NOT EXISTS `held_messages`(`id` INTEGER PRIMARY KEY AUTOINCREMENT, `uid` TEXT, `mode` INTEGER DEFAULT 0, `deliver` INTEGER, `timeline` INTEGER DEFAULT 0, `data` BLOB, `options` BLOB);",
+		"CREATE INDEX IF NOT EXISTS `idx_held_messages_deliver` ON `held_messages`(`deliver`);",
+		"CREATE TABLE IF NOT EXISTS `channels`(`uid` TEXT, `name` TEXT, `icon` TEXT DEFAULT '', `sound` TEXT DEFAULT '', `priority` INTEGER DEFAULT 0, `ilevel` TEXT DEFAULT '', `ttl` INTEGER DEFAULT NULL, `lastupdate` TIMESTAMP DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY(`uid`,`name`));",
+		"CREATE TABLE IF NOT EXISTS `timelines`(`id` INTEGER PRIMARY KEY AUTOINCREMENT, `uid` TEXT, `code` TEXT, `timestamp` INTEGER, `data` BLOB, `expires` INTEGER DEFAULT 0);",
+		"CREATE INDEX IF NOT EXISTS `idx_timelines_code` ON `timelines`(`uid`,`code`,`timestamp`);",
 	}
 	if _, err := s.db.Exec(strings.Join(sqls, "")); err != nil {
 		return err
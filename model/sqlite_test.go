@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"os"
 	"testing"
+	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 )
@@ -77,11 +78,58 @@ func TestSqliteDevice(t *testing.T) {
 	if len(devs) != 1 || string(devs[0].Token) != "PushToken" {
 		t.Fatal("Get push token failed")
 	}
+	if k, err := db.GetUserDeviceKey("abc", "xyz"); err != nil || string(k) != "key" {
+		t.Fatal("Get user device key failed:", err)
+	}
+	if _, err := db.GetUserDeviceKey("def", "xyz"); err != sql.ErrNoRows {
+		t.Fatal("Check device of other user failed:", err)
+	}
 	if err := db.UnbindDevice("abc", "xyz"); err != nil {
 		t.Fatal("Unbind device failed:", err)
 	}
 }
 
+func TestSqlitePluginValue(t *testing.T) {
+	db, _ := drivers["sqlite"]("sqlite://?mode=memory")
+	defer db.Close()
+	if _, err := db.GetPluginValue("github", "status"); err != sql.ErrNoRows {
+		t.Fatal("Check not found plugin value failed:", err)
+	}
+	if err := db.SetPluginValue("github", "status", []byte("ok"), 0); err != nil {
+		t.Fatal("Set plugin value failed:", err)
+	}
+	if v, err := db.GetPluginValue("github", "status"); err != nil || string(v) != "ok" {
+		t.Fatal("Get plugin value failed:", err)
+	}
+	if _, err := db.GetPluginValue("gitlab", "status"); err != sql.ErrNoRows {
+		t.Fatal("Check plugin value namespace failed:", err)
+	}
+	if err := db.SetPluginValue("github", "status", []byte("expired"), time.Now().Unix()-1); err != nil {
+		t.Fatal("Set expired plugin value failed:", err)
+	}
+	if _, err := db.GetPluginValue("github", "status"); err != sql.ErrNoRows {
+		t.Fatal("Check expired plugin value failed:", err)
+	}
+	if v, err := db.IncrPluginValue("github", "status", 2, 0); err != nil || v != 2 {
+		t.Fatal("Incr expired plugin value failed:", v, err)
+	}
+	if v, err := db.IncrPluginValue("github", "count", 1, time.Now().Unix()+60); err != nil || v != 1 {
+		t.Fatal("Incr plugin value failed:", v, err)
+	}
+	if v, err := db.IncrPluginValue("github", "count", 5, 0); err != nil || v != 6 {
+		t.Fatal("Incr plugin value again failed:", v, err)
+	}
+	if v, err := db.GetPluginValue("github", "count"); err != nil || string(v) != "6" {
+		t.Fatal("Get incr plugin value failed:", string(v), err)
+	}
+	if err := db.DelPluginValue("github", "count"); err != nil {
+		t.Fatal("Del plugin value failed:", err)
+	}
+	if _, err := db.GetPluginValue("github", "count"); err != sql.ErrNoRows {
+		t.Fatal("Check del plugin value failed:", err)
+	}
+}
+
 func TestSqliteGetDeviceFailed(t *testing.T) {
 	db, _ := drivers["sqlite"]("sqlite://?mode=memory")
 	defer db.Close()
@@ -155,3 +203,166 @@ func TestSQLiteFixDBFailed(t *testing.T) {
 		t.Fatal("Check fix db commit failed:", err)
 	}
 }
+
+func TestSqliteUserDND(t *testing.T) {
+	db, _ := drivers["sqlite"]("sqlite://?mode=memory")
+	defer db.Close()
+	if _, err := db.GetUserDND("abc"); err != sql.ErrNoRows {
+		t.Fatal("Check not found user dnd failed:", err)
+	}
+	if err := db.SetUserDND("abc", []byte("{}")); err != nil {
+		t.Fatal("Set user dnd failed:", err)
+	}
+	if err := db.SetUserDND("abc", []byte(`{"action":"hold"}`)); err != nil {
+		t.Fatal("Update user dnd failed:", err)
+	}
+	if v, err := db.GetUserDND("abc"); err != nil || string(v) != `{"action":"hold"}` {
+		t.Fatal("Get user dnd failed:", string(v), err)
+	}
+	if err := db.SetUserDND("abc", nil); err != nil {
+		t.Fatal("Clear user dnd failed:", err)
+	}
+	if _, err := db.GetUserDND("abc"); err != sql.ErrNoRows {
+		t.Fatal("Check clear user dnd failed:", err)
+	}
+}
+
+func TestSqliteHeldMessages(t *testing.T) {
+	db, _ := drivers["sqlite"]("sqlite://?mode=memory")
+	defer db.Close()
+	for i := 1; i <= 3; i++ {
+		m := NewMessage(&Token{}).TextContent("hello", "", "", "").SetTimeline(i == 2)
+		if err := db.HoldMessage(NewHeldMessage("abc", i, int64(i*100), m)); err != nil {
+			t.Fatal("Hold message failed:", err)
+		}
+	}
+	msgs, err := db.PopHeldMessages(50, 10)
+	if err != nil || len(msgs) != 0 {
+		t.Fatal("Check pop no held messages failed:", err)
+	}
+	msgs, err = db.PopHeldMessages(200, 10)
+	if err != nil || len(msgs) != 2 || msgs[0].Mode != 1 || msgs[1].Mode != 2 || !msgs[1].Timeline || msgs[0].UID != "abc" {
+		t.Fatal("Pop held messages failed:", err, msgs)
+	}
+	if m, err := msgs[1].Message(); err != nil || !m.IsTimeline() || len(m.Content) <= 0 {
+		t.Fatal("Check held message failed:", err)
+	}
+	if msgs, err := db.PopHeldMessages(200, 10); err != nil || len(msgs) != 0 {
+		t.Fatal("Check pop held messages again failed:", err)
+	}
+	if msgs, err := db.PopHeldMessages(300, 10); err != nil || len(msgs) != 1 || msgs[0].Deliver != 300 {
+		t.Fatal("Pop last held messages failed:", err)
+	}
+	if _, err := (&HeldMessage{Data: []byte{0xff}}).Message(); err == nil {
+		t.Fatal("Check invalid held message failed")
+	}
+	if _, err := (&HeldMessage{Options: []byte("{")}).Message(); err == nil {
+		t.Fatal("Check invalid held options failed")
+	}
+}
+
+func TestSqliteHeldMessageOptions(t *testing.T) {
+	db, _ := drivers["sqlite"]("sqlite://?mode=memory")
+	defer db.Close()
+	m := NewMessage(&Token{}).TextContent("hello", "", "", "").SetBatch(5 * time.Minute).SetTTL(time.Hour)
+	if err := db.HoldMessage(NewHeldMessage("abc", 1, 100, m)); err != nil {
+		t.Fatal("Hold message failed:", err)
+	}
+	if err := db.HoldMessage(NewHeldMessage("abc", 1, 100, NewMessage(&Token{}).TextContent("hello", "", "", ""))); err != nil {
+		t.Fatal("Hold message failed:", err)
+	}
+	msgs, err := db.PopHeldMessages(100, 10)
+	if err != nil || len(msgs) != 2 || len(msgs[1].Options) != 0 {
+		t.Fatal("Pop held messages failed:", err, msgs)
+	}
+	h, err := msgs[0].Message()
+	if err != nil || h.BatchWindow() != 5*time.Minute {
+		t.Fatal("Check held message options failed:", err)
+	}
+	if ttl, ok := h.TTL(); !ok || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Error("Check held message ttl failed:", ttl, ok)
+	}
+	if h, _ := msgs[1].Message(); h.BatchWindow() != 0 || h.Ack() != nil {
+		t.Error("Check held message without options failed")
+	} else if _, ok := h.TTL(); ok {
+		t.Error("Check held message without ttl failed")
+	}
+	expired, _ := (&HeldMessage{Options: []byte(`{"expires":1}`)}).Message()
+	if ttl, ok := expired.TTL(); !ok || ttl != 0 {
+		t.Error("Check expired held message ttl failed:", ttl, ok)
+	}
+}
+
+func TestSqliteChannels(t *testing.T) {
+	db, _ := drivers["sqlite"]("sqlite://?mode=memory")
+	defer db.Close()
+	if _, err := db.GetChannel("abc", "logs"); err != sql.ErrNoRows {
+		t.Fatal("Check not found channel failed:", err)
+	}
+	if err := db.UpsertChannel("abc", &Channel{Name: "logs", Sound: "bell"}); err != nil {
+		t.Fatal("Create channel failed:", err)
+	}
+	ttl := 0
+	if err := db.UpsertChannel("abc", &Channel{Name: "logs", Icon: "https://example.com/icon.png", Priority: 5, InterruptionLevel: "passive", TTL: &ttl}); err != nil {
+		t.Fatal("Update channel failed:", err)
+	}
+	db.UpsertChannel("abc", &Channel{Name: "alerts"}) // nolint: errcheck
+	db.UpsertChannel("def", &Channel{Name: "other"})  // nolint: errcheck
+	if ch, err := db.GetChannel("abc", "logs"); err != nil || ch.Name != "logs" || ch.Sound != "" || ch.Priority != 5 || ch.InterruptionLevel != "passive" || len(ch.Icon) <= 0 || ch.TTL == nil || *ch.TTL != 0 {
+		t.Fatal("Get channel failed:", ch, err)
+	}
+	if chs, err := db.GetChannels("abc"); err != nil || len(chs) != 2 || chs[0].Name != "alerts" || chs[1].Name != "logs" || chs[0].TTL != nil || chs[1].TTL == nil {
+		t.Fatal("Get channels failed:", chs, err)
+	}
+	if err := db.DeleteChannel("abc", "logs"); err != nil {
+		t.Fatal("Delete channel failed:", err)
+	}
+	if chs, err := db.GetChannels("abc"); err != nil || len(chs) != 1 {
+		t.Fatal("Check delete channel failed:", chs, err)
+	}
+}
+
+func TestSqliteTimelines(t *testing.T) {
+	db, _ := drivers["sqlite"]("sqlite://?mode=memory")
+	defer db.Close()
+	for i := 1; i <= 3; i++ {
+		p := &TimelinePoint{Code: "cpu", Timestamp: int64(i * 100), Data: []byte{byte(i)}}
+		if err := db.AddTimelinePoint("abc", p, 0, 0); err != nil {
+			t.Fatal("Add timeline point failed:", err)
+		}
+	}
+	db.AddTimelinePoint("abc", &TimelinePoint{Code: "mem", Timestamp: 100}, 0, 0)  // nolint: errcheck
+	db.AddTimelinePoint("def", &TimelinePoint{Code: "disk", Timestamp: 100}, 0, 0) // nolint: errcheck
+	if codes, err := db.GetTimelineCodes("abc", 0); err != nil || len(codes) != 2 || codes[0] != "cpu" || codes[1] != "mem" {
+		t.Fatal("Get timeline codes failed:", codes, err)
+	}
+	pts, err := db.GetTimelinePoints("abc", "cpu", 0, 1000, 0, 2)
+	if err != nil || len(pts) != 2 || pts[0].Timestamp != 200 || pts[1].Timestamp != 300 || pts[1].Data[0] != 3 || pts[0].Code != "cpu" {
+		t.Fatal("Get timeline points failed:", pts, err)
+	}
+	if pts, err := db.GetTimelinePoints("abc", "cpu", 150, 250, 0, 10); err != nil || len(pts) != 1 || pts[0].Timestamp != 200 {
+		t.Fatal("Get timeline points in range failed:", pts, err)
+	}
+	if err := db.AddTimelinePoint("abc", &TimelinePoint{Code: "cpu", Timestamp: 400}, 250, 0); err != nil {
+		t.Fatal("Add timeline point with expires failed:", err)
+	}
+	if pts, err := db.GetTimelinePoints("abc", "cpu", 0, 1000, 0, 10); err != nil || len(pts) != 2 || pts[0].Timestamp != 300 {
+		t.Fatal("Check expired timeline points failed:", pts, err)
+	}
+	if err := db.AddTimelinePoint("abc", &TimelinePoint{Code: "disk", Timestamp: 100, Expires: 2000}, 0, 1000); err != nil {
+		t.Fatal("Add timeline point with ttl failed:", err)
+	}
+	if pts, err := db.GetTimelinePoints("abc", "disk", 0, 1000, 1500, 10); err != nil || len(pts) != 1 || pts[0].Timestamp != 100 {
+		t.Fatal("Get timeline points with ttl failed:", pts, err)
+	}
+	if pts, err := db.GetTimelinePoints("abc", "disk", 0, 1000, 2000, 10); err != nil || len(pts) != 0 {
+		t.Fatal("Check timeline points out of ttl failed:", pts, err)
+	}
+	if codes, err := db.GetTimelineCodes("abc", 2000); err != nil || len(codes) != 2 {
+		t.Fatal("Check timeline codes out of ttl failed:", codes, err)
+	}
+	db.AddTimelinePoint("abc", &TimelinePoint{Code: "disk", Timestamp: 200}, 0, 2000) // nolint: errcheck
+	if pts, err := db.GetTimelinePoints("abc", "disk", 0, 1000, 0, 10); err != nil || len(pts) != 1 || pts[0].Timestamp != 200 {
+		t.Fatal("Check purge timeline points out of ttl failed:", pts, err)
+	}
+}
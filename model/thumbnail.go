@@ -15,3 +15,9 @@ func NewThumbnail(w int, h int) *Thumbnail {
 		preview: nil,
 	}
 }
+
+// SetPreview set preview image data
+func (t *Thumbnail) SetPreview(preview []byte) *Thumbnail {
+	t.preview = preview
+	return t
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/chanify/chanify/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// TimelinePoint is data point of timeline code kept in history
+type TimelinePoint struct {
+	Code      string
+	Timestamp int64
+	Data      []byte
+	Expires   int64 // ms, 0 to keep until history expires
+}
+
+// TimelinePoint return history point of timeline message, nil if message is not timeline
+func (m *Message) TimelinePoint() *TimelinePoint {
+	if !m.isTimeline {
+		return nil
+	}
+	var ctx pb.MsgContent
+	if err := proto.Unmarshal(m.Content, &ctx); err != nil || ctx.TimeContent == nil || len(ctx.TimeContent.Code) <= 0 {
+		return nil
+	}
+	data, _ := proto.Marshal(ctx.TimeContent)
+	return &TimelinePoint{
+		Code:      ctx.TimeContent.Code,
+		Timestamp: int64(ctx.TimeContent.Timestamp),
+		Data:      data,
+	}
+}
+
+// Items return timeline items of point
+func (p *TimelinePoint) Items() ([]*MsgTimeItem, error) {
+	var tc pb.TimeContent
+	if err := proto.Unmarshal(p.Data, &tc); err != nil {
+		return nil, err
+	}
+	items := []*MsgTimeItem{}
+	for _, ti := range tc.TimeItems {
+		item := &MsgTimeItem{Name: ti.Name, Unit: ti.Unit}
+		switch ti.ValueType {
+		case pb.ValueType_ValueTypeInteger:
+			item.Value = ti.IntegerValue
+		case pb.ValueType_ValueTypeDouble:
+			item.Value = ti.DoubleValue
+		case pb.ValueType_ValueTypeString:
+			item.Value = ti.StringValue
+		case pb.ValueType_ValueTypeBool:
+			item.Value = ti.BoolValue
+		default:
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func scanTimelinePoints(code string, rows *sql.Rows) ([]*TimelinePoint, error) {
+	pts := []*TimelinePoint{}
+	for rows.Next() {
+		p := &TimelinePoint{Code: code}
+		if err := rows.Scan(&p.Timestamp, &p.Data); err != nil {
+			return nil, err
+		}
+		pts = append(pts, p)
+	}
+	sort.Slice(pts, func(i, j int) bool { return pts[i].Timestamp < pts[j].Timestamp })
+	return pts, rows.Err()
+}
+
+func scanTimelineCodes(rows *sql.Rows) ([]string, error) {
+	codes := []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
@@ -0,0 +1,20 @@
+-- Message filter example
+-- Redact secrets from CI logs and raise priority for outage keywords
+
+msg.text = string.gsub(msg.text, "([Tt][Oo][Kk][Ee][Nn]=)%S+", "%1***")
+msg.text = string.gsub(msg.text, "([Pp][Aa][Ss][Ss][Ww][Oo][Rr][Dd]=)%S+", "%1***")
+
+local keyword = ctx:env("keyword") or "PROD DOWN"
+if string.find(msg.title .. "\n" .. msg.text, keyword, 1, true) then
+	msg.priority = 10
+	msg.interruption_level = "time-sensitive"
+	local channel = ctx:env("channel")
+	if channel then
+		msg.channel = channel
+	end
+end
+
+local mute = ctx:env("mute")
+if mute and string.find(msg.text, mute, 1, true) then
+	return false -- drop message
+end
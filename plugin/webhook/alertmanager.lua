@@ -0,0 +1,53 @@
+-- Prometheus Alertmanager Webhook
+-- Ref: https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+
+local json = require 'json'
+local alert = require 'alert'
+
+local req = ctx:request()
+local data = json.decode(req:body())
+if type(data) ~= "table" or type(data["alerts"]) ~= "table" then
+	return 400, "invalid alertmanager payload"
+end
+
+local status = data["status"] or "firing"
+local common = data["commonLabels"] or {}
+local group = data["groupLabels"] or {}
+local name = group["alertname"] or common["alertname"] or "Alert"
+local severity = common["severity"]
+local lines = {}
+local firing = 0
+
+for _, alert in ipairs(data["alerts"]) do
+	local labels = alert["labels"] or {}
+	local annotations = alert["annotations"] or {}
+	if alert["status"] == "firing" then
+		firing = firing + 1
+	end
+	if severity == nil then
+		severity = labels["severity"]
+	end
+	local line = annotations["summary"] or annotations["description"] or labels["alertname"] or ""
+	if labels["instance"] ~= nil then
+		line = string.format("%s (%s)", line, labels["instance"])
+	end
+	table.insert(lines, string.format("[%s] %s", string.upper(alert["status"] or status), line))
+end
+
+local title = ""
+if status == "resolved" then
+	title = string.format("[RESOLVED] %s", name)
+else
+	title = string.format("[FIRING:%d] %s", firing, name)
+end
+
+local priority, level = alert.level(severity, status == "resolved")
+local ret = ctx:send({
+	title = title,
+	text = table.concat(lines, "\n"),
+	sound = req:query("sound"),
+	priority = priority,
+	["interruption-level"] = level,
+})
+
+return 200, ret
@@ -0,0 +1,69 @@
+-- Gitea Webhook Event
+-- Ref: https://docs.gitea.com/usage/webhooks
+
+local hex = require 'hex'
+local json = require 'json'
+local crypto = require 'crypto'
+
+local req = ctx:request()
+local seckey = ctx:env("SECRET_TOKEN")
+if seckey ~= nil and seckey ~= "" then
+	local sign = hex.decode(req:header("X-Gitea-Signature"))
+	if not crypto.equal(crypto.hmac("sha256", seckey, req:body()), sign) then
+		return 401
+	end
+end
+
+local data = json.decode(req:body())
+if type(data) ~= "table" then
+	return 400, "invalid gitea payload"
+end
+
+local function short_ref(ref)
+	ref = ref or ""
+	return (ref:gsub("^refs/heads/", ""):gsub("^refs/tags/", ""))
+end
+
+local event = req:header("X-Gitea-Event")
+local repo = (data["repository"] or {})["full_name"] or ""
+local priority = 10
+local level = "active"
+local msg = ""
+
+if event == "push" then
+	local commits = data["commits"] or {}
+	local lines = { string.format("%s pushed %d commit(s) to %s", (data["pusher"] or {})["login"] or "", #commits, short_ref(data["ref"])) }
+	for _, commit in ipairs(commits) do
+		table.insert(lines, "- " .. (commit["message"] or ""):gsub("\n.*", ""))
+	end
+	msg = table.concat(lines, "\n")
+	priority = 5
+	level = "passive"
+elseif event == "create" or event == "delete" then
+	msg = string.format("%s %s %s", event, data["ref_type"] or "", data["ref"] or "")
+elseif event == "release" then
+	msg = string.format("release %s %s", (data["release"] or {})["tag_name"] or "", data["action"] or "")
+elseif event == "issues" then
+	local issue = data["issue"] or {}
+	msg = string.format("issue #%s %s:\n%s", tostring(issue["number"]), data["action"] or "", issue["title"] or "")
+elseif event == "issue_comment" then
+	local issue = data["issue"] or {}
+	msg = string.format("comment on #%s %s:\n%s", tostring(issue["number"]), issue["title"] or "", (data["comment"] or {})["body"] or "")
+	priority = 5
+	level = "passive"
+elseif event == "pull_request" then
+	local pr = data["pull_request"] or {}
+	msg = string.format("pull request #%s %s:\n%s", tostring(pr["number"]), data["action"] or "", pr["title"] or "")
+else
+	msg = string.format("%s %s", event or "", data["action"] or "")
+end
+
+local ret = ctx:send({
+	title = "Gitea " .. repo,
+	text = msg,
+	sound = req:query("sound"),
+	priority = priority,
+	["interruption-level"] = level,
+})
+
+return 200, ret
@@ -0,0 +1,82 @@
+-- GitLab Webhook Event
+-- Ref: https://docs.gitlab.com/ee/user/project/integrations/webhook_events.html
+
+local json = require 'json'
+local crypto = require 'crypto'
+
+local req = ctx:request()
+local seckey = ctx:env("SECRET_TOKEN")
+if seckey ~= nil and seckey ~= "" then
+	if not crypto.equal(seckey, req:header("X-Gitlab-Token")) then
+		return 401
+	end
+end
+
+local data = json.decode(req:body())
+if type(data) ~= "table" then
+	return 400, "invalid gitlab payload"
+end
+
+local function short_ref(ref)
+	ref = ref or ""
+	return (ref:gsub("^refs/heads/", ""):gsub("^refs/tags/", ""))
+end
+
+local event = req:header("X-Gitlab-Event")
+local project = (data["project"] or {})["path_with_namespace"] or (data["repository"] or {})["name"] or ""
+local attrs = data["object_attributes"] or {}
+local priority = 10
+local level = "active"
+local msg = ""
+
+if event == "Push Hook" then
+	local lines = { string.format("%s pushed %d commit(s) to %s", data["user_name"] or "", data["total_commits_count"] or 0, short_ref(data["ref"])) }
+	for _, commit in ipairs(data["commits"] or {}) do
+		table.insert(lines, "- " .. (commit["title"] or commit["message"] or ""))
+	end
+	msg = table.concat(lines, "\n")
+	priority = 5
+	level = "passive"
+elseif event == "Tag Push Hook" then
+	msg = string.format("%s pushed tag %s", data["user_name"] or "", short_ref(data["ref"]))
+elseif event == "Merge Request Hook" then
+	msg = string.format("merge request !%s %s:\n%s", tostring(attrs["iid"]), attrs["action"] or attrs["state"] or "", attrs["title"] or "")
+elseif event == "Issue Hook" then
+	msg = string.format("issue #%s %s:\n%s", tostring(attrs["iid"]), attrs["action"] or attrs["state"] or "", attrs["title"] or "")
+elseif event == "Note Hook" then
+	msg = string.format("%s commented:\n%s", (data["user"] or {})["name"] or "", attrs["note"] or "")
+	priority = 5
+	level = "passive"
+elseif event == "Pipeline Hook" then
+	local status = attrs["status"] or ""
+	msg = string.format("pipeline #%s %s on %s", tostring(attrs["id"]), status, attrs["ref"] or "")
+	if status == "failed" then
+		level = "time-sensitive"
+	elseif status == "success" or status == "running" or status == "pending" then
+		priority = 5
+		level = "passive"
+	end
+elseif event == "Job Hook" then
+	local status = data["build_status"] or ""
+	msg = string.format("job %s %s on %s", data["build_name"] or "", status, data["ref"] or "")
+	if status == "failed" then
+		level = "time-sensitive"
+	else
+		priority = 5
+		level = "passive"
+	end
+elseif event == "Release Hook" then
+	msg = string.format("release %s %s", data["tag"] or "", data["action"] or "")
+else
+	msg = string.format("%s %s", event or "", attrs["action"] or data["object_kind"] or "")
+end
+
+local ret = ctx:send({
+	title = "GitLab " .. project,
+	text = msg,
+	sound = req:query("sound"),
+	priority = priority,
+	["interruption-level"] = level,
+})
+
+return 200, ret
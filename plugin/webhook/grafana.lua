@@ -0,0 +1,64 @@
+-- Grafana Alerting Webhook
+-- Ref: https://grafana.com/docs/grafana/latest/alerting/configure-notifications/manage-contact-points/integrations/webhook-notifier/
+
+local json = require 'json'
+local alert = require 'alert'
+
+local req = ctx:request()
+local data = json.decode(req:body())
+if type(data) ~= "table" then
+	return 400, "invalid grafana payload"
+end
+
+local resolved = false
+local severity = nil
+local title = data["title"] or data["ruleName"] or "Grafana Alert"
+local lines = {}
+
+if type(data["alerts"]) == "table" then
+	-- Unified alerting
+	resolved = data["status"] == "resolved"
+	severity = (data["commonLabels"] or {})["severity"]
+	for _, alert in ipairs(data["alerts"]) do
+		local labels = alert["labels"] or {}
+		local annotations = alert["annotations"] or {}
+		if severity == nil then
+			severity = labels["severity"]
+		end
+		local line = annotations["summary"] or annotations["description"] or labels["alertname"] or ""
+		if alert["valueString"] ~= nil and alert["valueString"] ~= "" then
+			line = string.format("%s\n%s", line, alert["valueString"])
+		end
+		table.insert(lines, string.format("[%s] %s", string.upper(alert["status"] or ""), line))
+	end
+else
+	-- Legacy alerting
+	local state = data["state"] or ""
+	resolved = state == "ok"
+	if state == "alerting" then
+		severity = "critical"
+	elseif state == "no_data" or state == "pending" then
+		severity = "warning"
+	end
+	if data["message"] ~= nil then
+		table.insert(lines, data["message"])
+	end
+	for _, match in ipairs(data["evalMatches"] or {}) do
+		table.insert(lines, string.format("%s: %s", match["metric"] or "", tostring(match["value"])))
+	end
+end
+
+if #lines <= 0 and data["message"] ~= nil then
+	table.insert(lines, data["message"])
+end
+
+local priority, level = alert.level(severity, resolved)
+local ret = ctx:send({
+	title = title,
+	text = table.concat(lines, "\n"),
+	sound = req:query("sound"),
+	priority = priority,
+	["interruption-level"] = level,
+})
+
+return 200, ret
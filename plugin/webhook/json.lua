@@ -0,0 +1,63 @@
+-- Generic JSON Webhook
+-- Field paths can be set by query (title_field, text_field, severity_field, status_field)
+-- or env (TITLE_FIELD, TEXT_FIELD, SEVERITY_FIELD, STATUS_FIELD), e.g. "alert.labels.name"
+
+local json = require 'json'
+local alert = require 'alert'
+
+local req = ctx:request()
+local data = json.decode(req:body())
+if type(data) ~= "table" then
+	return 400, "invalid json payload"
+end
+
+local function lookup(path)
+	local value = data
+	for key in string.gmatch(path, "[^%.]+") do
+		if type(value) ~= "table" then
+			return nil
+		end
+		local v = value[key]
+		if v == nil and tonumber(key) ~= nil then
+			v = value[tonumber(key)]
+		end
+		value = v
+	end
+	if type(value) == "table" then
+		return json.encode(value)
+	elseif value ~= nil then
+		return tostring(value)
+	end
+	return nil
+end
+
+local function field(name, defaults)
+	local path = req:query(name .. "_field") or ctx:env(name .. "_field")
+	if path ~= nil and path ~= "" then
+		return lookup(path)
+	end
+	for _, key in ipairs(defaults) do
+		local value = lookup(key)
+		if value ~= nil then
+			return value
+		end
+	end
+	return nil
+end
+
+local title = field("title", { "title", "subject", "name" })
+local text = field("text", { "text", "message", "body", "description" }) or req:body()
+local severity = field("severity", { "severity", "level", "priority" })
+local status = string.lower(field("status", { "status", "state" }) or "")
+local resolved = status == "resolved" or status == "ok" or status == "up" or status == "success"
+
+local priority, level = alert.level(severity, resolved)
+local ret = ctx:send({
+	title = title,
+	text = text,
+	sound = req:query("sound"),
+	priority = priority,
+	["interruption-level"] = level,
+})
+
+return 200, ret
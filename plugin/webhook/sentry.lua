@@ -0,0 +1,80 @@
+-- Sentry Webhook
+-- Ref: https://docs.sentry.io/organization/integrations/integration-platform/webhooks/
+
+local hex = require 'hex'
+local json = require 'json'
+local alert = require 'alert'
+local crypto = require 'crypto'
+
+local req = ctx:request()
+local seckey = ctx:env("CLIENT_SECRET")
+if seckey ~= nil and seckey ~= "" then
+	local sign = hex.decode(req:header("Sentry-Hook-Signature"))
+	if not crypto.equal(crypto.hmac("sha256", seckey, req:body()), sign) then
+		return 401
+	end
+end
+
+local data = json.decode(req:body())
+if type(data) ~= "table" then
+	return 400, "invalid sentry payload"
+end
+
+local resource = req:header("Sentry-Hook-Resource")
+local action = data["action"] or ""
+local body = data["data"] or {}
+local title = "Sentry"
+local lines = {}
+local severity = nil
+local resolved = false
+
+local function add_event(event)
+	table.insert(lines, event["title"] or "")
+	if event["culprit"] ~= nil and event["culprit"] ~= "" then
+		table.insert(lines, event["culprit"])
+	end
+	local link = event["web_url"] or event["permalink"] or event["url"]
+	if link ~= nil and link ~= "" then
+		table.insert(lines, link)
+	end
+	severity = event["level"]
+end
+
+if resource == "issue" then
+	local issue = body["issue"] or {}
+	title = string.format("Sentry issue %s", action)
+	add_event(issue)
+	resolved = action == "resolved" or action == "ignored"
+elseif resource == "event_alert" then
+	local event = body["event"] or {}
+	title = string.format("Sentry alert %s", body["triggered_rule"] or "")
+	add_event(event)
+elseif resource == "metric_alert" then
+	title = body["description_title"] or "Sentry metric alert"
+	table.insert(lines, body["description_text"] or "")
+	severity = action
+	resolved = action == "resolved"
+elseif resource == "error" then
+	title = "Sentry error"
+	add_event(body["error"] or {})
+else
+	-- Legacy webhook plugin
+	title = string.format("Sentry %s", data["project_name"] or data["project"] or "")
+	add_event({
+		title = (data["event"] or {})["title"] or data["message"],
+		culprit = data["culprit"],
+		url = data["url"],
+		level = data["level"],
+	})
+end
+
+local priority, level = alert.level(severity, resolved)
+local ret = ctx:send({
+	title = title,
+	text = table.concat(lines, "\n"),
+	sound = req:query("sound"),
+	priority = priority,
+	["interruption-level"] = level,
+})
+
+return 200, ret
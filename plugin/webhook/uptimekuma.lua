@@ -0,0 +1,47 @@
+-- Uptime Kuma Webhook
+-- Ref: https://github.com/louislam/uptime-kuma/wiki/Notification-Methods
+
+local json = require 'json'
+
+local status_names = { [0] = "DOWN", [1] = "UP", [2] = "PENDING", [3] = "MAINTENANCE" }
+
+local req = ctx:request()
+local data = json.decode(req:body())
+if type(data) ~= "table" then
+	return 400, "invalid uptime kuma payload"
+end
+
+local monitor = data["monitor"] or {}
+local heartbeat = data["heartbeat"]
+local name = monitor["name"] or "Uptime Kuma"
+local text = data["msg"] or ""
+local priority = 10
+local level = "active"
+local title = name
+
+if type(heartbeat) == "table" then
+	local status = heartbeat["status"]
+	title = string.format("[%s] %s", status_names[status] or "UNKNOWN", name)
+	if heartbeat["msg"] ~= nil and heartbeat["msg"] ~= "" then
+		text = heartbeat["msg"]
+	end
+	if monitor["url"] ~= nil and monitor["url"] ~= "" then
+		text = string.format("%s\n%s", text, monitor["url"])
+	end
+	if status == 0 then
+		level = "time-sensitive"
+	elseif status == 1 or status == 3 then
+		priority = 5
+		level = "passive"
+	end
+end
+
+local ret = ctx:send({
+	title = title,
+	text = text,
+	sound = req:query("sound"),
+	priority = priority,
+	["interruption-level"] = level,
+})
+
+return 200, ret